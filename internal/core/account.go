@@ -0,0 +1,166 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const emailChangeUse = "email_change"
+
+var (
+	ErrEmailTaken              error = errors.New("email already in use")
+	ErrInvalidEmailChangeToken error = errors.New("invalid email change token")
+)
+
+// ChangePassword replaces the password of the user after checking the
+// current one. Every other session of the user is revoked, the caller
+// continues with the returned tokens.
+func (f *fuzzy) ChangePassword(userID uint, dto model.ChangePasswordDTO) (model.AuthTokens, error) {
+	if err := validator.New().Struct(dto); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("validate struct: %w", err)
+	}
+
+	user, err := f.repo.GetUserByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.AuthTokens{}, ErrUserNotFound
+	}
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+	if err := f.checkPassword(user, dto.CurrentPassword); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("check password: %w", err)
+	}
+
+	if err := f.checkPasswordPolicy(dto.NewPassword, user); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("check password policy: %w", err)
+	}
+	if err := f.setPassword(user, dto.NewPassword); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("set password: %w", err)
+	}
+	if err := f.repo.RevokeUserSessions(user.ID, TimeNow()); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo revoke user sessions: %w", err)
+	}
+
+	tokens, err := f.issueTokens(user, f.loginTokenInfo(user), uuid.NewString())
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("issue tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RequestEmailChange emails a confirmation link to the new address and a
+// notice to the current one. The email is only switched once the link
+// is opened.
+func (f *fuzzy) RequestEmailChange(userID uint, dto model.ChangeEmailDTO) error {
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+
+	user, err := f.repo.GetUserByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user by id: %w", err)
+	}
+	if err := f.checkPassword(user, dto.Password); err != nil {
+		return fmt.Errorf("check password: %w", err)
+	}
+
+	exists, err := f.UserExists(user.TenantID, dto.NewEmail)
+	if err != nil {
+		return fmt.Errorf("user exists: %w", err)
+	}
+	if exists {
+		return ErrEmailTaken
+	}
+
+	info := model.TokenInfo{
+		UserID:     user.ID,
+		Email:      user.Email,
+		Subject:    strconv.FormatUint(uint64(user.ID), 10),
+		Expiration: emailVerificationTTL,
+		Claims: map[string]any{
+			tokenUseClaim: emailChangeUse,
+			"new_email":   dto.NewEmail,
+		},
+	}
+	token, err := f.jwtIssuer.Sign(f.jwtIssuer.Generate(&info))
+	if err != nil {
+		return fmt.Errorf("sign email change token: %w", err)
+	}
+
+	recipient := user
+	recipient.Email = dto.NewEmail
+	data := map[string]any{
+		"Link": f.link("/api/me/email/confirm", url.Values{"token": {token}}),
+	}
+	if err := f.sendMail(recipient, "email_change_confirm", data); err != nil {
+		return fmt.Errorf("send email change confirmation: %w", err)
+	}
+	if err := f.sendMail(user, "email_change_notice", map[string]any{"NewEmail": dto.NewEmail}); err != nil {
+		return fmt.Errorf("send email change notice: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange switches the user's email to the address the token
+// was sent to and revokes all of the user's sessions
+func (f *fuzzy) ConfirmEmailChange(token string) error {
+	claims, err := f.jwtIssuer.Validate(token)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEmailChangeToken, err)
+	}
+	if claims[tokenUseClaim] != emailChangeUse {
+		return ErrInvalidEmailChangeToken
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return ErrInvalidEmailChangeToken
+	}
+	userID, ok := numericClaim(claims, "uid")
+	if !ok {
+		return ErrInvalidEmailChangeToken
+	}
+	oldEmail, _ := claims["email"].(string)
+	newEmail, _ := claims["new_email"].(string)
+
+	revoked, err := f.repo.IsTokenRevoked(jti)
+	if err != nil {
+		return fmt.Errorf("repo is token revoked: %w", err)
+	}
+	if revoked {
+		return fmt.Errorf("%w: token already used", ErrInvalidEmailChangeToken)
+	}
+
+	updated, err := f.repo.UpdateEmail(uint(userID), oldEmail, newEmail)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("repo update email: %w", err)
+	}
+	if !updated {
+		return fmt.Errorf("%w: email changed in the meantime", ErrInvalidEmailChangeToken)
+	}
+
+	used := model.RevokedToken{
+		JTI:       jti,
+		UserID:    uint(userID),
+		ExpiresAt: claimExpiration(claims),
+	}
+	if err := f.repo.Create(&used); err != nil {
+		return fmt.Errorf("create revoked token: %w", err)
+	}
+	if err := f.repo.RevokeUserSessions(uint(userID), TimeNow()); err != nil {
+		return fmt.Errorf("repo revoke user sessions: %w", err)
+	}
+	return nil
+}
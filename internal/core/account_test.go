@@ -0,0 +1,84 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_ChangePassword(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 4}, Email: "test@test.com"}
+	repo := newMemoryStore(t, user)
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")))
+
+	_, err := fuzzy.ChangePassword(user.ID, model.ChangePasswordDTO{CurrentPassword: "wrong", NewPassword: "new-secret"})
+	if !errors.Is(err, core.ErrInvalidPassword) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrInvalidPassword, err)
+	}
+
+	tokens, err := fuzzy.ChangePassword(user.ID, model.ChangePasswordDTO{CurrentPassword: "current", NewPassword: "new-secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := core.NewArgon2Hasher(core.DefaultArgon2Params).Verify(user.PasswordHash, "new-secret"); err != nil {
+		t.Fatalf("password not updated: %s", err)
+	}
+	if len(repo.sessionRevocations) != 1 {
+		t.Fatal("other sessions not revoked")
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatal("caller's session not renewed")
+	}
+}
+
+func Test_ChangeEmail(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 4}, Email: "old@test.com", FirstName: "Test"}
+	repo := newMemoryStore(t, user)
+	mailer := &mailerMock{}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")), core.WithMailer(mailer, "https://fuzzy.test"))
+
+	if err := fuzzy.RequestEmailChange(user.ID, model.ChangeEmailDTO{NewEmail: "old@test.com", Password: "current"}); !errors.Is(err, core.ErrEmailTaken) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrEmailTaken, err)
+	}
+
+	if err := fuzzy.RequestEmailChange(user.ID, model.ChangeEmailDTO{NewEmail: "new@test.com", Password: "current"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mailer.sent) != 2 {
+		t.Fatalf("expected two mails, got: %d", len(mailer.sent))
+	}
+	confirm, notice := mailer.sent[0], mailer.sent[1]
+	if confirm.To != "new@test.com" || confirm.Template != "email_change_confirm" {
+		t.Fatalf("unexpected confirmation mail: %+v", confirm)
+	}
+	if notice.To != "old@test.com" || notice.Template != "email_change_notice" || notice.Data["NewEmail"] != "new@test.com" {
+		t.Fatalf("unexpected notice mail: %+v", notice)
+	}
+	if user.Email != "old@test.com" {
+		t.Fatal("email switched before confirmation")
+	}
+
+	token := linkToken(t, confirm)
+	if _, err := fuzzy.VerifyUser(token); !errors.Is(err, core.ErrTokenUse) {
+		t.Fatalf("confirmation token accepted as access token: %v", err)
+	}
+	if err := fuzzy.ConfirmEmailChange(token); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if user.Email != "new@test.com" || !user.EmailVerified {
+		t.Fatalf("email not switched: %+v", user)
+	}
+	if len(repo.sessionRevocations) != 1 {
+		t.Fatal("sessions not revoked")
+	}
+	if err := fuzzy.ConfirmEmailChange(token); !errors.Is(err, core.ErrInvalidEmailChangeToken) {
+		t.Fatalf("confirmation token reused: %v", err)
+	}
+}
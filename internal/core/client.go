@@ -0,0 +1,168 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrClientAuthFailed         = &OAuthError{"invalid_client", "client authentication failed"}
+	ErrInvalidClientScope error = errors.New("scope is not allowed for clients")
+)
+
+// WithClientScopes sets the scopes machine clients can be registered with,
+// in addition to token:revoke. Permissions of the admin endpoints are never
+// allowed, those are granted to users through roles only.
+func WithClientScopes(scopes ...string) Option {
+	return func(f *fuzzy) {
+		for _, scope := range scopes {
+			if !isAdminPermission(scope) {
+				f.clientScopes[scope] = true
+			}
+		}
+	}
+}
+
+// dummyHash is compared against when a client or user does not exist,
+// so that failed lookups take as long as failed password checks
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), 10)
+
+// IssueToken serves the token endpoint for all supported grant types
+func (f *fuzzy) IssueToken(req model.TokenRequest) (model.TokenResponse, error) {
+	switch req.GrantType {
+	case "authorization_code":
+		return f.ExchangeAuthorizationCode(req)
+	case "client_credentials":
+		return f.ClientCredentials(req)
+	}
+	return model.TokenResponse{}, ErrUnsupportedGrantType
+}
+
+// ClientCredentials issues an access token to an authenticated machine client.
+// The token subject is the client id and the token has no user attached.
+func (f *fuzzy) ClientCredentials(req model.TokenRequest) (model.TokenResponse, error) {
+	if req.GrantType != "client_credentials" {
+		return model.TokenResponse{}, ErrUnsupportedGrantType
+	}
+
+	client, err := f.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return model.TokenResponse{}, fmt.Errorf("authenticate client: %w", err)
+	}
+
+	scope, ok := grantedScope(req.Scope, client.Scopes)
+	if !ok {
+		return model.TokenResponse{}, ErrInvalidScope
+	}
+
+	var info model.TokenInfo
+	info.Subject = client.ClientID
+	info.Issuer = f.issuer
+	info.Scope = scope
+	info.Expiration = f.accessTokenTTL
+	info.Claims = map[string]any{
+		"client_id": client.ClientID,
+		"principal": "client",
+	}
+
+	token, err := f.jwtIssuer.Sign(f.jwtIssuer.Generate(&info))
+	if err != nil {
+		return model.TokenResponse{}, fmt.Errorf("token signing: %w", err)
+	}
+
+	return model.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(f.accessTokenTTL.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// RegisterClient creates a machine client with a random id and secret
+func (f *fuzzy) RegisterClient(dto model.RegisterClientDTO) (model.ClientCredentials, error) {
+	validate := validator.New()
+	if err := validate.Struct(dto); err != nil {
+		return model.ClientCredentials{}, fmt.Errorf("validate struct: %w", err)
+	}
+	for _, scope := range dto.Scopes {
+		if !f.clientScopes[scope] {
+			return model.ClientCredentials{}, fmt.Errorf("%w: %q", ErrInvalidClientScope, scope)
+		}
+	}
+
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return model.ClientCredentials{}, fmt.Errorf("new client secret: %w", err)
+	}
+	hs, err := bcrypt.GenerateFromPassword([]byte(secret), 10)
+	if err != nil {
+		return model.ClientCredentials{}, fmt.Errorf("bcrypt generate secret hash: %w", err)
+	}
+
+	client := model.OAuthClient{
+		ClientID:   uuid.NewString(),
+		Name:       dto.Name,
+		SecretHash: string(hs),
+		Scopes:     strings.Join(dto.Scopes, " "),
+	}
+	if err := f.repo.Create(&client); err != nil {
+		return model.ClientCredentials{}, fmt.Errorf("create client: %w", err)
+	}
+
+	return model.ClientCredentials{
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		Scope:        client.Scopes,
+	}, nil
+}
+
+func (f *fuzzy) authenticateClient(clientID, secret string) (model.OAuthClient, error) {
+	if clientID == "" || secret == "" {
+		return model.OAuthClient{}, ErrClientAuthFailed
+	}
+
+	client, err := f.repo.GetOAuthClient(clientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(secret))
+		return model.OAuthClient{}, ErrClientAuthFailed
+	}
+	if err != nil {
+		return model.OAuthClient{}, fmt.Errorf("repo get client: %w", err)
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(secret))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return model.OAuthClient{}, ErrClientAuthFailed
+	}
+	if err != nil {
+		return model.OAuthClient{}, fmt.Errorf("compare secret and secret hash: %w", err)
+	}
+	return client, nil
+}
+
+// grantedScope checks the requested scope against the allowed one.
+// An empty request is granted all allowed scopes.
+func grantedScope(requested, allowed string) (string, bool) {
+	if strings.TrimSpace(requested) == "" {
+		return allowed, true
+	}
+	allowedScopes := strings.Fields(allowed)
+	var res []string
+	for _, s := range strings.Fields(requested) {
+		if !contains(allowedScopes, s) {
+			return "", false
+		}
+		if !contains(res, s) {
+			res = append(res, s)
+		}
+	}
+	return strings.Join(res, " "), true
+}
@@ -0,0 +1,108 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_ClientCredentials(t *testing.T) {
+	var stored model.OAuthClient
+	repo := &repositoryMock{
+		create: func(a any) error {
+			client, ok := a.(*model.OAuthClient)
+			if !ok {
+				return errors.New("unexpected entity")
+			}
+			stored = *client
+			return nil
+		},
+		getOAuthClient: func(clientID string) (model.OAuthClient, error) {
+			if clientID != stored.ClientID {
+				return model.OAuthClient{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+			}
+			return stored, nil
+		},
+		isTokenRevoked: func(jti string) (bool, error) {
+			return false, nil
+		},
+	}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")), core.WithClientScopes("reports:read", "reports:write"))
+
+	creds, err := fuzzy.RegisterClient(model.RegisterClientDTO{Name: "job", Scopes: []string{"reports:read", "reports:write"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stored.SecretHash == "" || stored.SecretHash == creds.ClientSecret {
+		t.Fatal("client secret not stored hashed")
+	}
+
+	resp, err := fuzzy.IssueToken(model.TokenRequest{
+		GrantType:    "client_credentials",
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		Scope:        "reports:read",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.Scope != "reports:read" {
+		t.Fatalf("scope does not match, expected: %s, got: %s", "reports:read", resp.Scope)
+	}
+
+	claims, err := fuzzy.VerifyUser(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if claims["sub"] != creds.ClientID || claims["client_id"] != creds.ClientID || claims["principal"] != "client" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if _, ok := claims["uid"]; ok {
+		t.Fatal("client token carries a user id")
+	}
+
+	cases := []struct {
+		name     string
+		req      model.TokenRequest
+		expected error
+	}{
+		{"wrong secret", model.TokenRequest{GrantType: "client_credentials", ClientID: creds.ClientID, ClientSecret: "wrong"}, core.ErrClientAuthFailed},
+		{"unknown client", model.TokenRequest{GrantType: "client_credentials", ClientID: "unknown", ClientSecret: "secret"}, core.ErrClientAuthFailed},
+		{"scope not allowed", model.TokenRequest{GrantType: "client_credentials", ClientID: creds.ClientID, ClientSecret: creds.ClientSecret, Scope: "admin"}, core.ErrInvalidScope},
+		{"unsupported grant", model.TokenRequest{GrantType: "password"}, core.ErrUnsupportedGrantType},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := fuzzy.IssueToken(c.req)
+			if !errors.Is(err, c.expected) {
+				t.Fatalf("unexpected error, expected: %s, got: %v", c.expected, err)
+			}
+		})
+	}
+}
+
+func Test_RegisterClient_ScopeAllowlist(t *testing.T) {
+	repo := &repositoryMock{
+		create: func(a any) error {
+			return nil
+		},
+	}
+	fuzzy := core.NewFuzzy(repo, nil, core.WithClientScopes("reports:read", core.PermissionUsersRead))
+
+	if _, err := fuzzy.RegisterClient(model.RegisterClientDTO{Name: "job", Scopes: []string{"reports:read", "token:revoke"}}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, scope := range []string{"reports:write", core.PermissionUsersRead, core.PermissionClientsManage} {
+		_, err := fuzzy.RegisterClient(model.RegisterClientDTO{Name: "job", Scopes: []string{scope}})
+		if !errors.Is(err, core.ErrInvalidClientScope) {
+			t.Fatalf("%s: expected invalid client scope, got %v", scope, err)
+		}
+	}
+}
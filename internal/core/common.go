@@ -3,25 +3,121 @@ package core
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+var TimeNow = time.Now
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type fuzzy struct {
-	repo      Repository
-	jwtIssuer JwtIssuer
+	repo            Repository
+	jwtIssuer       JwtIssuer
+	hasher          PasswordHasher
+	passwordPolicy  PasswordPolicy
+	breached        BreachedPasswords
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	issuer          string
+	oidcClients     map[string]model.OIDCClient
+	clientScopes    map[string]bool
+
+	requireVerifiedEmail bool
+	mailer               Mailer
+	publicURL            string
+	passwordResetTTL     time.Duration
+	passwordResetURL     string
+	magicLinkTTL         time.Duration
+	magicLinkURL         string
+	invitationTTL        time.Duration
+	invitationURL        string
+	totpIssuer           string
+	relyingParty         RelyingParty
+	throttle             ThrottleStore
+	loginThrottle        LoginThrottle
+	hideAccounts         bool
+	roles                RoleRepository
+	userSearch           UserSearcher
+	dummyHash            string
+	dummyHashOnce        sync.Once
+	logs                 *zap.SugaredLogger
+}
+
+// Option configures optional settings of the fuzzy type
+type Option func(*fuzzy)
+
+// WithTokenTTL sets the lifetime of the issued access and refresh tokens
+func WithTokenTTL(access, refresh time.Duration) Option {
+	return func(f *fuzzy) {
+		if access > 0 {
+			f.accessTokenTTL = access
+		}
+		if refresh > 0 {
+			f.refreshTokenTTL = refresh
+		}
+	}
+}
+
+// WithPasswordHasher replaces the default argon2id password hasher
+func WithPasswordHasher(hasher PasswordHasher) Option {
+	return func(f *fuzzy) {
+		f.hasher = hasher
+	}
+}
+
+// WithMailer sends messages through the mailer. Links in the messages
+// point to publicURL.
+func WithMailer(mailer Mailer, publicURL string) Option {
+	return func(f *fuzzy) {
+		f.mailer = mailer
+		f.publicURL = strings.TrimSuffix(publicURL, "/")
+	}
+}
+
+// WithLogger reports failures which do not fail the operation they happen
+// in, such as a password hash which could not be upgraded
+func WithLogger(logger *zap.SugaredLogger) Option {
+	return func(f *fuzzy) {
+		f.logs = logger
+	}
 }
 
 // NewFuzzy is a constructor function for the fuzzy type
-func NewFuzzy(db Repository, issuer JwtIssuer) *fuzzy {
-	return &fuzzy{
-		repo:      db,
-		jwtIssuer: issuer,
+func NewFuzzy(db Repository, issuer JwtIssuer, opts ...Option) *fuzzy {
+	f := &fuzzy{
+		repo:            db,
+		jwtIssuer:       issuer,
+		hasher:          NewArgon2Hasher(DefaultArgon2Params),
+		passwordPolicy:  DefaultPasswordPolicy,
+		accessTokenTTL:  defaultAccessTokenTTL,
+		refreshTokenTTL: defaultRefreshTokenTTL,
+		clientScopes:    map[string]bool{revokeAnyScope: true},
+
+		passwordResetTTL: defaultPasswordResetTTL,
+		magicLinkTTL:     defaultMagicLinkTTL,
+		invitationTTL:    defaultInvitationTTL,
+		totpIssuer:       defaultTOTPIssuer,
+		loginThrottle:    DefaultLoginThrottle,
+		logs:             zap.NewNop().Sugar(),
 	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
 
-func (f *fuzzy) UserExists(email string) (bool, error) {
-	_, err := f.repo.GetUser(email)
+func (f *fuzzy) UserExists(tenantID uint, email string) (bool, error) {
+	_, err := f.repo.GetUser(tenantID, email)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return false, nil
 	}
@@ -30,3 +126,33 @@ func (f *fuzzy) UserExists(email string) (bool, error) {
 	}
 	return true, nil
 }
+
+// sendMail delivers the named template to the user. It is a no-op
+// when no mailer is configured.
+func (f *fuzzy) sendMail(user model.User, template string, data map[string]any) error {
+	if f.mailer == nil {
+		return nil
+	}
+	if data == nil {
+		data = map[string]any{}
+	}
+	data["FirstName"] = user.FirstName
+	data["LastName"] = user.LastName
+	data["Email"] = user.Email
+
+	mail := model.Mail{
+		To:       user.Email,
+		Locale:   user.Locale,
+		Template: template,
+		Data:     data,
+	}
+	if err := f.mailer.Send(mail); err != nil {
+		return fmt.Errorf("mailer send %s: %w", template, err)
+	}
+	return nil
+}
+
+// link builds an absolute URL to the given path of the service
+func (f *fuzzy) link(path string, query url.Values) string {
+	return f.publicURL + path + "?" + query.Encode()
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/dgdraganov/fuzzy-user-api/internal/core"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
@@ -28,21 +29,196 @@ func (jwt *jwtIssuerMock) Validate(token string) (jwt.MapClaims, error) {
 }
 
 type repositoryMock struct {
-	create  func(any) error
-	getUser func(email string) (model.User, error)
+	create                   func(any) error
+	createUser               func(user *model.User, role string) error
+	getUser                  func(tenantID uint, email string) (model.User, error)
+	getUserByID              func(id uint) (model.User, error)
+	getRefreshToken          func(tokenHash string) (model.RefreshToken, error)
+	consumeRefreshToken      func(id uint, usedAt time.Time) (bool, error)
+	revokeRefreshTokenFamily func(familyID string, revokedAt time.Time) error
+	isTokenRevoked           func(jti string) (bool, error)
+	revokeUserSessions       func(userID uint, revokedAt time.Time) error
+	purgeExpiredTokens       func(now time.Time) error
+	getAuthorizationCode     func(codeHash string) (model.AuthorizationCode, error)
+	consumeAuthorizationCode func(id uint, usedAt time.Time, accessTokenID string) (bool, error)
+	getOAuthClient           func(clientID string) (model.OAuthClient, error)
+	markEmailVerified        func(userID uint, email string) (bool, error)
+	getPasswordResetToken    func(tokenHash string) (model.PasswordResetToken, error)
+	consumePasswordReset     func(id uint, usedAt time.Time) (bool, error)
+	updatePasswordHash       func(userID uint, passwordHash string) error
+	updateEmail              func(userID uint, oldEmail, newEmail string) (bool, error)
+	getPasswordHistory       func(userID uint, limit int) ([]model.PasswordHistory, error)
+	setTOTPSecret            func(userID uint, secret string) (bool, error)
+	enableTOTP               func(userID uint, enabledAt time.Time, step int64, codes []model.RecoveryCode) (bool, error)
+	updateTOTPStep           func(userID uint, step int64) (bool, error)
+	consumeRecoveryCode      func(userID uint, codeHash string, usedAt time.Time) (bool, error)
+	getWebAuthnCredentials   func(userID uint) ([]model.WebAuthnCredential, error)
+	getWebAuthnCredential    func(credentialID string) (model.WebAuthnCredential, error)
+	updateWebAuthnSignCount  func(id uint, signCount uint32, usedAt time.Time) (bool, error)
+	getOrganization          func(slug string) (model.Organization, error)
+	getOrganizationByID      func(id uint) (model.Organization, error)
+	getOrganizations         func() ([]model.Organization, error)
+	getMembership            func(organizationID, userID uint) (model.Membership, error)
+	getMembers               func(organizationID uint) ([]model.Member, error)
+	updateMemberRole         func(organizationID, userID uint, role string) (bool, error)
+	deleteMembership         func(organizationID, userID uint) (bool, error)
+	getInvitation            func(id uint) (model.Invitation, error)
+	getInvitationByToken     func(tokenID string) (model.Invitation, error)
+	getInvitations           func(organizationID uint) ([]model.Invitation, error)
+	renewInvitation          func(id uint, tokenID string, expiresAt time.Time) (bool, error)
+	revokeInvitation         func(id uint, revokedAt time.Time) (bool, error)
+	acceptInvitation         func(id uint, tokenID string, acceptedAt time.Time, user *model.User, role string) (bool, error)
+	getUsers                 func(filter model.UserFilter) ([]model.User, error)
+	getUserUnscoped          func(id uint) (model.User, error)
+	updateUser               func(id uint, fields map[string]any) (bool, error)
+	setUserDisabled          func(id uint, disabledAt *time.Time) (bool, error)
+	deleteUser               func(id uint, hard bool) (bool, error)
 }
 
 func (r *repositoryMock) Create(entity any) error {
 	return r.create(entity)
 }
-func (r *repositoryMock) GetUser(email string) (model.User, error) {
-	return r.getUser(email)
+func (r *repositoryMock) CreateUser(user *model.User, role string) error {
+	return r.createUser(user, role)
+}
+func (r *repositoryMock) GetUser(tenantID uint, email string) (model.User, error) {
+	return r.getUser(tenantID, email)
+}
+func (r *repositoryMock) GetUserByID(id uint) (model.User, error) {
+	return r.getUserByID(id)
+}
+func (r *repositoryMock) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	return r.getRefreshToken(tokenHash)
+}
+func (r *repositoryMock) ConsumeRefreshToken(id uint, usedAt time.Time) (bool, error) {
+	return r.consumeRefreshToken(id, usedAt)
+}
+func (r *repositoryMock) RevokeRefreshTokenFamily(familyID string, revokedAt time.Time) error {
+	return r.revokeRefreshTokenFamily(familyID, revokedAt)
+}
+func (r *repositoryMock) IsTokenRevoked(jti string) (bool, error) {
+	return r.isTokenRevoked(jti)
+}
+func (r *repositoryMock) RevokeUserSessions(userID uint, revokedAt time.Time) error {
+	return r.revokeUserSessions(userID, revokedAt)
+}
+func (r *repositoryMock) PurgeExpiredTokens(now time.Time) error {
+	return r.purgeExpiredTokens(now)
+}
+func (r *repositoryMock) GetAuthorizationCode(codeHash string) (model.AuthorizationCode, error) {
+	return r.getAuthorizationCode(codeHash)
+}
+func (r *repositoryMock) ConsumeAuthorizationCode(id uint, usedAt time.Time, accessTokenID string) (bool, error) {
+	return r.consumeAuthorizationCode(id, usedAt, accessTokenID)
+}
+func (r *repositoryMock) GetOAuthClient(clientID string) (model.OAuthClient, error) {
+	return r.getOAuthClient(clientID)
+}
+func (r *repositoryMock) MarkEmailVerified(userID uint, email string) (bool, error) {
+	return r.markEmailVerified(userID, email)
+}
+func (r *repositoryMock) GetPasswordResetToken(tokenHash string) (model.PasswordResetToken, error) {
+	return r.getPasswordResetToken(tokenHash)
+}
+func (r *repositoryMock) ConsumePasswordResetToken(id uint, usedAt time.Time) (bool, error) {
+	return r.consumePasswordReset(id, usedAt)
+}
+func (r *repositoryMock) UpdatePasswordHash(userID uint, passwordHash string) error {
+	return r.updatePasswordHash(userID, passwordHash)
+}
+func (r *repositoryMock) UpdateEmail(userID uint, oldEmail, newEmail string) (bool, error) {
+	return r.updateEmail(userID, oldEmail, newEmail)
+}
+func (r *repositoryMock) GetPasswordHistory(userID uint, limit int) ([]model.PasswordHistory, error) {
+	return r.getPasswordHistory(userID, limit)
+}
+
+func (r *repositoryMock) SetTOTPSecret(userID uint, secret string) (bool, error) {
+	return r.setTOTPSecret(userID, secret)
+}
+
+func (r *repositoryMock) EnableTOTP(userID uint, enabledAt time.Time, step int64, codes []model.RecoveryCode) (bool, error) {
+	return r.enableTOTP(userID, enabledAt, step, codes)
+}
+
+func (r *repositoryMock) UpdateTOTPStep(userID uint, step int64) (bool, error) {
+	return r.updateTOTPStep(userID, step)
+}
+
+func (r *repositoryMock) ConsumeRecoveryCode(userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	return r.consumeRecoveryCode(userID, codeHash, usedAt)
+}
+
+func (r *repositoryMock) GetWebAuthnCredentials(userID uint) ([]model.WebAuthnCredential, error) {
+	return r.getWebAuthnCredentials(userID)
+}
+
+func (r *repositoryMock) GetWebAuthnCredential(credentialID string) (model.WebAuthnCredential, error) {
+	return r.getWebAuthnCredential(credentialID)
+}
+
+func (r *repositoryMock) UpdateWebAuthnSignCount(id uint, signCount uint32, usedAt time.Time) (bool, error) {
+	return r.updateWebAuthnSignCount(id, signCount, usedAt)
+}
+func (r *repositoryMock) GetOrganization(slug string) (model.Organization, error) {
+	return r.getOrganization(slug)
+}
+func (r *repositoryMock) GetOrganizationByID(id uint) (model.Organization, error) {
+	return r.getOrganizationByID(id)
+}
+func (r *repositoryMock) GetOrganizations() ([]model.Organization, error) {
+	return r.getOrganizations()
+}
+func (r *repositoryMock) GetMembership(organizationID, userID uint) (model.Membership, error) {
+	return r.getMembership(organizationID, userID)
+}
+func (r *repositoryMock) GetMembers(organizationID uint) ([]model.Member, error) {
+	return r.getMembers(organizationID)
+}
+func (r *repositoryMock) UpdateMemberRole(organizationID, userID uint, role string) (bool, error) {
+	return r.updateMemberRole(organizationID, userID, role)
+}
+func (r *repositoryMock) DeleteMembership(organizationID, userID uint) (bool, error) {
+	return r.deleteMembership(organizationID, userID)
+}
+func (r *repositoryMock) GetInvitation(id uint) (model.Invitation, error) {
+	return r.getInvitation(id)
+}
+func (r *repositoryMock) GetInvitationByToken(tokenID string) (model.Invitation, error) {
+	return r.getInvitationByToken(tokenID)
+}
+func (r *repositoryMock) GetInvitations(organizationID uint) ([]model.Invitation, error) {
+	return r.getInvitations(organizationID)
+}
+func (r *repositoryMock) RenewInvitation(id uint, tokenID string, expiresAt time.Time) (bool, error) {
+	return r.renewInvitation(id, tokenID, expiresAt)
+}
+func (r *repositoryMock) RevokeInvitation(id uint, revokedAt time.Time) (bool, error) {
+	return r.revokeInvitation(id, revokedAt)
+}
+func (r *repositoryMock) AcceptInvitation(id uint, tokenID string, acceptedAt time.Time, user *model.User, role string) (bool, error) {
+	return r.acceptInvitation(id, tokenID, acceptedAt, user, role)
+}
+func (r *repositoryMock) GetUsers(filter model.UserFilter) ([]model.User, error) {
+	return r.getUsers(filter)
+}
+func (r *repositoryMock) GetUserUnscoped(id uint) (model.User, error) {
+	return r.getUserUnscoped(id)
+}
+func (r *repositoryMock) UpdateUser(id uint, fields map[string]any) (bool, error) {
+	return r.updateUser(id, fields)
+}
+func (r *repositoryMock) SetUserDisabled(id uint, disabledAt *time.Time) (bool, error) {
+	return r.setUserDisabled(id, disabledAt)
+}
+func (r *repositoryMock) DeleteUser(id uint, hard bool) (bool, error) {
+	return r.deleteUser(id, hard)
 }
 
 func Test_UserExists_True(t *testing.T) {
 	userEmail := "test@test.com"
 	repo := &repositoryMock{
-		getUser: func(email string) (model.User, error) {
+		getUser: func(tenantID uint, email string) (model.User, error) {
 			if email == userEmail {
 				return model.User{}, nil
 			}
@@ -51,7 +227,7 @@ func Test_UserExists_True(t *testing.T) {
 	}
 
 	fuzzy := core.NewFuzzy(repo, nil)
-	exists, err := fuzzy.UserExists(userEmail)
+	exists, err := fuzzy.UserExists(0, userEmail)
 
 	var expectedError error = nil
 	var expectedExists bool = true
@@ -66,7 +242,7 @@ func Test_UserExists_True(t *testing.T) {
 func Test_UserExists_False(t *testing.T) {
 	userEmail := "test@test.com"
 	repo := &repositoryMock{
-		getUser: func(email string) (model.User, error) {
+		getUser: func(tenantID uint, email string) (model.User, error) {
 			if email == userEmail {
 				return model.User{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
 			}
@@ -75,7 +251,7 @@ func Test_UserExists_False(t *testing.T) {
 	}
 
 	fuzzy := core.NewFuzzy(repo, nil)
-	exists, err := fuzzy.UserExists(userEmail)
+	exists, err := fuzzy.UserExists(0, userEmail)
 
 	var expectedError error = nil
 	var expectedExists bool = false
@@ -91,7 +267,7 @@ func Test_UserExists_Error(t *testing.T) {
 	userEmail := "test@test.com"
 	expectedError := errors.New("fake error")
 	repo := &repositoryMock{
-		getUser: func(email string) (model.User, error) {
+		getUser: func(tenantID uint, email string) (model.User, error) {
 			if email == userEmail {
 				return model.User{}, expectedError
 			}
@@ -100,7 +276,7 @@ func Test_UserExists_Error(t *testing.T) {
 	}
 
 	fuzzy := core.NewFuzzy(repo, nil)
-	exists, err := fuzzy.UserExists(userEmail)
+	exists, err := fuzzy.UserExists(0, userEmail)
 
 	var expectedExists bool = false
 	if !errors.Is(err, expectedError) {
@@ -116,12 +292,12 @@ func Test_RegisterUser_Success(t *testing.T) {
 		FirstName: "Penko",
 		LastName:  "Penkov",
 		Email:     "test@gmail.com",
-		Password:  "testPass",
+		Password:  "s3curePass",
 	}
 	var expected error
 
 	repoMock := repositoryMock{
-		create: func(a any) error {
+		createUser: func(user *model.User, role string) error {
 			return nil
 		},
 	}
@@ -137,14 +313,13 @@ func Test_RegisterUser_Failed(t *testing.T) {
 		FirstName: "Penko",
 		LastName:  "Penkov",
 		Email:     "test@gmainl.com",
-		Password:  "testPass",
+		Password:  "s3curePass",
 	}
 
 	var expected error = errors.New("expected mock error")
 	repoMock := repositoryMock{
-		create: func(a any) error {
-			us, ok := a.(*model.User)
-			if ok && us.Email == dto.Email {
+		createUser: func(user *model.User, role string) error {
+			if user.Email == dto.Email {
 				return expected
 			}
 			return errors.New("unexpected error")
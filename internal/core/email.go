@@ -0,0 +1,120 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	emailVerificationUse = "email_verification"
+)
+
+var (
+	ErrEmailNotVerified         error = errors.New("email address not verified")
+	ErrInvalidVerificationToken error = errors.New("invalid email verification token")
+	ErrEmailVerificationNotSent error = errors.New("email verification not sent")
+)
+
+// WithEmailVerification makes LoginUser refuse to issue tokens to users
+// who have not verified their email address yet
+func WithEmailVerification(required bool) Option {
+	return func(f *fuzzy) {
+		f.requireVerifiedEmail = required
+	}
+}
+
+// SendEmailVerification issues a new verification token for the user with
+// the given email. Unknown and already verified users are silently ignored.
+func (f *fuzzy) SendEmailVerification(tenantID uint, email string) error {
+	user, err := f.repo.GetUser(tenantID, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user: %w", err)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	return f.sendEmailVerification(user)
+}
+
+func (f *fuzzy) sendEmailVerification(user model.User) error {
+	if f.mailer == nil {
+		return nil
+	}
+
+	info := model.TokenInfo{
+		UserID:     user.ID,
+		Email:      user.Email,
+		Subject:    strconv.FormatUint(uint64(user.ID), 10),
+		Expiration: emailVerificationTTL,
+		Claims:     map[string]any{tokenUseClaim: emailVerificationUse},
+	}
+	token, err := f.jwtIssuer.Sign(f.jwtIssuer.Generate(&info))
+	if err != nil {
+		return fmt.Errorf("sign verification token: %w", err)
+	}
+
+	data := map[string]any{
+		"Link": f.link("/api/verify-email", url.Values{"token": {token}}),
+	}
+	if err := f.sendMail(user, "email_verification", data); err != nil {
+		return fmt.Errorf("%w: %w", ErrEmailVerificationNotSent, err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the email address the token was issued for as verified.
+// Each token can be used once and only while the user keeps that address.
+func (f *fuzzy) VerifyEmail(token string) error {
+	claims, err := f.jwtIssuer.Validate(token)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidVerificationToken, err)
+	}
+	if claims[tokenUseClaim] != emailVerificationUse {
+		return ErrInvalidVerificationToken
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return ErrInvalidVerificationToken
+	}
+	userID, ok := numericClaim(claims, "uid")
+	if !ok {
+		return ErrInvalidVerificationToken
+	}
+	email, _ := claims["email"].(string)
+
+	revoked, err := f.repo.IsTokenRevoked(jti)
+	if err != nil {
+		return fmt.Errorf("repo is token revoked: %w", err)
+	}
+	if revoked {
+		return fmt.Errorf("%w: token already used", ErrInvalidVerificationToken)
+	}
+
+	verified, err := f.repo.MarkEmailVerified(uint(userID), email)
+	if err != nil {
+		return fmt.Errorf("repo mark email verified: %w", err)
+	}
+	if !verified {
+		return fmt.Errorf("%w: email changed or already verified", ErrInvalidVerificationToken)
+	}
+
+	used := model.RevokedToken{
+		JTI:       jti,
+		UserID:    uint(userID),
+		ExpiresAt: claimExpiration(claims),
+	}
+	if err := f.repo.Create(&used); err != nil {
+		return fmt.Errorf("create revoked token: %w", err)
+	}
+	return nil
+}
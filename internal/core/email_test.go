@@ -0,0 +1,126 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+type mailerMock struct {
+	sent []model.Mail
+}
+
+func (m *mailerMock) Send(mail model.Mail) error {
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+// linkToken returns the token query parameter of the link in the mail
+func linkToken(t *testing.T, mail model.Mail) string {
+	t.Helper()
+	link, err := url.Parse(fmt.Sprint(mail.Data["Link"]))
+	if err != nil {
+		t.Fatalf("invalid link: %s", err)
+	}
+	return link.Query().Get("token")
+}
+
+func Test_EmailVerification(t *testing.T) {
+	var user model.User
+	used := map[string]bool{}
+	repo := &repositoryMock{
+		createUser: func(u *model.User, role string) error {
+			u.ID = 7
+			user = *u
+			return nil
+		},
+		create: func(a any) error {
+			switch e := a.(type) {
+			case *model.RevokedToken:
+				used[e.JTI] = true
+			case *model.RefreshToken:
+			default:
+				return errors.New("unexpected entity")
+			}
+			return nil
+		},
+		getUser: func(tenantID uint, email string) (model.User, error) {
+			if email != user.Email {
+				return model.User{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+			}
+			return user, nil
+		},
+		isTokenRevoked: func(jti string) (bool, error) {
+			return used[jti], nil
+		},
+		markEmailVerified: func(userID uint, email string) (bool, error) {
+			if userID != user.ID || email != user.Email || user.EmailVerified {
+				return false, nil
+			}
+			user.EmailVerified = true
+			return true, nil
+		},
+	}
+	mailer := &mailerMock{}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithMailer(mailer, "https://fuzzy.test/"),
+		core.WithEmailVerification(true),
+	)
+
+	dto := model.RegisterDTO{FirstName: "Test", LastName: "User", Email: "test@test.com", Password: "password", Locale: "bg"}
+	if err := fuzzy.RegisterUser(dto); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected one verification mail, got: %d", len(mailer.sent))
+	}
+	mail := mailer.sent[0]
+	if mail.To != dto.Email || mail.Locale != "bg" || mail.Template != "email_verification" {
+		t.Fatalf("unexpected mail: %+v", mail)
+	}
+	if !strings.HasPrefix(fmt.Sprint(mail.Data["Link"]), "https://fuzzy.test/api/verify-email?token=") {
+		t.Fatalf("unexpected verification link: %s", mail.Data["Link"])
+	}
+	token := linkToken(t, mail)
+
+	login := model.LoginDTO{Email: dto.Email, Password: dto.Password}
+	if _, err := fuzzy.LoginUser(login); !errors.Is(err, core.ErrEmailNotVerified) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrEmailNotVerified, err)
+	}
+
+	if _, err := fuzzy.VerifyUser(token); !errors.Is(err, core.ErrTokenUse) {
+		t.Fatalf("verification token accepted as access token: %v", err)
+	}
+
+	if err := fuzzy.VerifyEmail(token); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !user.EmailVerified {
+		t.Fatal("email not marked as verified")
+	}
+	if err := fuzzy.VerifyEmail(token); !errors.Is(err, core.ErrInvalidVerificationToken) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrInvalidVerificationToken, err)
+	}
+
+	if _, err := fuzzy.LoginUser(login); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := fuzzy.SendEmailVerification(0, dto.Email); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatal("verification mail sent to a verified user")
+	}
+}
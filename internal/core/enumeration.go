@@ -0,0 +1,56 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var ErrUserExists error = errors.New("user already exists")
+
+// WithAccountHiding makes RegisterUser and LoginUser answer the same way
+// for known and unknown email addresses. Registering a taken address
+// succeeds on the surface and mails a notice to the owner instead, and
+// logins with unknown addresses fail like a wrong password after the same
+// hashing work.
+func WithAccountHiding(enabled bool) Option {
+	return func(f *fuzzy) {
+		f.hideAccounts = enabled
+	}
+}
+
+// registrationAttempt handles a registration for an email address which
+// already has an account
+func (f *fuzzy) registrationAttempt(tenantID uint, email string) error {
+	if !f.hideAccounts {
+		return fmt.Errorf("%w: %s", ErrUserExists, email)
+	}
+	user, err := f.repo.GetUser(tenantID, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// soft deleted accounts keep their address taken
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user: %w", err)
+	}
+	if err := f.sendMail(user, "registration_attempt", nil); err != nil {
+		// reported like the verification mail of a new account would be
+		return fmt.Errorf("%w: %w", ErrEmailVerificationNotSent, err)
+	}
+	return nil
+}
+
+// dummyPasswordCheck spends the time of a password verification when there
+// is no hash to verify against, so failed logins for unknown accounts take
+// as long as those with a wrong password
+func (f *fuzzy) dummyPasswordCheck(password string) {
+	f.dummyHashOnce.Do(func() {
+		secret, err := newOpaqueToken()
+		if err != nil {
+			return
+		}
+		f.dummyHash, _ = f.hasher.Hash(secret)
+	})
+	_ = f.hasher.Verify(f.dummyHash, password)
+}
@@ -0,0 +1,70 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_RegisterUser_Existing(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 3}, FirstName: "Penko", Email: "test@test.com"}
+	dto := model.RegisterDTO{FirstName: "Other", LastName: "Person", Email: user.Email, Password: "s3curePass"}
+
+	fuzzy := core.NewFuzzy(newMemoryStore(t, user), nil)
+	if err := fuzzy.RegisterUser(dto); !errors.Is(err, core.ErrUserExists) {
+		t.Fatalf("expected user exists, got %v", err)
+	}
+
+	mailer := &mailerMock{}
+	jwtgen.TimeNow = time.Now
+	fuzzy = core.NewFuzzy(
+		newMemoryStore(t, user),
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithMailer(mailer, "https://fuzzy.test"),
+		core.WithAccountHiding(true),
+	)
+	if err := fuzzy.RegisterUser(dto); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected 1 mail, got %d", len(mailer.sent))
+	}
+	if notice := mailer.sent[0]; notice.To != user.Email || notice.Template != "registration_attempt" || notice.Data["FirstName"] != "Penko" {
+		t.Fatalf("unexpected notice mail: %+v", notice)
+	}
+
+	dto.Email = "new@test.com"
+	if err := fuzzy.RegisterUser(dto); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if verification := mailer.sent[1]; verification.To != "new@test.com" || verification.Template != "email_verification" {
+		t.Fatalf("unexpected verification mail: %+v", verification)
+	}
+}
+
+func Test_LoginUser_UnknownAccountHidden(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 3}, Email: "test@test.com"}
+	repo := newMemoryStore(t, user)
+	fuzzy := core.NewFuzzy(repo, nil, core.WithAccountHiding(true))
+
+	_, unknown := fuzzy.LoginUser(model.LoginDTO{Email: "unknown@test.com", Password: "current"})
+	_, wrong := fuzzy.LoginUser(model.LoginDTO{Email: user.Email, Password: "wrong"})
+	if !errors.Is(unknown, core.ErrInvalidPassword) || !errors.Is(wrong, core.ErrInvalidPassword) {
+		t.Fatalf("expected invalid password for both, got %v and %v", unknown, wrong)
+	}
+}
+
+func Test_LoginUser_UnknownAccount(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 3}, Email: "test@test.com"}
+	fuzzy := core.NewFuzzy(newMemoryStore(t, user), nil)
+
+	_, err := fuzzy.LoginUser(model.LoginDTO{Email: "unknown@test.com", Password: "current"})
+	if !errors.Is(err, core.ErrUserNotFound) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", core.ErrUserNotFound, err)
+	}
+}
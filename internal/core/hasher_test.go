@@ -0,0 +1,133 @@
+package core_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var testArgon2Params = core.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func Test_Argon2Hasher(t *testing.T) {
+	hasher := core.NewArgon2Hasher(testArgon2Params)
+
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash format: %s", hash)
+	}
+	if err := hasher.Verify(hash, "password"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := hasher.Verify(hash, "wrong"); !errors.Is(err, core.ErrInvalidPassword) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrInvalidPassword, err)
+	}
+	if hasher.NeedsRehash(hash) {
+		t.Fatal("current hash reported as outdated")
+	}
+
+	stronger := core.NewArgon2Hasher(core.Argon2Params{Memory: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	if !stronger.NeedsRehash(hash) {
+		t.Fatal("hash with outdated parameters not reported")
+	}
+	if err := stronger.Verify(hash, "password"); err != nil {
+		t.Fatalf("hash with outdated parameters not verified: %s", err)
+	}
+
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err := hasher.Verify(string(legacy), "password"); err != nil {
+		t.Fatalf("bcrypt hash not verified: %s", err)
+	}
+	if err := hasher.Verify(string(legacy), "wrong"); !errors.Is(err, core.ErrInvalidPassword) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrInvalidPassword, err)
+	}
+	if !hasher.NeedsRehash(string(legacy)) {
+		t.Fatal("bcrypt hash not reported as outdated")
+	}
+
+	if err := hasher.Verify("plain", "plain"); !errors.Is(err, core.ErrUnknownHashFormat) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrUnknownHashFormat, err)
+	}
+}
+
+func Test_LoginUser_RehashesBcrypt(t *testing.T) {
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	user := model.User{Model: gorm.Model{ID: 9}, Email: "test@test.com", PasswordHash: string(legacy)}
+	updates := 0
+	repo := &repositoryMock{
+		getUser: func(tenantID uint, email string) (model.User, error) {
+			return user, nil
+		},
+		updatePasswordHash: func(userID uint, passwordHash string) error {
+			updates++
+			user.PasswordHash = passwordHash
+			return nil
+		},
+		create: func(a any) error {
+			return nil
+		},
+	}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithPasswordHasher(core.NewArgon2Hasher(testArgon2Params)),
+	)
+
+	login := model.LoginDTO{Email: user.Email, Password: "password"}
+	if _, err := fuzzy.LoginUser(login); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if updates != 1 || !strings.HasPrefix(user.PasswordHash, "$argon2id$") {
+		t.Fatalf("hash not upgraded: %s", user.PasswordHash)
+	}
+
+	if _, err := fuzzy.LoginUser(login); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if updates != 1 {
+		t.Fatal("current hash rehashed")
+	}
+}
+
+func Test_LoginUser_RehashFailureLogged(t *testing.T) {
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	user := model.User{Model: gorm.Model{ID: 9}, Email: "test@test.com", PasswordHash: string(legacy)}
+	repo := &repositoryMock{
+		getUser: func(tenantID uint, email string) (model.User, error) {
+			return user, nil
+		},
+		updatePasswordHash: func(userID uint, passwordHash string) error {
+			return errors.New("db unavailable")
+		},
+		create: func(a any) error {
+			return nil
+		},
+	}
+	observed, logs := observer.New(zap.WarnLevel)
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithPasswordHasher(core.NewArgon2Hasher(testArgon2Params)),
+		core.WithLogger(zap.New(observed).Sugar()),
+	)
+
+	if _, err := fuzzy.LoginUser(model.LoginDTO{Email: user.Email, Password: "password"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if logs.FilterMessage("password hash upgrade failed").Len() != 1 {
+		t.Fatalf("rehash failure not logged: %v", logs.All())
+	}
+}
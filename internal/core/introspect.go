@@ -0,0 +1,109 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+// revokeAnyScope lets a client revoke tokens which were not issued to it
+const revokeAnyScope = "token:revoke"
+
+var ErrUnauthorizedClient = &OAuthError{"unauthorized_client", "the token was not issued to the client"}
+
+// IntrospectToken reports whether the token is active as defined in RFC 7662.
+// Access tokens are described by their claims, refresh tokens by their record.
+func (f *fuzzy) IntrospectToken(req model.TokenActionRequest) (map[string]any, error) {
+	if _, err := f.authenticateClient(req.ClientID, req.ClientSecret); err != nil {
+		return nil, fmt.Errorf("authenticate client: %w", err)
+	}
+
+	inactive := map[string]any{"active": false}
+	if req.TokenTypeHint != "refresh_token" {
+		if res, ok := f.introspectAccessToken(req.Token); ok {
+			return res, nil
+		}
+	}
+
+	stored, err := f.repo.GetRefreshToken(hashToken(req.Token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// the hint only orders the lookups, it does not rule out access
+		// tokens (RFC 7662 section 2.1)
+		if req.TokenTypeHint == "refresh_token" {
+			if res, ok := f.introspectAccessToken(req.Token); ok {
+				return res, nil
+			}
+		}
+		return inactive, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repo get refresh token: %w", err)
+	}
+	if stored.UsedAt != nil || stored.RevokedAt != nil || !TimeNow().Before(stored.ExpiresAt) {
+		return inactive, nil
+	}
+	return map[string]any{
+		"active":     true,
+		"token_type": "refresh_token",
+		"sub":        strconv.FormatUint(uint64(stored.UserID), 10),
+		"uid":        stored.UserID,
+		"iat":        stored.CreatedAt.Unix(),
+		"exp":        stored.ExpiresAt.Unix(),
+	}, nil
+}
+
+// introspectAccessToken describes the token by its claims when it is an
+// active access token
+func (f *fuzzy) introspectAccessToken(token string) (map[string]any, bool) {
+	claims, err := f.verifyAccessToken(token, "access", oidcAccessUse)
+	if err != nil {
+		return nil, false
+	}
+	res := map[string]any{"active": true, "token_type": "Bearer"}
+	for k, v := range claims {
+		res[k] = v
+	}
+	if email, ok := claims["email"]; ok {
+		res["username"] = email
+	}
+	return res, true
+}
+
+// RevokeToken revokes an access or a refresh token as defined in RFC 7009.
+// Unknown and already invalid tokens are not reported as errors.
+func (f *fuzzy) RevokeToken(req model.TokenActionRequest) error {
+	client, err := f.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return fmt.Errorf("authenticate client: %w", err)
+	}
+	revokeAny := hasScope(client.Scopes, revokeAnyScope)
+
+	if req.TokenTypeHint != "refresh_token" {
+		claims, err := f.jwtIssuer.Validate(req.Token)
+		if err == nil {
+			if !revokeAny && claims["client_id"] != client.ClientID && claims["aud"] != client.ClientID {
+				return ErrUnauthorizedClient
+			}
+			return f.revokeAccessToken(claims)
+		}
+	}
+
+	// refresh tokens are only issued to users logging in directly
+	if !revokeAny {
+		return nil
+	}
+	stored, err := f.repo.GetRefreshToken(hashToken(req.Token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repo get refresh token: %w", err)
+	}
+	if err := f.repo.RevokeRefreshTokenFamily(stored.FamilyID, TimeNow()); err != nil {
+		return fmt.Errorf("revoke token family: %w", err)
+	}
+	return nil
+}
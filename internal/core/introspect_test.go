@@ -0,0 +1,115 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_IntrospectAndRevoke(t *testing.T) {
+	clients := map[string]model.OAuthClient{}
+	revoked := map[string]bool{}
+	repo := &repositoryMock{
+		create: func(a any) error {
+			switch e := a.(type) {
+			case *model.OAuthClient:
+				clients[e.ClientID] = *e
+			case *model.RevokedToken:
+				if revoked[e.JTI] {
+					return fmt.Errorf("mock error: %w", gorm.ErrDuplicatedKey)
+				}
+				revoked[e.JTI] = true
+			default:
+				return errors.New("unexpected entity")
+			}
+			return nil
+		},
+		getOAuthClient: func(clientID string) (model.OAuthClient, error) {
+			client, ok := clients[clientID]
+			if !ok {
+				return model.OAuthClient{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+			}
+			return client, nil
+		},
+		isTokenRevoked: func(jti string) (bool, error) {
+			return revoked[jti], nil
+		},
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			return model.RefreshToken{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+		},
+	}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")), core.WithClientScopes("reports"))
+
+	owner, err := fuzzy.RegisterClient(model.RegisterClientDTO{Name: "job", Scopes: []string{"reports"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	gateway, err := fuzzy.RegisterClient(model.RegisterClientDTO{Name: "gateway"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp, err := fuzzy.IssueToken(model.TokenRequest{GrantType: "client_credentials", ClientID: owner.ClientID, ClientSecret: owner.ClientSecret})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	req := model.TokenActionRequest{ClientID: gateway.ClientID, ClientSecret: gateway.ClientSecret, Token: resp.AccessToken}
+	got, err := fuzzy.IntrospectToken(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["active"] != true || got["client_id"] != owner.ClientID {
+		t.Fatalf("unexpected introspection response: %v", got)
+	}
+
+	hinted := req
+	hinted.TokenTypeHint = "refresh_token"
+	got, err = fuzzy.IntrospectToken(hinted)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["active"] != true {
+		t.Fatalf("access token with a refresh token hint reported as inactive: %v", got)
+	}
+
+	if _, err := fuzzy.IntrospectToken(model.TokenActionRequest{ClientID: gateway.ClientID, ClientSecret: "wrong", Token: resp.AccessToken}); !errors.Is(err, core.ErrClientAuthFailed) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrClientAuthFailed, err)
+	}
+
+	if err := fuzzy.RevokeToken(req); !errors.Is(err, core.ErrUnauthorizedClient) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrUnauthorizedClient, err)
+	}
+
+	if err := fuzzy.RevokeToken(model.TokenActionRequest{ClientID: owner.ClientID, ClientSecret: owner.ClientSecret, Token: resp.AccessToken}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// revoking a revoked token succeeds as well
+	if err := fuzzy.RevokeToken(model.TokenActionRequest{ClientID: owner.ClientID, ClientSecret: owner.ClientSecret, Token: resp.AccessToken}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got, err = fuzzy.IntrospectToken(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["active"] != false || len(got) != 1 {
+		t.Fatalf("revoked token reported as active: %v", got)
+	}
+
+	got, err = fuzzy.IntrospectToken(model.TokenActionRequest{ClientID: gateway.ClientID, ClientSecret: gateway.ClientSecret, Token: "unknown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["active"] != false {
+		t.Fatalf("unknown token reported as active: %v", got)
+	}
+	if err := fuzzy.RevokeToken(model.TokenActionRequest{ClientID: gateway.ClientID, ClientSecret: gateway.ClientSecret, Token: "unknown"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
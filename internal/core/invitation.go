@@ -0,0 +1,332 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultInvitationTTL = 7 * 24 * time.Hour
+	invitationUse        = "invitation"
+)
+
+var (
+	ErrInvalidInvitation  error = errors.New("invalid invitation")
+	ErrInvitationNotFound error = errors.New("invitation not found")
+	ErrAlreadyMember      error = errors.New("user is already a member of the organization")
+	ErrOwnerRequired      error = errors.New("only owners can manage owners")
+	ErrLastOwner          error = errors.New("organization needs an owner")
+)
+
+// WithInvitations sets the lifetime of invitation links and the page they
+// point to. The token is appended as the "token" query parameter.
+func WithInvitations(ttl time.Duration, acceptURL string) Option {
+	return func(f *fuzzy) {
+		if ttl > 0 {
+			f.invitationTTL = ttl
+		}
+		f.invitationURL = acceptURL
+	}
+}
+
+// CreateInvitation emails an invitation to join the organization of the
+// actor. Only owners can invite owners.
+func (f *fuzzy) CreateInvitation(actor model.OrgActor, dto model.CreateInvitationDTO) (model.InvitationDTO, error) {
+	if err := validator.New().Struct(dto); err != nil {
+		return model.InvitationDTO{}, fmt.Errorf("validate struct: %w", err)
+	}
+	if dto.Role == "" {
+		dto.Role = model.MemberRoleMember
+	}
+	if dto.Role == model.MemberRoleOwner && actor.Role != model.MemberRoleOwner {
+		return model.InvitationDTO{}, ErrOwnerRequired
+	}
+	org, err := f.actorOrganization(actor)
+	if err != nil {
+		return model.InvitationDTO{}, err
+	}
+
+	user, err := f.repo.GetUser(org.ID, dto.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.InvitationDTO{}, fmt.Errorf("repo get user: %w", err)
+	}
+	if err == nil {
+		_, err := f.repo.GetMembership(org.ID, user.ID)
+		if err == nil {
+			return model.InvitationDTO{}, fmt.Errorf("%w: %s", ErrAlreadyMember, dto.Email)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.InvitationDTO{}, fmt.Errorf("repo get membership: %w", err)
+		}
+	}
+
+	token, tokenID := f.invitationToken(org.ID, dto.Email)
+	invitation := model.Invitation{
+		OrganizationID: org.ID,
+		Email:          dto.Email,
+		Role:           dto.Role,
+		InvitedBy:      actor.UserID,
+		TokenID:        tokenID,
+		ExpiresAt:      TimeNow().Add(f.invitationTTL),
+	}
+	if err := f.repo.Create(&invitation); err != nil {
+		return model.InvitationDTO{}, fmt.Errorf("create invitation: %w", err)
+	}
+	if err := f.sendInvitation(org, invitation, token); err != nil {
+		return model.InvitationDTO{}, fmt.Errorf("send invitation: %w", err)
+	}
+	return invitationDTO(invitation), nil
+}
+
+// ListInvitations returns the invitations of the actor's organization,
+// newest first
+func (f *fuzzy) ListInvitations(actor model.OrgActor) ([]model.InvitationDTO, error) {
+	if _, err := f.actorOrganization(actor); err != nil {
+		return nil, err
+	}
+	invitations, err := f.repo.GetInvitations(actor.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("repo get invitations: %w", err)
+	}
+	res := make([]model.InvitationDTO, 0, len(invitations))
+	for _, invitation := range invitations {
+		res = append(res, invitationDTO(invitation))
+	}
+	return res, nil
+}
+
+// RevokeInvitation invalidates a pending invitation
+func (f *fuzzy) RevokeInvitation(actor model.OrgActor, id uint) error {
+	invitation, err := f.pendingInvitation(actor, id)
+	if err != nil {
+		return err
+	}
+	revoked, err := f.repo.RevokeInvitation(invitation.ID, TimeNow())
+	if err != nil {
+		return fmt.Errorf("repo revoke invitation: %w", err)
+	}
+	if !revoked {
+		return fmt.Errorf("%w: no longer pending", ErrInvitationNotFound)
+	}
+	return nil
+}
+
+// ResendInvitation emails a new link for a pending invitation and extends
+// its expiration. Links sent before stop working.
+func (f *fuzzy) ResendInvitation(actor model.OrgActor, id uint) error {
+	invitation, err := f.pendingInvitation(actor, id)
+	if err != nil {
+		return err
+	}
+	org, err := f.actorOrganization(actor)
+	if err != nil {
+		return err
+	}
+
+	token, tokenID := f.invitationToken(org.ID, invitation.Email)
+	expiresAt := TimeNow().Add(f.invitationTTL)
+	renewed, err := f.repo.RenewInvitation(invitation.ID, tokenID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("repo renew invitation: %w", err)
+	}
+	if !renewed {
+		return fmt.Errorf("%w: no longer pending", ErrInvitationNotFound)
+	}
+	invitation.TokenID = tokenID
+	invitation.ExpiresAt = expiresAt
+	if err := f.sendInvitation(org, invitation, token); err != nil {
+		return fmt.Errorf("send invitation: %w", err)
+	}
+	return nil
+}
+
+// AcceptInvitation adds the invited user to the organization. Users new to
+// the organization's tenant are registered with the names and password of
+// the request, and their email counts as verified, since the invitation
+// reached it.
+func (f *fuzzy) AcceptInvitation(dto model.AcceptInvitationDTO) error {
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+	claims, err := f.jwtIssuer.Validate(dto.Token)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidInvitation, err)
+	}
+	if claims[tokenUseClaim] != invitationUse {
+		return ErrInvalidInvitation
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return ErrInvalidInvitation
+	}
+	invitation, err := f.repo.GetInvitationByToken(jti)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: link replaced", ErrInvalidInvitation)
+	}
+	if err != nil {
+		return fmt.Errorf("repo get invitation by token: %w", err)
+	}
+	if status := invitationStatus(invitation); status != model.InvitationPending {
+		return fmt.Errorf("%w: %s", ErrInvalidInvitation, status)
+	}
+
+	user, err := f.repo.GetUser(invitation.OrganizationID, invitation.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return f.acceptAsNewUser(invitation, dto)
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user: %w", err)
+	}
+
+	return f.consumeInvitation(invitation, &user)
+}
+
+// acceptAsNewUser registers the invited user the same way RegisterUser
+// does, bypassing the signup setting of the organization
+func (f *fuzzy) acceptAsNewUser(invitation model.Invitation, dto model.AcceptInvitationDTO) error {
+	register := model.RegisterDTO{
+		FirstName: dto.FirstName,
+		LastName:  dto.LastName,
+		Email:     invitation.Email,
+		Password:  dto.Password,
+		Locale:    dto.Locale,
+		TenantID:  invitation.OrganizationID,
+	}
+	if err := validateRegisterDTO(register); err != nil {
+		return fmt.Errorf("validate register dto: %w", err)
+	}
+	user, err := f.prepareUserRegister(register)
+	if err != nil {
+		return fmt.Errorf("prepare user register: %w", err)
+	}
+	user.EmailVerified = true
+
+	err = f.consumeInvitation(invitation, &user)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%w: %s", ErrUserExists, user.Email)
+	}
+	return err
+}
+
+// consumeInvitation marks the invitation as accepted and makes the user a
+// member, registering new users, all at once. Of two concurrent requests
+// with the same link only one succeeds, and a failed request leaves the
+// link usable.
+func (f *fuzzy) consumeInvitation(invitation model.Invitation, user *model.User) error {
+	accepted, err := f.repo.AcceptInvitation(invitation.ID, invitation.TokenID, TimeNow(), user, invitation.Role)
+	if err != nil {
+		return fmt.Errorf("repo accept invitation: %w", err)
+	}
+	if !accepted {
+		return fmt.Errorf("%w: already used", ErrInvalidInvitation)
+	}
+	return nil
+}
+
+// pendingInvitation loads an invitation of the actor's organization which
+// can still be accepted. Only owners can touch invitations of owners.
+func (f *fuzzy) pendingInvitation(actor model.OrgActor, id uint) (model.Invitation, error) {
+	invitation, err := f.repo.GetInvitation(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Invitation{}, ErrInvitationNotFound
+	}
+	if err != nil {
+		return model.Invitation{}, fmt.Errorf("repo get invitation: %w", err)
+	}
+	if invitation.OrganizationID != actor.TenantID {
+		return model.Invitation{}, ErrInvitationNotFound
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return model.Invitation{}, fmt.Errorf("%w: no longer pending", ErrInvitationNotFound)
+	}
+	if invitation.Role == model.MemberRoleOwner && actor.Role != model.MemberRoleOwner {
+		return model.Invitation{}, ErrOwnerRequired
+	}
+	return invitation, nil
+}
+
+// actorOrganization loads the organization managed by the actor. The
+// default tenant has none.
+func (f *fuzzy) actorOrganization(actor model.OrgActor) (model.Organization, error) {
+	if actor.TenantID == 0 {
+		return model.Organization{}, ErrUnknownTenant
+	}
+	org, err := f.repo.GetOrganizationByID(actor.TenantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Organization{}, ErrUnknownTenant
+	}
+	if err != nil {
+		return model.Organization{}, fmt.Errorf("repo get organization by id: %w", err)
+	}
+	return org, nil
+}
+
+// invitationToken generates the token of an invitation link and returns it
+// unsigned along with its jti
+func (f *fuzzy) invitationToken(tenantID uint, email string) (*jwt.Token, string) {
+	info := model.TokenInfo{
+		Email:      email,
+		Subject:    email,
+		Expiration: f.invitationTTL,
+		Claims: map[string]any{
+			tokenUseClaim: invitationUse,
+			"tenant":      tenantID,
+		},
+	}
+	token := f.jwtIssuer.Generate(&info)
+	tokenID, _ := token.Claims.(jwt.MapClaims)["jti"].(string)
+	return token, tokenID
+}
+
+func (f *fuzzy) sendInvitation(org model.Organization, invitation model.Invitation, token *jwt.Token) error {
+	signed, err := f.jwtIssuer.Sign(token)
+	if err != nil {
+		return fmt.Errorf("sign invitation token: %w", err)
+	}
+	acceptURL := f.invitationURL
+	if acceptURL == "" {
+		acceptURL = f.publicURL + "/api/invitations/accept"
+	}
+	data := map[string]any{
+		"Link":         acceptURL + "?" + url.Values{"token": {signed}}.Encode(),
+		"Token":        signed,
+		"Organization": org.Name,
+		"Role":         invitation.Role,
+		"ExpiresIn":    f.invitationTTL.String(),
+	}
+	if err := f.sendMail(model.User{Email: invitation.Email}, "invitation", data); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+func invitationStatus(invitation model.Invitation) string {
+	switch {
+	case invitation.AcceptedAt != nil:
+		return model.InvitationAccepted
+	case invitation.RevokedAt != nil:
+		return model.InvitationRevoked
+	case !TimeNow().Before(invitation.ExpiresAt):
+		return model.InvitationExpired
+	}
+	return model.InvitationPending
+}
+
+func invitationDTO(invitation model.Invitation) model.InvitationDTO {
+	return model.InvitationDTO{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		Status:    invitationStatus(invitation),
+		InvitedBy: invitation.InvitedBy,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
@@ -0,0 +1,199 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_Invitation_NewUser(t *testing.T) {
+	org := model.Organization{Model: gorm.Model{ID: 3}, Slug: "acme", Name: "Acme"}
+	repo := newMemoryStore(t, &model.User{}, org)
+	mailer := &mailerMock{}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithMailer(mailer, "https://fuzzy.test"),
+	)
+	admin := model.OrgActor{TenantID: org.ID, UserID: 1, Role: model.MemberRoleAdmin}
+
+	owner := model.CreateInvitationDTO{Email: "boss@test.com", Role: model.MemberRoleOwner}
+	if _, err := fuzzy.CreateInvitation(admin, owner); !errors.Is(err, core.ErrOwnerRequired) {
+		t.Fatalf("expected owner required, got %v", err)
+	}
+	invitation, err := fuzzy.CreateInvitation(admin, model.CreateInvitationDTO{Email: "new@test.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if invitation.Role != model.MemberRoleMember || invitation.Status != model.InvitationPending {
+		t.Fatalf("unexpected invitation: %+v", invitation)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].Template != "invitation" || mailer.sent[0].Data["Organization"] != "Acme" {
+		t.Fatalf("unexpected mails: %+v", mailer.sent)
+	}
+	token := linkToken(t, mailer.sent[0])
+
+	dto := model.AcceptInvitationDTO{Token: token, FirstName: "Penko", LastName: "Penkov", Password: "s3curePass"}
+	if err := fuzzy.AcceptInvitation(dto); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(repo.registered) != 1 {
+		t.Fatalf("expected 1 registered user, got %d", len(repo.registered))
+	}
+	user := repo.registered[0]
+	if user.Email != "new@test.com" || user.TenantID != org.ID || !user.EmailVerified {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+	if m := repo.memberships[user.ID]; m.OrganizationID != org.ID || m.Role != model.MemberRoleMember {
+		t.Fatalf("unexpected membership: %+v", m)
+	}
+
+	if err := fuzzy.AcceptInvitation(dto); !errors.Is(err, core.ErrInvalidInvitation) {
+		t.Fatalf("expected invalid invitation, got %v", err)
+	}
+}
+
+func Test_Invitation_FailedMembershipKeepsLink(t *testing.T) {
+	org := model.Organization{Model: gorm.Model{ID: 3}, Slug: "acme", Name: "Acme"}
+	repo := newMemoryStore(t, &model.User{}, org)
+	insert := repo.create
+	failing := true
+	repo.create = func(a any) error {
+		if _, ok := a.(*model.Membership); ok && failing {
+			return errors.New("mock error")
+		}
+		return insert(a)
+	}
+	mailer := &mailerMock{}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithMailer(mailer, "https://fuzzy.test"),
+	)
+	admin := model.OrgActor{TenantID: org.ID, UserID: 1, Role: model.MemberRoleAdmin}
+	if _, err := fuzzy.CreateInvitation(admin, model.CreateInvitationDTO{Email: "new@test.com"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dto := model.AcceptInvitationDTO{Token: linkToken(t, mailer.sent[0]), FirstName: "Penko", LastName: "Penkov", Password: "s3curePass"}
+	if err := fuzzy.AcceptInvitation(dto); err == nil {
+		t.Fatal("expected the failed membership to fail the request")
+	}
+	if len(repo.registered) != 0 {
+		t.Fatalf("expected no user without a membership, got %+v", repo.registered)
+	}
+
+	failing = false
+	if err := fuzzy.AcceptInvitation(dto); err != nil {
+		t.Fatalf("expected the link to stay usable, got: %s", err)
+	}
+	if len(repo.registered) != 1 || repo.memberships[repo.registered[0].ID].OrganizationID != org.ID {
+		t.Fatalf("unexpected users %+v and memberships %+v", repo.registered, repo.memberships)
+	}
+}
+
+func Test_Invitation_ExistingUser(t *testing.T) {
+	org := model.Organization{Model: gorm.Model{ID: 3}, Slug: "acme", Name: "Acme"}
+	user := &model.User{Model: gorm.Model{ID: 5}, Email: "test@test.com", TenantID: org.ID}
+	repo := newMemoryStore(t, user, org)
+	mailer := &mailerMock{}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithMailer(mailer, "https://fuzzy.test"),
+	)
+	owner := model.OrgActor{TenantID: org.ID, Role: model.MemberRoleOwner}
+
+	invitation, err := fuzzy.CreateInvitation(owner, model.CreateInvitationDTO{Email: user.Email, Role: model.MemberRoleAdmin})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := fuzzy.ResendInvitation(owner, invitation.ID); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mailer.sent) != 2 {
+		t.Fatalf("expected 2 mails, got %d", len(mailer.sent))
+	}
+
+	replaced := model.AcceptInvitationDTO{Token: linkToken(t, mailer.sent[0])}
+	if err := fuzzy.AcceptInvitation(replaced); !errors.Is(err, core.ErrInvalidInvitation) {
+		t.Fatalf("expected the first link to be replaced, got %v", err)
+	}
+	if err := fuzzy.AcceptInvitation(model.AcceptInvitationDTO{Token: linkToken(t, mailer.sent[1])}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(repo.registered) != 0 {
+		t.Fatalf("expected the existing user to be linked, got %d registered", len(repo.registered))
+	}
+	if m := repo.memberships[user.ID]; m.OrganizationID != org.ID || m.Role != model.MemberRoleAdmin {
+		t.Fatalf("unexpected membership: %+v", m)
+	}
+
+	if _, err := fuzzy.CreateInvitation(owner, model.CreateInvitationDTO{Email: user.Email}); !errors.Is(err, core.ErrAlreadyMember) {
+		t.Fatalf("expected already a member, got %v", err)
+	}
+}
+
+func Test_Members_Owners(t *testing.T) {
+	org := model.Organization{Model: gorm.Model{ID: 3}, Slug: "acme"}
+	user := &model.User{Model: gorm.Model{ID: 5}, Email: "test@test.com", TenantID: org.ID}
+	repo := newMemoryStore(t, user, org)
+	repo.memberships[5] = model.Membership{OrganizationID: org.ID, UserID: 5, Role: model.MemberRoleOwner}
+	repo.memberships[6] = model.Membership{OrganizationID: org.ID, UserID: 6, Role: model.MemberRoleMember}
+	repo.getMembers = func(organizationID uint) ([]model.Member, error) {
+		var res []model.Member
+		for _, m := range repo.memberships {
+			res = append(res, model.Member{Membership: m})
+		}
+		return res, nil
+	}
+	repo.updateMemberRole = func(organizationID, userID uint, role string) (bool, error) {
+		m := repo.memberships[userID]
+		m.Role = role
+		repo.memberships[userID] = m
+		return true, nil
+	}
+	repo.deleteMembership = func(organizationID, userID uint) (bool, error) {
+		delete(repo.memberships, userID)
+		return true, nil
+	}
+	var revoked []uint
+	repo.revokeUserSessions = func(userID uint, revokedAt time.Time) error {
+		revoked = append(revoked, userID)
+		return nil
+	}
+	fuzzy := core.NewFuzzy(repo, nil)
+	owner := model.OrgActor{TenantID: org.ID, UserID: 5, Role: model.MemberRoleOwner}
+	admin := model.OrgActor{TenantID: org.ID, UserID: 7, Role: model.MemberRoleAdmin}
+
+	if err := fuzzy.RemoveMember(owner, 5); !errors.Is(err, core.ErrLastOwner) {
+		t.Fatalf("expected last owner, got %v", err)
+	}
+	if err := fuzzy.RemoveMember(admin, 5); !errors.Is(err, core.ErrOwnerRequired) {
+		t.Fatalf("expected owner required, got %v", err)
+	}
+	if err := fuzzy.UpdateMember(admin, model.UpdateMemberDTO{UserID: 6, Role: model.MemberRoleOwner}); !errors.Is(err, core.ErrOwnerRequired) {
+		t.Fatalf("expected owner required, got %v", err)
+	}
+	if err := fuzzy.UpdateMember(owner, model.UpdateMemberDTO{UserID: 6, Role: model.MemberRoleOwner}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := fuzzy.RemoveMember(owner, 5); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := repo.memberships[5]; ok || len(revoked) != 1 || revoked[0] != 5 {
+		t.Fatalf("expected the member removed and logged out, got %v, %v", repo.memberships, revoked)
+	}
+
+	if err := fuzzy.RemoveMember(model.OrgActor{Role: model.MemberRoleOwner}, 6); !errors.Is(err, core.ErrUnknownTenant) {
+		t.Fatalf("expected unknown tenant, got %v", err)
+	}
+}
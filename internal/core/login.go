@@ -6,33 +6,53 @@ import (
 
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
 	"github.com/go-playground/validator"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 var ErrInvalidPassword error = errors.New("invalid password")
 
-func (f *fuzzy) LoginUser(dto model.LoginDTO) (string, error) {
+func (f *fuzzy) LoginUser(dto model.LoginDTO) (model.AuthTokens, error) {
 	err := validateLoginDTO(dto)
 	if err != nil {
-		return "", fmt.Errorf("validate login dto: %w", err)
+		return model.AuthTokens{}, fmt.Errorf("validate login dto: %w", err)
 	}
 
-	user, err := f.repo.GetUser(dto.Email)
+	account := passwordThrottleKey(dto.TenantID, dto.Email)
+	if err := f.reserveAttempt(account, dto.ClientIP); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("reserve attempt: %w", err)
+	}
+
+	user, err := f.repo.GetUser(dto.TenantID, dto.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if f.hideAccounts {
+			f.dummyPasswordCheck(dto.Password)
+			err = fmt.Errorf("%w: %w", ErrInvalidPassword, err)
+		} else {
+			err = fmt.Errorf("%w: %w", ErrUserNotFound, err)
+		}
+	}
 	if err != nil {
-		return "", fmt.Errorf("get password hash: %w", err)
+		return model.AuthTokens{}, fmt.Errorf("get password hash: %w", err)
 	}
+	if err := f.checkPassword(user, dto.Password); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("check password: %w", err)
+	}
+	f.resetFailures(account)
 
 	info, err := f.prepareTokenInfo(dto, user)
 	if err != nil {
-		return "", fmt.Errorf("prapare token info: %w", err)
+		return model.AuthTokens{}, fmt.Errorf("prapare token info: %w", err)
+	}
+	if user.TOTPEnabledAt != nil {
+		return model.AuthTokens{}, f.mfaChallenge(user)
 	}
 
-	token := f.jwtIssuer.Generate(&info)
-	tokenStr, err := f.jwtIssuer.Sign(token)
+	tokens, err := f.issueTokens(user, info, uuid.NewString())
 	if err != nil {
-		return "", fmt.Errorf("token signing: %w", err)
+		return model.AuthTokens{}, fmt.Errorf("issue tokens: %w", err)
 	}
-	return tokenStr, nil
+	return tokens, nil
 }
 
 func validateLoginDTO(dto model.LoginDTO) error {
@@ -44,23 +64,71 @@ func validateLoginDTO(dto model.LoginDTO) error {
 	return nil
 }
 
+// prepareTokenInfo prepares the session of a user whose password matched
 func (f *fuzzy) prepareTokenInfo(dto model.LoginDTO, user model.User) (model.TokenInfo, error) {
-	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(dto.Password))
-	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-		return model.TokenInfo{}, ErrInvalidPassword
+	f.rehashPassword(user, dto.Password)
+	return f.authenticatedTokenInfo(user)
+}
+
+// authenticatedTokenInfo prepares the session of a user who has proven
+// their identity, with the password or a passkey
+func (f *fuzzy) authenticatedTokenInfo(user model.User) (model.TokenInfo, error) {
+	if f.requireVerifiedEmail && !user.EmailVerified {
+		return model.TokenInfo{}, ErrEmailNotVerified
+	}
+	return f.loginTokenInfo(user), nil
+}
+
+func (f *fuzzy) checkPassword(user model.User, password string) error {
+	if user.PasswordHash == "" {
+		if f.hideAccounts {
+			f.dummyPasswordCheck(password)
+		}
+		return fmt.Errorf("%w: account has no password", ErrInvalidPassword)
+	}
+	if err := f.hasher.Verify(user.PasswordHash, password); err != nil {
+		return fmt.Errorf("hasher verify: %w", err)
 	}
+	return nil
+}
+
+// rehashPassword upgrades a hash written with an outdated algorithm or
+// parameters. Failures are logged but not fatal, the old hash keeps working
+// and the upgrade is retried on the next login.
+func (f *fuzzy) rehashPassword(user model.User, password string) {
+	if !f.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+	if err := f.upgradePasswordHash(user, password); err != nil {
+		f.logs.Warnw(
+			"password hash upgrade failed",
+			"error", err,
+			"user_id", user.ID,
+		)
+	}
+}
+
+func (f *fuzzy) upgradePasswordHash(user model.User, password string) error {
+	hs, err := f.hashPassword(password)
 	if err != nil {
-		return model.TokenInfo{}, fmt.Errorf("compare pass and pass hash: %w", err)
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if err := f.repo.UpdatePasswordHash(user.ID, hs); err != nil {
+		return fmt.Errorf("repo update password hash: %w", err)
 	}
+	return nil
+}
 
+func (f *fuzzy) loginTokenInfo(user model.User) model.TokenInfo {
 	var res model.TokenInfo
+	res.UserID = user.ID
 	res.Email = user.Email
 	res.FirstName = user.FirstName
 	res.LastName = user.LastName
 	res.Subject = "Login"
-	res.Expiration = 24
+	res.Expiration = f.accessTokenTTL
 
-	return res, nil
+	return res
 }
 
 type TokenInfo struct {
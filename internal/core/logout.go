@@ -0,0 +1,105 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+var ErrUserNotFound error = errors.New("user not found")
+
+// LogoutUser revokes the presented access token and, when given,
+// the whole family of the presented refresh token. The refresh token
+// is revoked even when the access token has expired or is invalid.
+func (f *fuzzy) LogoutUser(dto model.LogoutDTO) error {
+	if dto.RefreshToken != "" {
+		stored, err := f.repo.GetRefreshToken(hashToken(dto.RefreshToken))
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("repo get refresh token: %w", err)
+		}
+		if err == nil {
+			if err := f.repo.RevokeRefreshTokenFamily(stored.FamilyID, TimeNow()); err != nil {
+				return fmt.Errorf("revoke token family: %w", err)
+			}
+		}
+	}
+
+	claims, err := f.jwtIssuer.Validate(dto.AccessToken)
+	if err != nil {
+		expired, ok := expiredClaims(dto.AccessToken, err)
+		if !ok {
+			return fmt.Errorf("jwt validate: %w", err)
+		}
+		claims = expired
+	}
+	return f.revokeAccessToken(claims)
+}
+
+// revokeAccessToken adds the jti of the token to the revocation list.
+// Revoking a token twice is not an error.
+func (f *fuzzy) revokeAccessToken(claims jwt.MapClaims) error {
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return nil
+	}
+	userID, _ := numericClaim(claims, "uid")
+	revoked := model.RevokedToken{
+		JTI:       jti,
+		UserID:    uint(userID),
+		ExpiresAt: claimExpiration(claims),
+	}
+	if err := f.repo.Create(&revoked); err != nil && !errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("create revoked token: %w", err)
+	}
+	return nil
+}
+
+// expiredClaims returns the claims of a token which failed validation only
+// because it expired. Its signature has been checked by then.
+func expiredClaims(token string, err error) (jwt.MapClaims, bool) {
+	var validationErr *jwt.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Errors != jwt.ValidationErrorExpired {
+		return nil, false
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// RevokeUserSessions invalidates every access and refresh token
+// issued to the user with the given email in the tenant so far.
+func (f *fuzzy) RevokeUserSessions(tenantID uint, email string) error {
+	user, err := f.repo.GetUser(tenantID, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user: %w", err)
+	}
+
+	if err := f.repo.RevokeUserSessions(user.ID, TimeNow()); err != nil {
+		return fmt.Errorf("repo revoke user sessions: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredTokens removes revocation entries and refresh tokens
+// which have expired and can no longer be presented, together with
+// login throttling counters which ran out.
+func (f *fuzzy) PurgeExpiredTokens() error {
+	now := TimeNow()
+	if err := f.repo.PurgeExpiredTokens(now); err != nil {
+		return fmt.Errorf("repo purge expired tokens: %w", err)
+	}
+	if f.throttle != nil {
+		if err := f.throttle.PurgeThrottle(now.Add(-f.loginThrottle.Window)); err != nil {
+			return fmt.Errorf("purge throttle: %w", err)
+		}
+	}
+	return nil
+}
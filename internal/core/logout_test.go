@@ -0,0 +1,217 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+func Test_VerifyUser_RevokedJTI(t *testing.T) {
+	jwtMock := jwtIssuerMock{
+		validate: func(token string) (jwt.MapClaims, error) {
+			return jwt.MapClaims{"jti": "revoked-id"}, nil
+		},
+	}
+	repo := &repositoryMock{
+		isTokenRevoked: func(jti string) (bool, error) {
+			return jti == "revoked-id", nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, &jwtMock)
+	_, err := fuzzy.VerifyUser("fake_token")
+
+	if !errors.Is(err, core.ErrTokenRevoked) {
+		t.Fatalf("unexpected error, expected: %s, got: %s", core.ErrTokenRevoked, err)
+	}
+}
+
+func Test_VerifyUser_IssuedBeforeSessionsRevoked(t *testing.T) {
+	revokedAt := time.Now()
+	jwtMock := jwtIssuerMock{
+		validate: func(token string) (jwt.MapClaims, error) {
+			return jwt.MapClaims{
+				"jti": "token-id",
+				"uid": float64(7),
+				"iat": float64(revokedAt.Add(-time.Hour).Unix()),
+			}, nil
+		},
+	}
+	repo := &repositoryMock{
+		isTokenRevoked: func(jti string) (bool, error) {
+			return false, nil
+		},
+		getUserByID: func(id uint) (model.User, error) {
+			return model.User{SessionsRevokedAt: &revokedAt}, nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, &jwtMock)
+	_, err := fuzzy.VerifyUser("fake_token")
+
+	if !errors.Is(err, core.ErrTokenRevoked) {
+		t.Fatalf("unexpected error, expected: %s, got: %s", core.ErrTokenRevoked, err)
+	}
+}
+
+func Test_VerifyUser_DeletedUser(t *testing.T) {
+	jwtMock := jwtIssuerMock{
+		validate: func(token string) (jwt.MapClaims, error) {
+			return jwt.MapClaims{"jti": "token-id", "uid": float64(7)}, nil
+		},
+	}
+	repo := &repositoryMock{
+		isTokenRevoked: func(jti string) (bool, error) {
+			return false, nil
+		},
+		getUserByID: func(id uint) (model.User, error) {
+			return model.User{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, &jwtMock)
+	_, err := fuzzy.VerifyUser("fake_token")
+
+	if !errors.Is(err, core.ErrTokenRevoked) {
+		t.Fatalf("unexpected error, expected: %s, got: %s", core.ErrTokenRevoked, err)
+	}
+}
+
+func Test_LogoutUser_Success(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	jwtMock := jwtIssuerMock{
+		validate: func(token string) (jwt.MapClaims, error) {
+			return jwt.MapClaims{
+				"jti": "token-id",
+				"uid": float64(7),
+				"exp": float64(expiresAt.Unix()),
+			}, nil
+		},
+	}
+
+	var revoked model.RevokedToken
+	var revokedFamily string
+	repo := &repositoryMock{
+		create: func(a any) error {
+			token, ok := a.(*model.RevokedToken)
+			if !ok {
+				return errors.New("unexpected entity")
+			}
+			revoked = *token
+			return nil
+		},
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			return model.RefreshToken{FamilyID: "family"}, nil
+		},
+		revokeRefreshTokenFamily: func(familyID string, revokedAt time.Time) error {
+			revokedFamily = familyID
+			return nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, &jwtMock)
+	err := fuzzy.LogoutUser(model.LogoutDTO{
+		AccessToken:  "fake_token",
+		RefreshToken: "fake_refresh_token",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if revoked.JTI != "token-id" || revoked.UserID != 7 || !revoked.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("unexpected revocation entry: %+v", revoked)
+	}
+	if revokedFamily != "family" {
+		t.Fatalf("refresh token family not revoked, got: %q", revokedFamily)
+	}
+}
+
+func Test_LogoutUser_ExpiredAccessToken(t *testing.T) {
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"jti": "token-id",
+		"exp": float64(time.Now().Add(-time.Hour).Unix()),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign token: %s", err)
+	}
+	jwtMock := jwtIssuerMock{
+		validate: func(token string) (jwt.MapClaims, error) {
+			return nil, fmt.Errorf("jwt parse: %w", &jwt.ValidationError{Errors: jwt.ValidationErrorExpired})
+		},
+	}
+
+	var revokedJTI, revokedFamily string
+	repo := &repositoryMock{
+		create: func(a any) error {
+			revokedJTI = a.(*model.RevokedToken).JTI
+			return nil
+		},
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			return model.RefreshToken{FamilyID: "family"}, nil
+		},
+		revokeRefreshTokenFamily: func(familyID string, revokedAt time.Time) error {
+			revokedFamily = familyID
+			return nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, &jwtMock)
+	err = fuzzy.LogoutUser(model.LogoutDTO{AccessToken: expired, RefreshToken: "fake_refresh_token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if revokedFamily != "family" || revokedJTI != "token-id" {
+		t.Fatalf("expected the family and the token revoked, got: %q, %q", revokedFamily, revokedJTI)
+	}
+}
+
+func Test_LogoutUser_InvalidAccessToken(t *testing.T) {
+	jwtMock := jwtIssuerMock{
+		validate: func(token string) (jwt.MapClaims, error) {
+			return nil, fmt.Errorf("jwt parse: %w", &jwt.ValidationError{Errors: jwt.ValidationErrorSignatureInvalid | jwt.ValidationErrorExpired})
+		},
+	}
+	var revokedFamily string
+	repo := &repositoryMock{
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			return model.RefreshToken{FamilyID: "family"}, nil
+		},
+		revokeRefreshTokenFamily: func(familyID string, revokedAt time.Time) error {
+			revokedFamily = familyID
+			return nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, &jwtMock)
+	err := fuzzy.LogoutUser(model.LogoutDTO{AccessToken: "forged", RefreshToken: "fake_refresh_token"})
+	if err == nil {
+		t.Fatal("expected an error for a forged access token")
+	}
+	if revokedFamily != "family" {
+		t.Fatalf("refresh token family not revoked, got: %q", revokedFamily)
+	}
+}
+
+func Test_LogoutUser_Twice(t *testing.T) {
+	jwtMock := jwtIssuerMock{
+		validate: func(token string) (jwt.MapClaims, error) {
+			return jwt.MapClaims{"jti": "token-id"}, nil
+		},
+	}
+	repo := &repositoryMock{
+		create: func(a any) error {
+			return fmt.Errorf("db create: %w", gorm.ErrDuplicatedKey)
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, &jwtMock)
+	if err := fuzzy.LogoutUser(model.LogoutDTO{AccessToken: "fake_token"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMagicLinkTTL = 15 * time.Minute
+	magicLinkUse        = "magic_link"
+)
+
+var ErrInvalidMagicLink error = errors.New("invalid magic link")
+
+// WithMagicLink sets the lifetime of emailed login links and the page they
+// point to. The token is appended as the "token" query parameter. An empty
+// loginURL keeps the service's own callback.
+func WithMagicLink(ttl time.Duration, loginURL string) Option {
+	return func(f *fuzzy) {
+		if ttl > 0 {
+			f.magicLinkTTL = ttl
+		}
+		f.magicLinkURL = loginURL
+	}
+}
+
+// SendMagicLink emails a single-use login link to the user with the given
+// email. Unknown emails are silently ignored.
+func (f *fuzzy) SendMagicLink(dto model.MagicLinkDTO) error {
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+
+	user, err := f.repo.GetUser(dto.TenantID, dto.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user: %w", err)
+	}
+
+	info := model.TokenInfo{
+		UserID:     user.ID,
+		Email:      user.Email,
+		Subject:    strconv.FormatUint(uint64(user.ID), 10),
+		Expiration: f.magicLinkTTL,
+		Claims:     map[string]any{tokenUseClaim: magicLinkUse},
+	}
+	token, err := f.jwtIssuer.Sign(f.jwtIssuer.Generate(&info))
+	if err != nil {
+		return fmt.Errorf("sign magic link token: %w", err)
+	}
+
+	loginURL := f.magicLinkURL
+	if loginURL == "" {
+		loginURL = f.publicURL + "/api/login/magic/callback"
+	}
+	data := map[string]any{
+		"Link":      loginURL + "?" + url.Values{"token": {token}}.Encode(),
+		"ExpiresIn": f.magicLinkTTL.String(),
+	}
+	if err := f.sendMail(user, "magic_link", data); err != nil {
+		return fmt.Errorf("send magic link: %w", err)
+	}
+	return nil
+}
+
+// LoginMagicLink exchanges the token of an emailed link for the session
+// tokens. Opening the link proves the ownership of the email address, so
+// it is marked as verified.
+func (f *fuzzy) LoginMagicLink(token string) (model.AuthTokens, error) {
+	claims, err := f.jwtIssuer.Validate(token)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("%w: %w", ErrInvalidMagicLink, err)
+	}
+	if claims[tokenUseClaim] != magicLinkUse {
+		return model.AuthTokens{}, ErrInvalidMagicLink
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return model.AuthTokens{}, ErrInvalidMagicLink
+	}
+	userID, ok := numericClaim(claims, "uid")
+	if !ok {
+		return model.AuthTokens{}, ErrInvalidMagicLink
+	}
+	if err := f.checkRevoked(claims); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("%w: %w", ErrInvalidMagicLink, err)
+	}
+
+	// the jti is the primary key of revoked tokens, so of two concurrent
+	// requests with the same link only one gets past this point
+	used := model.RevokedToken{
+		JTI:       jti,
+		UserID:    uint(userID),
+		ExpiresAt: claimExpiration(claims),
+	}
+	if err := f.repo.Create(&used); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.AuthTokens{}, fmt.Errorf("%w: link already used", ErrInvalidMagicLink)
+		}
+		return model.AuthTokens{}, fmt.Errorf("create revoked token: %w", err)
+	}
+
+	user, err := f.repo.GetUserByID(uint(userID))
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+	if email, _ := claims["email"].(string); email != user.Email {
+		return model.AuthTokens{}, fmt.Errorf("%w: email changed", ErrInvalidMagicLink)
+	}
+	if !user.EmailVerified {
+		if _, err := f.repo.MarkEmailVerified(user.ID, user.Email); err != nil {
+			return model.AuthTokens{}, fmt.Errorf("repo mark email verified: %w", err)
+		}
+		user.EmailVerified = true
+	}
+
+	info, err := f.authenticatedTokenInfo(user)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return model.AuthTokens{}, f.mfaChallenge(user)
+	}
+
+	tokens, err := f.issueTokens(user, info, uuid.NewString())
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("issue tokens: %w", err)
+	}
+	return tokens, nil
+}
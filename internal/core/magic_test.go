@@ -0,0 +1,100 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_MagicLink_Login(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 9}, Email: "test@test.com"}
+	repo := newMemoryStore(t, user)
+	user.PasswordHash = ""
+	mailer := &mailerMock{}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithMailer(mailer, "https://fuzzy.test"),
+		core.WithEmailVerification(true),
+		core.WithMagicLink(10*time.Minute, ""),
+	)
+
+	if _, err := fuzzy.LoginUser(model.LoginDTO{Email: user.Email, Password: "anything"}); !errors.Is(err, core.ErrInvalidPassword) {
+		t.Fatalf("passwordless account logged in with a password: %v", err)
+	}
+
+	if err := fuzzy.SendMagicLink(model.MagicLinkDTO{Email: "unknown@test.com"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := fuzzy.SendMagicLink(model.MagicLinkDTO{Email: user.Email}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].Template != "magic_link" || mailer.sent[0].To != user.Email {
+		t.Fatalf("unexpected mails: %+v", mailer.sent)
+	}
+	link := fmt.Sprint(mailer.sent[0].Data["Link"])
+	if !strings.HasPrefix(link, "https://fuzzy.test/api/login/magic/callback?token=") {
+		t.Fatalf("unexpected link: %s", link)
+	}
+	token := linkToken(t, mailer.sent[0])
+
+	if _, err := fuzzy.VerifyUser(token); !errors.Is(err, core.ErrTokenUse) {
+		t.Fatalf("magic link token accepted as access token: %v", err)
+	}
+	tokens, err := fuzzy.LoginMagicLink(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !user.EmailVerified {
+		t.Fatalf("email not marked as verified")
+	}
+	if _, err := fuzzy.VerifyUser(tokens.AccessToken); err != nil {
+		t.Fatalf("issued access token rejected: %s", err)
+	}
+	if _, err := fuzzy.LoginMagicLink(token); !errors.Is(err, core.ErrInvalidMagicLink) {
+		t.Fatalf("magic link used twice: %v", err)
+	}
+}
+
+func Test_MagicLink_EmailChanged(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 9}, Email: "test@test.com", EmailVerified: true}
+	repo := newMemoryStore(t, user)
+	mailer := &mailerMock{}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")), core.WithMailer(mailer, "https://fuzzy.test"))
+
+	if err := fuzzy.SendMagicLink(model.MagicLinkDTO{Email: user.Email}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	user.Email = "new@test.com"
+	if _, err := fuzzy.LoginMagicLink(linkToken(t, mailer.sent[0])); !errors.Is(err, core.ErrInvalidMagicLink) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrInvalidMagicLink, err)
+	}
+}
+
+func Test_RegisterUser_Passwordless(t *testing.T) {
+	var created model.User
+	repo := &repositoryMock{
+		createUser: func(user *model.User, role string) error {
+			created = *user
+			return nil
+		},
+	}
+	fuzzy := core.NewFuzzy(repo, nil)
+
+	dto := model.RegisterDTO{FirstName: "Penko", LastName: "Penkov", Email: "test@gmail.com"}
+	if err := fuzzy.RegisterUser(dto); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if created.PasswordHash != "" {
+		t.Fatalf("passwordless account got a password hash: %s", created.PasswordHash)
+	}
+}
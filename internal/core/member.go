@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"gorm.io/gorm"
+)
+
+// ListMembers returns the members of the actor's organization
+func (f *fuzzy) ListMembers(actor model.OrgActor) ([]model.MemberDTO, error) {
+	if _, err := f.actorOrganization(actor); err != nil {
+		return nil, err
+	}
+	members, err := f.repo.GetMembers(actor.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("repo get members: %w", err)
+	}
+	res := make([]model.MemberDTO, 0, len(members))
+	for _, m := range members {
+		res = append(res, model.MemberDTO{
+			UserID:    m.UserID,
+			Email:     m.Email,
+			FirstName: m.FirstName,
+			LastName:  m.LastName,
+			Role:      m.Role,
+			JoinedAt:  m.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+// UpdateMember changes the role of a member. Only owners can make or
+// demote owners, and the last owner cannot be demoted.
+func (f *fuzzy) UpdateMember(actor model.OrgActor, dto model.UpdateMemberDTO) error {
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+	membership, err := f.managedMembership(actor, dto.UserID)
+	if err != nil {
+		return err
+	}
+	if dto.Role == model.MemberRoleOwner && actor.Role != model.MemberRoleOwner {
+		return ErrOwnerRequired
+	}
+	if membership.Role == dto.Role {
+		return nil
+	}
+	if err := f.keepOwner(membership); err != nil {
+		return err
+	}
+
+	updated, err := f.repo.UpdateMemberRole(actor.TenantID, dto.UserID, dto.Role)
+	if err != nil {
+		return fmt.Errorf("repo update member role: %w", err)
+	}
+	if !updated {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// RemoveMember takes the user out of the organization and ends the user's
+// sessions. The user's account stays, and can be invited again.
+func (f *fuzzy) RemoveMember(actor model.OrgActor, userID uint) error {
+	membership, err := f.managedMembership(actor, userID)
+	if err != nil {
+		return err
+	}
+	if err := f.keepOwner(membership); err != nil {
+		return err
+	}
+
+	removed, err := f.repo.DeleteMembership(actor.TenantID, userID)
+	if err != nil {
+		return fmt.Errorf("repo delete membership: %w", err)
+	}
+	if !removed {
+		return ErrUserNotFound
+	}
+	if err := f.repo.RevokeUserSessions(userID, TimeNow()); err != nil {
+		return fmt.Errorf("repo revoke user sessions: %w", err)
+	}
+	return nil
+}
+
+// managedMembership loads the membership of the user in the actor's
+// organization. Only owners can manage owners.
+func (f *fuzzy) managedMembership(actor model.OrgActor, userID uint) (model.Membership, error) {
+	if _, err := f.actorOrganization(actor); err != nil {
+		return model.Membership{}, err
+	}
+	membership, err := f.repo.GetMembership(actor.TenantID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Membership{}, ErrUserNotFound
+	}
+	if err != nil {
+		return model.Membership{}, fmt.Errorf("repo get membership: %w", err)
+	}
+	if membership.Role == model.MemberRoleOwner && actor.Role != model.MemberRoleOwner {
+		return model.Membership{}, ErrOwnerRequired
+	}
+	return membership, nil
+}
+
+// keepOwner refuses to demote or remove the last owner of an organization
+func (f *fuzzy) keepOwner(membership model.Membership) error {
+	if membership.Role != model.MemberRoleOwner {
+		return nil
+	}
+	members, err := f.repo.GetMembers(membership.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("repo get members: %w", err)
+	}
+	for _, m := range members {
+		if m.Role == model.MemberRoleOwner && m.UserID != membership.UserID {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
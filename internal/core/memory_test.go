@@ -0,0 +1,294 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// memoryStore is an in-memory stand-in for the database of one existing
+// user, whose password is "current", and of the organizations passed to
+// newMemoryStore. It keeps what the service stores around the user and
+// mimics the constraints of the tables:
+//   - emails are unique within a tenant; new users get IDs from 100 on
+//   - revoked token JTIs are a primary key and refuse duplicates
+//   - TOTP steps only move forward and recovery codes work once
+//   - passkey sign counts only grow, unless the authenticator keeps none
+//   - invitations are accepted once, with their current token, and
+//     accepting one or registering a user is all or nothing
+//
+// Only the methods backed by the store are set on the embedded mock. Tests
+// set or wrap single methods where they need different behaviour.
+type memoryStore struct {
+	*repositoryMock
+
+	user               *model.User
+	organizations      []model.Organization
+	registered         []model.User
+	revoked            map[string]bool
+	sessionRevocations []time.Time
+	recoveryCodes      map[string]bool
+	passkeys           []model.WebAuthnCredential
+	memberships        map[uint]model.Membership
+	invitations        map[uint]*model.Invitation
+}
+
+func newMemoryStore(t *testing.T, user *model.User, organizations ...model.Organization) *memoryStore {
+	t.Helper()
+	hs, err := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	user.PasswordHash = string(hs)
+
+	s := &memoryStore{
+		user:          user,
+		organizations: organizations,
+		revoked:       map[string]bool{},
+		recoveryCodes: map[string]bool{},
+		memberships:   map[uint]model.Membership{},
+		invitations:   map[uint]*model.Invitation{},
+	}
+	s.repositoryMock = &repositoryMock{
+		create:                  s.insert,
+		createUser:              s.addUser,
+		getUser:                 s.findUser,
+		getUserByID:             s.findUserByID,
+		isTokenRevoked:          func(jti string) (bool, error) { return s.revoked[jti], nil },
+		revokeUserSessions:      s.revokeSessions,
+		markEmailVerified:       s.verifyEmail,
+		updatePasswordHash:      s.setPasswordHash,
+		updateEmail:             s.changeEmail,
+		getPasswordHistory:      func(userID uint, limit int) ([]model.PasswordHistory, error) { return nil, nil },
+		setTOTPSecret:           s.saveTOTPSecret,
+		enableTOTP:              s.turnOnTOTP,
+		updateTOTPStep:          s.advanceTOTPStep,
+		consumeRecoveryCode:     s.useRecoveryCode,
+		getWebAuthnCredentials:  func(userID uint) ([]model.WebAuthnCredential, error) { return s.passkeys, nil },
+		getWebAuthnCredential:   s.findPasskey,
+		updateWebAuthnSignCount: s.countPasskeySignature,
+		getOrganization:         s.findOrganization,
+		getOrganizationByID:     s.findOrganizationByID,
+		getMembership:           s.findMembership,
+		getInvitation:           s.findInvitation,
+		getInvitationByToken:    s.findInvitationByToken,
+		renewInvitation:         s.rotateInvitation,
+		acceptInvitation:        s.joinByInvitation,
+	}
+	return s
+}
+
+func notFound() error {
+	return fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+}
+
+func (s *memoryStore) insert(a any) error {
+	switch e := a.(type) {
+	case *model.User:
+		for _, u := range append([]model.User{*s.user}, s.registered...) {
+			if u.TenantID == e.TenantID && u.Email == e.Email {
+				return fmt.Errorf("mock error: %w", gorm.ErrDuplicatedKey)
+			}
+		}
+		e.ID = uint(100 + len(s.registered))
+		s.registered = append(s.registered, *e)
+	case *model.RevokedToken:
+		if s.revoked[e.JTI] {
+			return fmt.Errorf("mock error: %w", gorm.ErrDuplicatedKey)
+		}
+		s.revoked[e.JTI] = true
+	case *model.WebAuthnCredential:
+		e.ID = uint(len(s.passkeys) + 1)
+		s.passkeys = append(s.passkeys, *e)
+	case *model.Membership:
+		s.memberships[e.UserID] = *e
+	case *model.Invitation:
+		e.ID = uint(len(s.invitations) + 1)
+		s.invitations[e.ID] = e
+	case *model.RefreshToken, *model.PasswordHistory:
+	default:
+		return errors.New("unexpected entity")
+	}
+	return nil
+}
+
+func (s *memoryStore) findUser(tenantID uint, email string) (model.User, error) {
+	if tenantID != s.user.TenantID || email != s.user.Email {
+		return model.User{}, notFound()
+	}
+	return *s.user, nil
+}
+
+func (s *memoryStore) findUserByID(id uint) (model.User, error) {
+	if id != s.user.ID {
+		return model.User{}, notFound()
+	}
+	return *s.user, nil
+}
+
+func (s *memoryStore) revokeSessions(userID uint, revokedAt time.Time) error {
+	s.sessionRevocations = append(s.sessionRevocations, revokedAt)
+	return nil
+}
+
+func (s *memoryStore) verifyEmail(userID uint, email string) (bool, error) {
+	s.user.EmailVerified = true
+	return true, nil
+}
+
+func (s *memoryStore) setPasswordHash(userID uint, passwordHash string) error {
+	s.user.PasswordHash = passwordHash
+	return nil
+}
+
+func (s *memoryStore) changeEmail(userID uint, oldEmail, newEmail string) (bool, error) {
+	if userID != s.user.ID || oldEmail != s.user.Email {
+		return false, nil
+	}
+	s.user.Email = newEmail
+	s.user.EmailVerified = true
+	return true, nil
+}
+
+func (s *memoryStore) saveTOTPSecret(userID uint, secret string) (bool, error) {
+	if s.user.TOTPEnabledAt != nil {
+		return false, nil
+	}
+	s.user.TOTPSecret = secret
+	return true, nil
+}
+
+func (s *memoryStore) turnOnTOTP(userID uint, enabledAt time.Time, step int64, codes []model.RecoveryCode) (bool, error) {
+	if s.user.TOTPEnabledAt != nil {
+		return false, nil
+	}
+	s.user.TOTPEnabledAt = &enabledAt
+	s.user.TOTPLastStep = step
+	for _, code := range codes {
+		s.recoveryCodes[code.CodeHash] = true
+	}
+	return true, nil
+}
+
+func (s *memoryStore) advanceTOTPStep(userID uint, step int64) (bool, error) {
+	if step <= s.user.TOTPLastStep {
+		return false, nil
+	}
+	s.user.TOTPLastStep = step
+	return true, nil
+}
+
+func (s *memoryStore) useRecoveryCode(userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	if !s.recoveryCodes[codeHash] {
+		return false, nil
+	}
+	delete(s.recoveryCodes, codeHash)
+	return true, nil
+}
+
+func (s *memoryStore) findPasskey(credentialID string) (model.WebAuthnCredential, error) {
+	for _, cred := range s.passkeys {
+		if cred.CredentialID == credentialID {
+			return cred, nil
+		}
+	}
+	return model.WebAuthnCredential{}, notFound()
+}
+
+func (s *memoryStore) countPasskeySignature(id uint, signCount uint32, usedAt time.Time) (bool, error) {
+	cred := &s.passkeys[id-1]
+	if signCount <= cred.SignCount && (signCount != 0 || cred.SignCount != 0) {
+		return false, nil
+	}
+	cred.SignCount = signCount
+	return true, nil
+}
+
+func (s *memoryStore) findOrganization(slug string) (model.Organization, error) {
+	for _, org := range s.organizations {
+		if org.Slug == slug {
+			return org, nil
+		}
+	}
+	return model.Organization{}, notFound()
+}
+
+func (s *memoryStore) findOrganizationByID(id uint) (model.Organization, error) {
+	for _, org := range s.organizations {
+		if org.ID == id {
+			return org, nil
+		}
+	}
+	return model.Organization{}, notFound()
+}
+
+func (s *memoryStore) findMembership(organizationID, userID uint) (model.Membership, error) {
+	m, ok := s.memberships[userID]
+	if !ok || m.OrganizationID != organizationID {
+		return model.Membership{}, notFound()
+	}
+	return m, nil
+}
+
+func (s *memoryStore) findInvitation(id uint) (model.Invitation, error) {
+	if invitation, ok := s.invitations[id]; ok {
+		return *invitation, nil
+	}
+	return model.Invitation{}, notFound()
+}
+
+func (s *memoryStore) findInvitationByToken(tokenID string) (model.Invitation, error) {
+	for _, invitation := range s.invitations {
+		if invitation.TokenID == tokenID {
+			return *invitation, nil
+		}
+	}
+	return model.Invitation{}, notFound()
+}
+
+func (s *memoryStore) rotateInvitation(id uint, tokenID string, expiresAt time.Time) (bool, error) {
+	s.invitations[id].TokenID = tokenID
+	s.invitations[id].ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (s *memoryStore) joinByInvitation(id uint, tokenID string, acceptedAt time.Time, user *model.User, role string) (bool, error) {
+	invitation := s.invitations[id]
+	if invitation.TokenID != tokenID || invitation.AcceptedAt != nil {
+		return false, nil
+	}
+	if err := s.addUser(user, role); err != nil {
+		return false, err
+	}
+	invitation.AcceptedAt = &acceptedAt
+	return true, nil
+}
+
+// addUser inserts a user without an ID and its membership through create,
+// so tests can make either fail, and takes the user back out when the
+// membership fails, like the rolled back transaction would
+func (s *memoryStore) addUser(user *model.User, role string) error {
+	registered := len(s.registered)
+	if user.ID == 0 {
+		if err := s.create(user); err != nil {
+			return err
+		}
+	}
+	if user.TenantID == 0 {
+		return nil
+	}
+	membership := model.Membership{OrganizationID: user.TenantID, UserID: user.ID, Role: role}
+	if err := s.create(&membership); err != nil {
+		if len(s.registered) > registered {
+			s.registered = s.registered[:registered]
+			user.ID = 0
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package core
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/totp"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTOTPIssuer = "Fuzzy User API"
+	mfaPendingUse     = "mfa_pending"
+	mfaPendingTTL     = 5 * time.Minute
+	// totpSkew is the number of time steps around the current one in which
+	// codes are still accepted
+	totpSkew = 1
+
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	ErrMFARequired        error = errors.New("second factor required")
+	ErrInvalidMFAToken    error = errors.New("invalid mfa token")
+	ErrInvalidMFACode     error = errors.New("invalid mfa code")
+	ErrTOTPAlreadyEnabled error = errors.New("totp already enabled")
+	ErrTOTPNotEnrolled    error = errors.New("totp enrollment not started")
+)
+
+// MFARequiredError is returned by LoginUser in place of the session tokens
+// when the user has enabled TOTP. Token is the short-lived "mfa_pending"
+// token LoginMFA accepts together with a code.
+type MFARequiredError struct {
+	Token string
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// WithTOTPIssuer sets the name authenticator apps show next to the user's
+// account
+func WithTOTPIssuer(name string) Option {
+	return func(f *fuzzy) {
+		if name != "" {
+			f.totpIssuer = name
+		}
+	}
+}
+
+// EnrollTOTP starts a TOTP enrollment for the user. The returned secret is
+// not required on login until ConfirmTOTP receives a code generated from it.
+func (f *fuzzy) EnrollTOTP(userID uint) (model.TOTPEnrollment, error) {
+	user, err := f.repo.GetUserByID(userID)
+	if err != nil {
+		return model.TOTPEnrollment{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+	if user.TOTPEnabledAt != nil {
+		return model.TOTPEnrollment{}, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.TOTPEnrollment{}, fmt.Errorf("generate totp secret: %w", err)
+	}
+	stored, err := f.repo.SetTOTPSecret(user.ID, secret)
+	if err != nil {
+		return model.TOTPEnrollment{}, fmt.Errorf("repo set totp secret: %w", err)
+	}
+	if !stored {
+		return model.TOTPEnrollment{}, ErrTOTPAlreadyEnabled
+	}
+
+	return model.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(f.totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the pending TOTP enrollment once the user proves the
+// authenticator works. The returned recovery codes are not shown again.
+func (f *fuzzy) ConfirmTOTP(userID uint, dto model.ConfirmTOTPDTO) (model.RecoveryCodes, error) {
+	if err := validator.New().Struct(dto); err != nil {
+		return model.RecoveryCodes{}, fmt.Errorf("validate struct: %w", err)
+	}
+
+	user, err := f.repo.GetUserByID(userID)
+	if err != nil {
+		return model.RecoveryCodes{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+	if user.TOTPEnabledAt != nil {
+		return model.RecoveryCodes{}, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return model.RecoveryCodes{}, ErrTOTPNotEnrolled
+	}
+
+	now := TimeNow()
+	step, ok := totp.Validate(user.TOTPSecret, strings.TrimSpace(dto.Code), now, totpSkew)
+	if !ok {
+		return model.RecoveryCodes{}, ErrInvalidMFACode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]model.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return model.RecoveryCodes{}, fmt.Errorf("new recovery code: %w", err)
+		}
+		codes[i] = code
+		records[i] = model.RecoveryCode{UserID: user.ID, CodeHash: hashToken(normalizeRecoveryCode(code))}
+	}
+
+	enabled, err := f.repo.EnableTOTP(user.ID, now, step, records)
+	if err != nil {
+		return model.RecoveryCodes{}, fmt.Errorf("repo enable totp: %w", err)
+	}
+	if !enabled {
+		return model.RecoveryCodes{}, ErrTOTPAlreadyEnabled
+	}
+	return model.RecoveryCodes{Codes: codes}, nil
+}
+
+// LoginMFA completes a login started by LoginUser. It exchanges the
+// "mfa_pending" token and a TOTP or recovery code for the session tokens.
+func (f *fuzzy) LoginMFA(dto model.MFALoginDTO) (model.AuthTokens, error) {
+	if err := validator.New().Struct(dto); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("validate struct: %w", err)
+	}
+
+	claims, err := f.jwtIssuer.Validate(dto.MFAToken)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("%w: %w", ErrInvalidMFAToken, err)
+	}
+	if claims[tokenUseClaim] != mfaPendingUse {
+		return model.AuthTokens{}, ErrInvalidMFAToken
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return model.AuthTokens{}, ErrInvalidMFAToken
+	}
+	userID, ok := numericClaim(claims, "uid")
+	if !ok {
+		return model.AuthTokens{}, ErrInvalidMFAToken
+	}
+	if err := f.checkRevoked(claims); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("%w: %w", ErrInvalidMFAToken, err)
+	}
+
+	account := mfaThrottleKey(uint(userID))
+	if err := f.reserveAttempt(account, dto.ClientIP); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("reserve attempt: %w", err)
+	}
+
+	user, err := f.repo.GetUserByID(uint(userID))
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+	if user.TOTPEnabledAt == nil {
+		return model.AuthTokens{}, ErrInvalidMFAToken
+	}
+	if err := f.checkSecondFactor(user, dto.Code); err != nil {
+		return model.AuthTokens{}, err
+	}
+	f.resetFailures(account)
+
+	used := model.RevokedToken{
+		JTI:       jti,
+		UserID:    user.ID,
+		ExpiresAt: claimExpiration(claims),
+	}
+	if err := f.repo.Create(&used); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("create revoked token: %w", err)
+	}
+
+	tokens, err := f.issueTokens(user, f.loginTokenInfo(user), uuid.NewString())
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("issue tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// mfaChallenge issues the token which lets the user finish the login with
+// LoginMFA
+func (f *fuzzy) mfaChallenge(user model.User) error {
+	info := model.TokenInfo{
+		UserID:     user.ID,
+		Email:      user.Email,
+		Subject:    "Login",
+		Expiration: mfaPendingTTL,
+		Claims:     map[string]any{tokenUseClaim: mfaPendingUse},
+	}
+	token, err := f.jwtIssuer.Sign(f.jwtIssuer.Generate(&info))
+	if err != nil {
+		return fmt.Errorf("sign mfa token: %w", err)
+	}
+	return &MFARequiredError{Token: token}
+}
+
+// checkSecondFactor accepts a TOTP code not used before or an unused
+// recovery code
+func (f *fuzzy) checkSecondFactor(user model.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.TOTPSecret, code, TimeNow(), totpSkew)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		accepted, err := f.repo.UpdateTOTPStep(user.ID, step)
+		if err != nil {
+			return fmt.Errorf("repo update totp step: %w", err)
+		}
+		if !accepted {
+			return fmt.Errorf("%w: code already used", ErrInvalidMFACode)
+		}
+		return nil
+	}
+
+	consumed, err := f.repo.ConsumeRecoveryCode(user.ID, hashToken(normalizeRecoveryCode(code)), TimeNow())
+	if err != nil {
+		return fmt.Errorf("repo consume recovery code: %w", err)
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// newRecoveryCode returns a random code formatted as two groups of five
+// characters, e.g. "k3pq7-xw2nd"
+func newRecoveryCode() (string, error) {
+	size := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	var b strings.Builder
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("rand int: %w", err)
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,109 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/totp"
+	"gorm.io/gorm"
+)
+
+func mfaToken(t *testing.T, err error) string {
+	t.Helper()
+	var challenge *core.MFARequiredError
+	if !errors.As(err, &challenge) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrMFARequired, err)
+	}
+	return challenge.Token
+}
+
+func Test_TOTP_EnrollAndLogin(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 6}, Email: "test@test.com"}
+	repo := newMemoryStore(t, user)
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")), core.WithTOTPIssuer("Fuzzy"))
+	login := model.LoginDTO{Email: user.Email, Password: "current"}
+
+	enrollment, err := fuzzy.EnrollTOTP(user.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if enrollment.Secret != user.TOTPSecret || enrollment.URI != totp.URI("Fuzzy", user.Email, user.TOTPSecret) {
+		t.Fatalf("unexpected enrollment: %+v", enrollment)
+	}
+	if _, err := fuzzy.LoginUser(login); err != nil {
+		t.Fatalf("unconfirmed enrollment required on login: %s", err)
+	}
+
+	if _, err := fuzzy.ConfirmTOTP(user.ID, model.ConfirmTOTPDTO{Code: "000000x"}); !errors.Is(err, core.ErrInvalidMFACode) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrInvalidMFACode, err)
+	}
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(user.TOTPSecret, step)
+	recovery, err := fuzzy.ConfirmTOTP(user.ID, model.ConfirmTOTPDTO{Code: code})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(recovery.Codes) != 10 {
+		t.Fatalf("expected 10 recovery codes, got: %v", recovery.Codes)
+	}
+	if _, err := fuzzy.EnrollTOTP(user.ID); !errors.Is(err, core.ErrTOTPAlreadyEnabled) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrTOTPAlreadyEnabled, err)
+	}
+
+	_, err = fuzzy.LoginUser(login)
+	pending := mfaToken(t, err)
+	if _, err := fuzzy.VerifyUser(pending); !errors.Is(err, core.ErrTokenUse) {
+		t.Fatalf("mfa token accepted as access token: %v", err)
+	}
+
+	_, err = fuzzy.LoginMFA(model.MFALoginDTO{MFAToken: pending, Code: code})
+	if !errors.Is(err, core.ErrInvalidMFACode) {
+		t.Fatalf("replayed code accepted: %v", err)
+	}
+	next, _ := totp.Code(user.TOTPSecret, step+1)
+	tokens, err := fuzzy.LoginMFA(model.MFALoginDTO{MFAToken: pending, Code: next})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := fuzzy.VerifyUser(tokens.AccessToken); err != nil {
+		t.Fatalf("issued access token rejected: %s", err)
+	}
+	_, err = fuzzy.LoginMFA(model.MFALoginDTO{MFAToken: pending, Code: recovery.Codes[0]})
+	if !errors.Is(err, core.ErrInvalidMFAToken) {
+		t.Fatalf("mfa token used twice: %v", err)
+	}
+}
+
+func Test_LoginMFA_RecoveryCode(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 7}, Email: "test@test.com"}
+	repo := newMemoryStore(t, user)
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")))
+	login := model.LoginDTO{Email: user.Email, Password: "current"}
+
+	if _, err := fuzzy.EnrollTOTP(user.ID); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	code, _ := totp.Code(user.TOTPSecret, totp.Step(time.Now()))
+	recovery, err := fuzzy.ConfirmTOTP(user.ID, model.ConfirmTOTPDTO{Code: code})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = fuzzy.LoginUser(login)
+	dto := model.MFALoginDTO{MFAToken: mfaToken(t, err), Code: " " + recovery.Codes[3] + " "}
+	if _, err := fuzzy.LoginMFA(dto); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = fuzzy.LoginUser(login)
+	dto = model.MFALoginDTO{MFAToken: mfaToken(t, err), Code: recovery.Codes[3]}
+	if _, err := fuzzy.LoginMFA(dto); !errors.Is(err, core.ErrInvalidMFACode) {
+		t.Fatalf("recovery code used twice: %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+const authorizationCodeTTL = 5 * time.Minute
+
+// OAuthError is an error reported to OAuth 2.0 clients by its error code
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+var (
+	ErrInvalidRequest          = &OAuthError{"invalid_request", "the request is missing a required parameter or is malformed"}
+	ErrInvalidClient           = &OAuthError{"invalid_client", "unknown client or redirect uri"}
+	ErrUnsupportedResponseType = &OAuthError{"unsupported_response_type", "only the code response type is supported"}
+	ErrUnsupportedGrantType    = &OAuthError{"unsupported_grant_type", "the grant type is not supported"}
+	ErrInvalidScope            = &OAuthError{"invalid_scope", "the requested scope is invalid"}
+	ErrLoginRequired           = &OAuthError{"login_required", "the user is not logged in"}
+	ErrInvalidGrant            = &OAuthError{"invalid_grant", "the authorization code is invalid, expired or already used"}
+	ErrInsufficientScope       = &OAuthError{"insufficient_scope", "the access token does not grant the openid scope"}
+)
+
+var supportedScopes = []string{"openid", "profile", "email"}
+
+// WithOIDC sets the issuer of the tokens issued through OpenID Connect
+// and the relying parties allowed to use the authorization code flow
+func WithOIDC(issuer string, clients []model.OIDCClient) Option {
+	return func(f *fuzzy) {
+		f.issuer = issuer
+		f.oidcClients = make(map[string]model.OIDCClient, len(clients))
+		for _, client := range clients {
+			f.oidcClients[client.ID] = client
+		}
+	}
+}
+
+// Issuer returns the issuer identifier of the OpenID provider
+func (f *fuzzy) Issuer() string {
+	return f.issuer
+}
+
+// Authorize issues an authorization code to the user logged in with the given access token.
+// ErrInvalidClient means the redirect uri could not be verified and must not be redirected to.
+func (f *fuzzy) Authorize(req model.AuthorizeRequest, accessToken string) (string, error) {
+	client, ok := f.oidcClients[req.ClientID]
+	if !ok || !contains(client.RedirectURIs, req.RedirectURI) {
+		return "", ErrInvalidClient
+	}
+	if req.ResponseType != "code" {
+		return "", ErrUnsupportedResponseType
+	}
+	scope, ok := normalizeScope(req.Scope)
+	if !ok {
+		return "", ErrInvalidScope
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return "", fmt.Errorf("%w: S256 code challenge required", ErrInvalidRequest)
+	}
+
+	claims, err := f.VerifyUser(accessToken)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrLoginRequired, err)
+	}
+	userID, ok := numericClaim(claims, "uid")
+	if !ok {
+		return "", fmt.Errorf("%w: token without user id", ErrLoginRequired)
+	}
+
+	code, err := newOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("new authorization code: %w", err)
+	}
+	stored := model.AuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ID,
+		UserID:        uint(userID),
+		RedirectURI:   req.RedirectURI,
+		Scope:         scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     TimeNow().Add(authorizationCodeTTL),
+	}
+	if err := f.repo.Create(&stored); err != nil {
+		return "", fmt.Errorf("create authorization code: %w", err)
+	}
+	return code, nil
+}
+
+// ExchangeAuthorizationCode redeems an authorization code for an access and an ID token.
+// Redeeming a code twice revokes the access token issued for it the first time.
+func (f *fuzzy) ExchangeAuthorizationCode(req model.TokenRequest) (model.TokenResponse, error) {
+	if req.GrantType != "authorization_code" {
+		return model.TokenResponse{}, ErrUnsupportedGrantType
+	}
+	if _, ok := f.oidcClients[req.ClientID]; !ok {
+		return model.TokenResponse{}, ErrInvalidClient
+	}
+
+	stored, err := f.repo.GetAuthorizationCode(hashToken(req.Code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.TokenResponse{}, ErrInvalidGrant
+	}
+	if err != nil {
+		return model.TokenResponse{}, fmt.Errorf("repo get authorization code: %w", err)
+	}
+
+	now := TimeNow()
+	if stored.UsedAt != nil {
+		if err := f.revokeCodeAccessToken(stored); err != nil {
+			return model.TokenResponse{}, fmt.Errorf("revoke code access token: %w", err)
+		}
+		return model.TokenResponse{}, fmt.Errorf("%w: code reused", ErrInvalidGrant)
+	}
+	if !now.Before(stored.ExpiresAt) || stored.ClientID != req.ClientID || stored.RedirectURI != req.RedirectURI {
+		return model.TokenResponse{}, ErrInvalidGrant
+	}
+	if !verifyCodeChallenge(stored.CodeChallenge, req.CodeVerifier) {
+		return model.TokenResponse{}, fmt.Errorf("%w: code verifier mismatch", ErrInvalidGrant)
+	}
+
+	user, err := f.repo.GetUserByID(stored.UserID)
+	if err != nil {
+		return model.TokenResponse{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+	if user.DisabledAt != nil {
+		return model.TokenResponse{}, fmt.Errorf("%w: %w", ErrInvalidGrant, ErrAccountDisabled)
+	}
+
+	accessInfo := f.oidcTokenInfo(user, stored.ClientID, stored.Scope)
+	accessInfo.Scope = stored.Scope
+	accessInfo.Claims[tokenUseClaim] = oidcAccessUse
+	accessToken := f.jwtIssuer.Generate(&accessInfo)
+	accessTokenID, _ := accessToken.Claims.(jwt.MapClaims)["jti"].(string)
+
+	consumed, err := f.repo.ConsumeAuthorizationCode(stored.ID, now, accessTokenID)
+	if err != nil {
+		return model.TokenResponse{}, fmt.Errorf("consume authorization code: %w", err)
+	}
+	if !consumed {
+		return model.TokenResponse{}, fmt.Errorf("%w: code reused", ErrInvalidGrant)
+	}
+
+	accessTokenStr, err := f.jwtIssuer.Sign(accessToken)
+	if err != nil {
+		return model.TokenResponse{}, fmt.Errorf("access token signing: %w", err)
+	}
+
+	idInfo := f.oidcTokenInfo(user, stored.ClientID, stored.Scope)
+	idInfo.Claims[tokenUseClaim] = "id"
+	if stored.Nonce != "" {
+		idInfo.Claims["nonce"] = stored.Nonce
+	}
+	idTokenStr, err := f.jwtIssuer.Sign(f.jwtIssuer.Generate(&idInfo))
+	if err != nil {
+		return model.TokenResponse{}, fmt.Errorf("id token signing: %w", err)
+	}
+
+	return model.TokenResponse{
+		AccessToken: accessTokenStr,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(f.accessTokenTTL.Seconds()),
+		IDToken:     idTokenStr,
+		Scope:       stored.Scope,
+	}, nil
+}
+
+// UserInfo returns the claims about the user the access token was issued to.
+// It accepts the access tokens of relying parties as well as the service's own.
+func (f *fuzzy) UserInfo(accessToken string) (map[string]any, error) {
+	claims, err := f.verifyAccessToken(accessToken, "access", oidcAccessUse)
+	if err != nil {
+		return nil, fmt.Errorf("verify user: %w", err)
+	}
+	scope, _ := claims["scope"].(string)
+	if !hasScope(scope, "openid") {
+		return nil, ErrInsufficientScope
+	}
+	userID, ok := numericClaim(claims, "uid")
+	if !ok {
+		return nil, ErrInsufficientScope
+	}
+
+	user, err := f.repo.GetUserByID(uint(userID))
+	if err != nil {
+		return nil, fmt.Errorf("repo get user by id: %w", err)
+	}
+
+	res := map[string]any{"sub": strconv.FormatUint(uint64(user.ID), 10)}
+	for k, v := range userClaims(user, scope) {
+		res[k] = v
+	}
+	return res, nil
+}
+
+// oidcTokenInfo builds the token info shared by the access and the ID token.
+// The subject of OpenID Connect tokens is the user id.
+func (f *fuzzy) oidcTokenInfo(user model.User, clientID, scope string) model.TokenInfo {
+	var res model.TokenInfo
+	res.UserID = user.ID
+	res.Subject = strconv.FormatUint(uint64(user.ID), 10)
+	res.Issuer = f.issuer
+	res.Audience = clientID
+	res.Expiration = f.accessTokenTTL
+	res.Claims = userClaims(user, scope)
+	if user.TenantID != 0 {
+		res.Claims["tenant"] = user.TenantID
+	}
+
+	return res
+}
+
+func (f *fuzzy) revokeCodeAccessToken(code model.AuthorizationCode) error {
+	if code.AccessTokenID == "" {
+		return nil
+	}
+	revoked := model.RevokedToken{
+		JTI:       code.AccessTokenID,
+		UserID:    code.UserID,
+		ExpiresAt: code.UsedAt.Add(f.accessTokenTTL),
+	}
+	if err := f.repo.Create(&revoked); err != nil {
+		return fmt.Errorf("create revoked token: %w", err)
+	}
+	return nil
+}
+
+func userClaims(user model.User, scope string) map[string]any {
+	res := map[string]any{}
+	if hasScope(scope, "profile") {
+		res["name"] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		res["given_name"] = user.FirstName
+		res["family_name"] = user.LastName
+	}
+	if hasScope(scope, "email") {
+		res["email"] = user.Email
+	}
+	return res
+}
+
+// normalizeScope drops unsupported scopes and reports whether openid was requested
+func normalizeScope(scope string) (string, bool) {
+	var res []string
+	for _, s := range strings.Fields(scope) {
+		if contains(supportedScopes, s) && !contains(res, s) {
+			res = append(res, s)
+		}
+	}
+	return strings.Join(res, " "), contains(res, "openid")
+}
+
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func hasScope(scope, s string) bool {
+	return contains(strings.Fields(scope), s)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+package core_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mJ92K9qcDsaPmrXZjUtQbHzTg1ASZ6w5EK"
+
+func testChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// oidcRepo keeps authorization codes and revocations in memory
+type oidcRepo struct {
+	repositoryMock
+	codes   map[string]*model.AuthorizationCode
+	revoked map[string]bool
+}
+
+func newOIDCRepo(user model.User) *oidcRepo {
+	repo := &oidcRepo{
+		codes:   map[string]*model.AuthorizationCode{},
+		revoked: map[string]bool{},
+	}
+	repo.create = func(a any) error {
+		switch v := a.(type) {
+		case *model.AuthorizationCode:
+			v.ID = uint(len(repo.codes) + 1)
+			repo.codes[v.CodeHash] = v
+		case *model.RevokedToken:
+			repo.revoked[v.JTI] = true
+		default:
+			return errors.New("unexpected entity")
+		}
+		return nil
+	}
+	repo.getAuthorizationCode = func(codeHash string) (model.AuthorizationCode, error) {
+		code, ok := repo.codes[codeHash]
+		if !ok {
+			return model.AuthorizationCode{}, gorm.ErrRecordNotFound
+		}
+		return *code, nil
+	}
+	repo.consumeAuthorizationCode = func(id uint, usedAt time.Time, accessTokenID string) (bool, error) {
+		for _, code := range repo.codes {
+			if code.ID == id && code.UsedAt == nil {
+				code.UsedAt = &usedAt
+				code.AccessTokenID = accessTokenID
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	repo.isTokenRevoked = func(jti string) (bool, error) {
+		return repo.revoked[jti], nil
+	}
+	repo.getUserByID = func(id uint) (model.User, error) {
+		return user, nil
+	}
+	return repo
+}
+
+func newOIDCFuzzy(repo core.Repository) (interface {
+	Authorize(req model.AuthorizeRequest, accessToken string) (string, error)
+	ExchangeAuthorizationCode(req model.TokenRequest) (model.TokenResponse, error)
+	UserInfo(accessToken string) (map[string]any, error)
+	VerifyUser(jwtToken string) (map[string]any, error)
+}, string) {
+	jwtgen.TimeNow = time.Now
+	issuer := jwtgen.NewJwtGenerator([]byte("test_secret"))
+	fuzzy := core.NewFuzzy(repo, issuer, core.WithOIDC("https://issuer.test", []model.OIDCClient{
+		{ID: "spa", RedirectURIs: []string{"https://spa.test/callback"}},
+	}))
+
+	loginToken, err := issuer.Sign(issuer.Generate(&model.TokenInfo{UserID: 7, Subject: "Login", Expiration: time.Hour}))
+	if err != nil {
+		panic(err)
+	}
+	return fuzzy, loginToken
+}
+
+func testAuthorizeRequest() model.AuthorizeRequest {
+	return model.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "spa",
+		RedirectURI:         "https://spa.test/callback",
+		Scope:               "openid email profile offline_access",
+		Nonce:               "nonce-value",
+		CodeChallenge:       testChallenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func testTokenRequest(code string) model.TokenRequest {
+	return model.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  "https://spa.test/callback",
+		ClientID:     "spa",
+		CodeVerifier: testVerifier,
+	}
+}
+
+func Test_OIDC_AuthorizationCodeFlow(t *testing.T) {
+	user := model.User{FirstName: "Test", LastName: "User", Email: "test@test.com"}
+	user.ID = 7
+	repo := newOIDCRepo(user)
+	fuzzy, loginToken := newOIDCFuzzy(repo)
+
+	code, err := fuzzy.Authorize(testAuthorizeRequest(), loginToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp, err := fuzzy.ExchangeAuthorizationCode(testTokenRequest(code))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.TokenType != "Bearer" || resp.Scope != "openid email profile" {
+		t.Fatalf("unexpected token response: %+v", resp)
+	}
+
+	if _, err := fuzzy.VerifyUser(resp.IDToken); !errors.Is(err, core.ErrTokenUse) {
+		t.Fatalf("id token accepted as access token, got: %v", err)
+	}
+	if _, err := fuzzy.VerifyUser(resp.AccessToken); !errors.Is(err, core.ErrTokenUse) {
+		t.Fatalf("relying party token accepted as access token, got: %v", err)
+	}
+
+	info, err := fuzzy.UserInfo(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if info["sub"] != "7" || info["email"] != user.Email || info["given_name"] != user.FirstName {
+		t.Fatalf("unexpected userinfo: %+v", info)
+	}
+}
+
+func Test_OIDC_CodeReuseRevokesAccessToken(t *testing.T) {
+	repo := newOIDCRepo(model.User{Email: "test@test.com"})
+	fuzzy, loginToken := newOIDCFuzzy(repo)
+
+	code, err := fuzzy.Authorize(testAuthorizeRequest(), loginToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp, err := fuzzy.ExchangeAuthorizationCode(testTokenRequest(code))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = fuzzy.ExchangeAuthorizationCode(testTokenRequest(code))
+	if !errors.Is(err, core.ErrInvalidGrant) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", core.ErrInvalidGrant, err)
+	}
+	if _, err := fuzzy.UserInfo(resp.AccessToken); !errors.Is(err, core.ErrTokenRevoked) {
+		t.Fatalf("access token of a reused code still valid, got: %v", err)
+	}
+}
+
+func Test_OIDC_AccessTokenRejectedByService(t *testing.T) {
+	user := model.User{Email: "test@test.com"}
+	user.ID = 7
+	fuzzy, loginToken := newOIDCFuzzy(newOIDCRepo(user))
+
+	code, err := fuzzy.Authorize(testAuthorizeRequest(), loginToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp, err := fuzzy.ExchangeAuthorizationCode(testTokenRequest(code))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reached := false
+	handler := middleware.Authenticate(zap.NewNop().Sugar(), fuzzy, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	for _, path := range []string{"/api/verify", "/api/logout"} {
+		request := httptest.NewRequest(http.MethodPost, path, nil)
+		request.Header.Set("Authorization", "Bearer "+resp.AccessToken)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if response.Code != http.StatusUnauthorized || reached {
+			t.Fatalf("%s: expected 401, got: %d", path, response.Code)
+		}
+	}
+}
+
+func Test_OIDC_WrongCodeVerifier(t *testing.T) {
+	repo := newOIDCRepo(model.User{})
+	fuzzy, loginToken := newOIDCFuzzy(repo)
+
+	code, err := fuzzy.Authorize(testAuthorizeRequest(), loginToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req := testTokenRequest(code)
+	req.CodeVerifier = "wrong-verifier-wrong-verifier-wrong-verifier-wrong"
+
+	_, err = fuzzy.ExchangeAuthorizationCode(req)
+	if !errors.Is(err, core.ErrInvalidGrant) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", core.ErrInvalidGrant, err)
+	}
+}
+
+func Test_OIDC_Authorize_Errors(t *testing.T) {
+	repo := newOIDCRepo(model.User{})
+	fuzzy, loginToken := newOIDCFuzzy(repo)
+
+	cases := []struct {
+		name     string
+		modify   func(*model.AuthorizeRequest)
+		token    string
+		expected error
+	}{
+		{"unknown redirect uri", func(r *model.AuthorizeRequest) { r.RedirectURI = "https://evil.test" }, loginToken, core.ErrInvalidClient},
+		{"implicit flow", func(r *model.AuthorizeRequest) { r.ResponseType = "token" }, loginToken, core.ErrUnsupportedResponseType},
+		{"missing openid", func(r *model.AuthorizeRequest) { r.Scope = "email" }, loginToken, core.ErrInvalidScope},
+		{"plain challenge", func(r *model.AuthorizeRequest) { r.CodeChallengeMethod = "plain" }, loginToken, core.ErrInvalidRequest},
+		{"not logged in", func(r *model.AuthorizeRequest) {}, "", core.ErrLoginRequired},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := testAuthorizeRequest()
+			c.modify(&req)
+			_, err := fuzzy.Authorize(req, c.token)
+			if !errors.Is(err, c.expected) {
+				t.Fatalf("unexpected error, expected: %s, got: %v", c.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	passkeyRegistrationUse = "passkey_registration"
+	passkeyLoginUse        = "passkey_login"
+	challengeClaim         = "challenge"
+	challengeSize          = 32
+)
+
+var (
+	ErrPasskeysDisabled      error = errors.New("passkeys not enabled")
+	ErrInvalidPasskey        error = errors.New("invalid passkey")
+	ErrInvalidPasskeySession error = errors.New("invalid passkey session")
+)
+
+// WithWebAuthn enables passkey registration and login
+func WithWebAuthn(rp RelyingParty) Option {
+	return func(f *fuzzy) {
+		f.relyingParty = rp
+	}
+}
+
+// BeginPasskeyRegistration returns the options the user's browser creates
+// a new passkey with
+func (f *fuzzy) BeginPasskeyRegistration(userID uint) (model.PasskeyCreationOptions, error) {
+	if f.relyingParty == nil {
+		return model.PasskeyCreationOptions{}, ErrPasskeysDisabled
+	}
+	user, err := f.repo.GetUserByID(userID)
+	if err != nil {
+		return model.PasskeyCreationOptions{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+	creds, err := f.repo.GetWebAuthnCredentials(user.ID)
+	if err != nil {
+		return model.PasskeyCreationOptions{}, fmt.Errorf("repo get webauthn credentials: %w", err)
+	}
+
+	challenge, session, err := f.ceremonySession(passkeyRegistrationUse, user.ID)
+	if err != nil {
+		return model.PasskeyCreationOptions{}, err
+	}
+
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		exclude = append(exclude, credentialDescriptor(cred))
+	}
+	entity := webauthn.UserEntity{
+		ID:          userHandle(user.ID),
+		Name:        user.Email,
+		DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+	}
+	return model.PasskeyCreationOptions{
+		Session:   session,
+		PublicKey: f.relyingParty.CreationOptions(entity, challenge, exclude),
+	}, nil
+}
+
+// FinishPasskeyRegistration verifies the credential created by the browser
+// and stores it for the user
+func (f *fuzzy) FinishPasskeyRegistration(userID uint, dto model.PasskeyRegistrationDTO) error {
+	if f.relyingParty == nil {
+		return ErrPasskeysDisabled
+	}
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+
+	sessionUser, challenge, err := f.consumeCeremonySession(dto.Session, passkeyRegistrationUse)
+	if err != nil {
+		return err
+	}
+	if sessionUser != userID {
+		return fmt.Errorf("%w: issued to another user", ErrInvalidPasskeySession)
+	}
+
+	cred, err := f.relyingParty.VerifyRegistration(dto.Credential, challenge)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+
+	stored := model.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(cred.ID),
+		PublicKey:    cred.PublicKey,
+		SignCount:    cred.SignCount,
+		Transports:   strings.Join(cred.Transports, ","),
+		Name:         dto.Name,
+	}
+	if err := f.repo.Create(&stored); err != nil {
+		return fmt.Errorf("create webauthn credential: %w", err)
+	}
+	return nil
+}
+
+// BeginPasskeyLogin returns the options the browser asks the authenticator
+// for an assertion with. The credential is discovered by the authenticator,
+// so the user does not have to tell who they are.
+func (f *fuzzy) BeginPasskeyLogin() (model.PasskeyRequestOptions, error) {
+	if f.relyingParty == nil {
+		return model.PasskeyRequestOptions{}, ErrPasskeysDisabled
+	}
+	challenge, session, err := f.ceremonySession(passkeyLoginUse, 0)
+	if err != nil {
+		return model.PasskeyRequestOptions{}, err
+	}
+	return model.PasskeyRequestOptions{
+		Session:   session,
+		PublicKey: f.relyingParty.RequestOptions(challenge, nil),
+	}, nil
+}
+
+// FinishPasskeyLogin verifies the assertion in place of the password and
+// issues the same session tokens as LoginUser. Users with TOTP enabled
+// still have to enter a code when the authenticator did not verify them,
+// e.g. with a PIN or biometrics.
+func (f *fuzzy) FinishPasskeyLogin(dto model.PasskeyLoginDTO) (model.AuthTokens, error) {
+	if f.relyingParty == nil {
+		return model.AuthTokens{}, ErrPasskeysDisabled
+	}
+	if err := validator.New().Struct(dto); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("validate struct: %w", err)
+	}
+
+	_, challenge, err := f.consumeCeremonySession(dto.Session, passkeyLoginUse)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(dto.Credential.RawID)
+	cred, err := f.repo.GetWebAuthnCredential(credentialID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.AuthTokens{}, fmt.Errorf("%w: unknown credential", ErrInvalidPasskey)
+	}
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo get webauthn credential: %w", err)
+	}
+	handle := dto.Credential.Response.UserHandle
+	if len(handle) != 0 && !bytes.Equal(handle, userHandle(cred.UserID)) {
+		return model.AuthTokens{}, fmt.Errorf("%w: user handle mismatch", ErrInvalidPasskey)
+	}
+
+	assertion, err := f.relyingParty.VerifyAssertion(dto.Credential, challenge, cred.PublicKey)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	// a counter which does not increase hints at a cloned authenticator
+	counted, err := f.repo.UpdateWebAuthnSignCount(cred.ID, assertion.SignCount, TimeNow())
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo update webauthn sign count: %w", err)
+	}
+	if !counted {
+		return model.AuthTokens{}, fmt.Errorf("%w: signature counter did not increase", ErrInvalidPasskey)
+	}
+
+	user, err := f.repo.GetUserByID(cred.UserID)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+	info, err := f.authenticatedTokenInfo(user)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	if user.TOTPEnabledAt != nil && !assertion.UserVerified {
+		return model.AuthTokens{}, f.mfaChallenge(user)
+	}
+
+	tokens, err := f.issueTokens(user, info, uuid.NewString())
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("issue tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// ceremonySession issues a random challenge and the signed token which
+// carries it until the browser answers
+func (f *fuzzy) ceremonySession(use string, userID uint) ([]byte, string, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, "", fmt.Errorf("read random challenge: %w", err)
+	}
+	info := model.TokenInfo{
+		UserID:     userID,
+		Subject:    use,
+		Expiration: webauthn.Timeout,
+		Claims: map[string]any{
+			tokenUseClaim:  use,
+			challengeClaim: base64.RawURLEncoding.EncodeToString(challenge),
+		},
+	}
+	session, err := f.jwtIssuer.Sign(f.jwtIssuer.Generate(&info))
+	if err != nil {
+		return nil, "", fmt.Errorf("sign ceremony session: %w", err)
+	}
+	return challenge, session, nil
+}
+
+// consumeCeremonySession returns the user and the challenge of a session
+// issued by ceremonySession. Each session can be answered once.
+func (f *fuzzy) consumeCeremonySession(session, use string) (uint, []byte, error) {
+	claims, err := f.jwtIssuer.Validate(session)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrInvalidPasskeySession, err)
+	}
+	if claims[tokenUseClaim] != use {
+		return 0, nil, ErrInvalidPasskeySession
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return 0, nil, ErrInvalidPasskeySession
+	}
+	encoded, _ := claims[challengeClaim].(string)
+	challenge, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(challenge) != challengeSize {
+		return 0, nil, ErrInvalidPasskeySession
+	}
+	userID, _ := numericClaim(claims, "uid")
+
+	if err := f.checkRevoked(claims); err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrInvalidPasskeySession, err)
+	}
+	used := model.RevokedToken{
+		JTI:       jti,
+		UserID:    uint(userID),
+		ExpiresAt: claimExpiration(claims),
+	}
+	if err := f.repo.Create(&used); err != nil {
+		return 0, nil, fmt.Errorf("create revoked token: %w", err)
+	}
+	return uint(userID), challenge, nil
+}
+
+// userHandle is the WebAuthn user ID of the user, it holds no personal
+// information
+func userHandle(userID uint) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+func credentialDescriptor(cred model.WebAuthnCredential) webauthn.CredentialDescriptor {
+	id, _ := base64.RawURLEncoding.DecodeString(cred.CredentialID)
+	var transports []string
+	if cred.Transports != "" {
+		transports = strings.Split(cred.Transports, ",")
+	}
+	return webauthn.CredentialDescriptor{Type: "public-key", ID: id, Transports: transports}
+}
@@ -0,0 +1,120 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn/webauthntest"
+	"gorm.io/gorm"
+)
+
+const (
+	testRPID   = "fuzzy.local"
+	testOrigin = "https://fuzzy.local"
+)
+
+var withTestRelyingParty = core.WithWebAuthn(webauthn.NewRelyingParty(testRPID, "Fuzzy", []string{testOrigin}))
+
+func Test_Passkey_RegisterAndLogin(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 8}, Email: "test@test.com", FirstName: "Penko", LastName: "Penkov"}
+	repo := newMemoryStore(t, user)
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")), withTestRelyingParty)
+	authn := webauthntest.NewAuthenticator(testRPID, testOrigin)
+
+	creation, err := fuzzy.BeginPasskeyRegistration(user.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if creation.PublicKey.User.Name != user.Email || creation.PublicKey.RP.ID != testRPID {
+		t.Fatalf("unexpected creation options: %+v", creation.PublicKey)
+	}
+	attestation, err := authn.Register(creation.PublicKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	dto := model.PasskeyRegistrationDTO{Session: creation.Session, Name: "laptop", Credential: attestation}
+	if err := fuzzy.FinishPasskeyRegistration(9, dto); !errors.Is(err, core.ErrInvalidPasskeySession) {
+		t.Fatalf("session of another user accepted: %v", err)
+	}
+
+	creation, _ = fuzzy.BeginPasskeyRegistration(user.ID)
+	attestation, _ = authn.Register(creation.PublicKey)
+	dto = model.PasskeyRegistrationDTO{Session: creation.Session, Name: "laptop", Credential: attestation}
+	if err := fuzzy.FinishPasskeyRegistration(user.ID, dto); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	creation, _ = fuzzy.BeginPasskeyRegistration(user.ID)
+	if len(creation.PublicKey.ExcludeCredentials) != 1 {
+		t.Fatalf("registered passkey not excluded: %+v", creation.PublicKey.ExcludeCredentials)
+	}
+
+	request, err := fuzzy.BeginPasskeyLogin()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	assertion, err := authn.Login(request.PublicKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	login := model.PasskeyLoginDTO{Session: request.Session, Credential: assertion}
+	tokens, err := fuzzy.FinishPasskeyLogin(login)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	claims, err := fuzzy.VerifyUser(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("issued access token rejected: %s", err)
+	}
+	if claims["email"] != user.Email {
+		t.Fatalf("token issued for the wrong user: %v", claims)
+	}
+
+	if _, err := fuzzy.FinishPasskeyLogin(login); !errors.Is(err, core.ErrInvalidPasskeySession) {
+		t.Fatalf("session answered twice: %v", err)
+	}
+
+	// a copy of the first assertion answering a new session carries a
+	// stale signature counter
+	request, _ = fuzzy.BeginPasskeyLogin()
+	replayed, _ := authn.Login(request.PublicKey)
+	replayed.Response.AuthenticatorData = assertion.Response.AuthenticatorData
+	if _, err := fuzzy.FinishPasskeyLogin(model.PasskeyLoginDTO{Session: request.Session, Credential: replayed}); !errors.Is(err, core.ErrInvalidPasskey) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrInvalidPasskey, err)
+	}
+}
+
+func Test_Passkey_TOTPWithoutUserVerification(t *testing.T) {
+	enabled := time.Now()
+	user := &model.User{Model: gorm.Model{ID: 8}, Email: "test@test.com", TOTPEnabledAt: &enabled}
+	repo := newMemoryStore(t, user)
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")), withTestRelyingParty)
+	authn := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	authn.UserVerified = false
+
+	creation, _ := fuzzy.BeginPasskeyRegistration(user.ID)
+	attestation, _ := authn.Register(creation.PublicKey)
+	if err := fuzzy.FinishPasskeyRegistration(user.ID, model.PasskeyRegistrationDTO{Session: creation.Session, Credential: attestation}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	request, _ := fuzzy.BeginPasskeyLogin()
+	assertion, _ := authn.Login(request.PublicKey)
+	_, err := fuzzy.FinishPasskeyLogin(model.PasskeyLoginDTO{Session: request.Session, Credential: assertion})
+	if !errors.Is(err, core.ErrMFARequired) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrMFARequired, err)
+	}
+}
+
+func Test_Passkey_Disabled(t *testing.T) {
+	fuzzy := core.NewFuzzy(&repositoryMock{}, nil)
+	if _, err := fuzzy.BeginPasskeyLogin(); !errors.Is(err, core.ErrPasskeysDisabled) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrPasskeysDisabled, err)
+	}
+}
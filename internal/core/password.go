@@ -0,0 +1,117 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"gorm.io/gorm"
+)
+
+const defaultPasswordResetTTL = 30 * time.Minute
+
+var ErrInvalidResetToken error = errors.New("invalid password reset token")
+
+// WithPasswordReset sets the lifetime of password reset tokens and the page
+// the emailed link points to. The token is appended as the "token" query
+// parameter. An empty resetURL keeps the service's own endpoint.
+func WithPasswordReset(ttl time.Duration, resetURL string) Option {
+	return func(f *fuzzy) {
+		if ttl > 0 {
+			f.passwordResetTTL = ttl
+		}
+		f.passwordResetURL = resetURL
+	}
+}
+
+// ForgotPassword emails a password reset link to the user with the given
+// email. Unknown emails are silently ignored.
+func (f *fuzzy) ForgotPassword(dto model.ForgotPasswordDTO) error {
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+
+	user, err := f.repo.GetUser(dto.TenantID, dto.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user: %w", err)
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("new reset token: %w", err)
+	}
+	reset := model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: TimeNow().Add(f.passwordResetTTL),
+	}
+	if err := f.repo.Create(&reset); err != nil {
+		return fmt.Errorf("create reset token: %w", err)
+	}
+
+	resetURL := f.passwordResetURL
+	if resetURL == "" {
+		resetURL = f.publicURL + "/api/password/reset"
+	}
+	data := map[string]any{
+		"Link":      resetURL + "?" + url.Values{"token": {token}}.Encode(),
+		"Token":     token,
+		"ExpiresIn": f.passwordResetTTL.String(),
+	}
+	if err := f.sendMail(user, "password_reset", data); err != nil {
+		return fmt.Errorf("send password reset: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password for the user the reset token was
+// issued to and revokes all of the user's sessions
+func (f *fuzzy) ResetPassword(dto model.ResetPasswordDTO) error {
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+
+	stored, err := f.repo.GetPasswordResetToken(hashToken(dto.Token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("repo get reset token: %w", err)
+	}
+
+	now := TimeNow()
+	if stored.UsedAt != nil || !now.Before(stored.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := f.repo.GetUserByID(stored.UserID)
+	if err != nil {
+		return fmt.Errorf("repo get user by id: %w", err)
+	}
+	// a rejected password must not use the token up
+	if err := f.checkPasswordPolicy(dto.Password, user); err != nil {
+		return fmt.Errorf("check password policy: %w", err)
+	}
+
+	consumed, err := f.repo.ConsumePasswordResetToken(stored.ID, now)
+	if err != nil {
+		return fmt.Errorf("repo consume reset token: %w", err)
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	if err := f.setPassword(user, dto.Password); err != nil {
+		return fmt.Errorf("set password: %w", err)
+	}
+	if err := f.repo.RevokeUserSessions(stored.UserID, now); err != nil {
+		return fmt.Errorf("repo revoke user sessions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_PasswordReset(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	core.TimeNow = func() time.Time { return now }
+	defer func() { core.TimeNow = time.Now }()
+
+	user := model.User{Model: gorm.Model{ID: 3}, Email: "test@test.com", PasswordHash: "old_hash"}
+	resets := map[string]*model.PasswordResetToken{}
+	var sessionsRevokedAt time.Time
+	repo := &repositoryMock{
+		getUser: func(tenantID uint, email string) (model.User, error) {
+			if email != user.Email {
+				return model.User{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+			}
+			return user, nil
+		},
+		getUserByID: func(id uint) (model.User, error) {
+			return user, nil
+		},
+		create: func(a any) error {
+			switch e := a.(type) {
+			case *model.PasswordResetToken:
+				e.ID = uint(len(resets) + 1)
+				resets[e.TokenHash] = e
+			case *model.PasswordHistory:
+			default:
+				return errors.New("unexpected entity")
+			}
+			return nil
+		},
+		getPasswordHistory: func(userID uint, limit int) ([]model.PasswordHistory, error) {
+			return nil, nil
+		},
+		getPasswordResetToken: func(tokenHash string) (model.PasswordResetToken, error) {
+			reset, ok := resets[tokenHash]
+			if !ok {
+				return model.PasswordResetToken{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+			}
+			return *reset, nil
+		},
+		consumePasswordReset: func(id uint, usedAt time.Time) (bool, error) {
+			for _, reset := range resets {
+				if reset.ID == id && reset.UsedAt == nil {
+					reset.UsedAt = &usedAt
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		updatePasswordHash: func(userID uint, passwordHash string) error {
+			user.PasswordHash = passwordHash
+			return nil
+		},
+		revokeUserSessions: func(userID uint, revokedAt time.Time) error {
+			sessionsRevokedAt = revokedAt
+			return nil
+		},
+	}
+	mailer := &mailerMock{}
+	fuzzy := core.NewFuzzy(repo, nil, core.WithMailer(mailer, "https://fuzzy.test"), core.WithPasswordReset(time.Hour, ""))
+
+	if err := fuzzy.ForgotPassword(model.ForgotPasswordDTO{Email: "unknown@test.com"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Fatal("reset mail sent for an unknown email")
+	}
+
+	if err := fuzzy.ForgotPassword(model.ForgotPasswordDTO{Email: user.Email}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].Template != "password_reset" {
+		t.Fatalf("unexpected mails: %+v", mailer.sent)
+	}
+	token := linkToken(t, mailer.sent[0])
+	if _, ok := resets[token]; ok {
+		t.Fatal("reset token stored in plain text")
+	}
+
+	if err := fuzzy.ResetPassword(model.ResetPasswordDTO{Token: "wrong", Password: "new_password"}); !errors.Is(err, core.ErrInvalidResetToken) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrInvalidResetToken, err)
+	}
+
+	if err := fuzzy.ResetPassword(model.ResetPasswordDTO{Token: token, Password: "new_password"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := core.NewArgon2Hasher(core.DefaultArgon2Params).Verify(user.PasswordHash, "new_password"); err != nil {
+		t.Fatalf("password not updated: %s", err)
+	}
+	if !sessionsRevokedAt.Equal(now) {
+		t.Fatal("sessions not revoked")
+	}
+
+	if err := fuzzy.ResetPassword(model.ResetPasswordDTO{Token: token, Password: "other_password"}); !errors.Is(err, core.ErrInvalidResetToken) {
+		t.Fatalf("reset token reused: %v", err)
+	}
+
+	if err := fuzzy.ForgotPassword(model.ForgotPasswordDTO{Email: user.Email}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := fuzzy.ResetPassword(model.ResetPasswordDTO{Token: linkToken(t, mailer.sent[1]), Password: "other_password"}); !errors.Is(err, core.ErrInvalidResetToken) {
+		t.Fatalf("expired reset token accepted: %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+var ErrWeakPassword error = errors.New("password does not meet the policy")
+
+// PolicyError lists every rule a rejected password breaks
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrWeakPassword, strings.Join(e.Violations, "; "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// PasswordPolicy holds the rules new passwords are checked against
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength is counted in bytes, bcrypt ignores everything past 72
+	MaxLength int
+	// MinCharClasses is the number of different character classes (lower
+	// and upper case letters, digits and symbols) a password has to use
+	MinCharClasses int
+	// DisallowPersonalInfo rejects passwords containing the user's email
+	// or name
+	DisallowPersonalInfo bool
+	// HistorySize is the number of previous passwords which cannot be reused
+	HistorySize int
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:            8,
+	MaxLength:            72,
+	MinCharClasses:       1,
+	DisallowPersonalInfo: true,
+	HistorySize:          5,
+}
+
+// WithPasswordPolicy replaces the default password policy. When breached is
+// not nil passwords found in its corpus are rejected as well.
+func WithPasswordPolicy(policy PasswordPolicy, breached BreachedPasswords) Option {
+	return func(f *fuzzy) {
+		f.passwordPolicy = policy
+		f.breached = breached
+	}
+}
+
+// Check returns the rules of the policy the password breaks for the user
+func (p PasswordPolicy) Check(password string, user model.User) []string {
+	var violations []string
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes long", p.MaxLength))
+	}
+	if classes := charClasses(password); classes < p.MinCharClasses {
+		violations = append(violations, fmt.Sprintf("must mix at least %d of lower case, upper case, digits and symbols", p.MinCharClasses))
+	}
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, user) {
+		violations = append(violations, "must not contain your email or name")
+	}
+	return violations
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+func containsPersonalInfo(password string, user model.User) bool {
+	password = strings.ToLower(password)
+	local, _, _ := strings.Cut(user.Email, "@")
+	for _, info := range []string{local, user.FirstName, user.LastName} {
+		// very short names would reject too many unrelated passwords
+		if len(info) >= 3 && strings.Contains(password, strings.ToLower(info)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPasswordPolicy validates a new password for the user. Previous
+// passwords are only checked for users which already exist.
+func (f *fuzzy) checkPasswordPolicy(password string, user model.User) error {
+	violations := f.passwordPolicy.Check(password, user)
+
+	if f.breached != nil {
+		breached, err := f.breached.IsBreached(password)
+		if err != nil {
+			return fmt.Errorf("breached passwords lookup: %w", err)
+		}
+		if breached {
+			violations = append(violations, "appears in a known data breach")
+		}
+	}
+
+	if user.ID != 0 && f.passwordPolicy.HistorySize > 0 {
+		reused, err := f.isPasswordReused(password, user)
+		if err != nil {
+			return fmt.Errorf("password history: %w", err)
+		}
+		if reused {
+			violations = append(violations, fmt.Sprintf("must differ from your last %d passwords", f.passwordPolicy.HistorySize))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func (f *fuzzy) isPasswordReused(password string, user model.User) (bool, error) {
+	hashes := []string{user.PasswordHash}
+	history, err := f.repo.GetPasswordHistory(user.ID, f.passwordPolicy.HistorySize-1)
+	if err != nil {
+		return false, fmt.Errorf("repo get password history: %w", err)
+	}
+	for _, entry := range history {
+		hashes = append(hashes, entry.PasswordHash)
+	}
+
+	for _, hash := range hashes {
+		if hash == "" {
+			continue
+		}
+		err := f.hasher.Verify(hash, password)
+		if err == nil {
+			return true, nil
+		}
+		// a corrupted hash must not block resetting the password
+		if !errors.Is(err, ErrInvalidPassword) && !errors.Is(err, ErrUnknownHashFormat) {
+			return false, fmt.Errorf("hasher verify: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// setPassword keeps the current hash in the user's password history and
+// stores the new one. The password has to pass checkPasswordPolicy first.
+func (f *fuzzy) setPassword(user model.User, password string) error {
+	hs, err := f.hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if user.PasswordHash != "" && f.passwordPolicy.HistorySize > 1 {
+		previous := model.PasswordHistory{UserID: user.ID, PasswordHash: user.PasswordHash}
+		if err := f.repo.Create(&previous); err != nil {
+			return fmt.Errorf("create password history: %w", err)
+		}
+	}
+	if err := f.repo.UpdatePasswordHash(user.ID, hs); err != nil {
+		return fmt.Errorf("repo update password hash: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+type breachedMock struct {
+	passwords map[string]bool
+}
+
+func (b *breachedMock) IsBreached(password string) (bool, error) {
+	return b.passwords[password], nil
+}
+
+func Test_PasswordPolicy_Check(t *testing.T) {
+	policy := core.PasswordPolicy{MinLength: 10, MaxLength: 72, MinCharClasses: 3, DisallowPersonalInfo: true}
+	user := model.User{Email: "penko@test.com", FirstName: "Penko", LastName: "Penkov"}
+
+	cases := []struct {
+		name       string
+		password   string
+		violations int
+	}{
+		{"valid", "Str0ng-enough", 0},
+		{"too short", "Sh0rt!", 1},
+		{"too long", "Aa1" + strings.Repeat("x", 70), 1},
+		{"single class", "onlylowercaseletters", 1},
+		{"contains name", "MyNameIsPENKO1", 1},
+		{"contains email", "Xpenko@test99", 1},
+		{"short and simple", "abc", 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := policy.Check(c.password, user)
+			if len(got) != c.violations {
+				t.Fatalf("violations do not match, expected: %d, got: %v", c.violations, got)
+			}
+		})
+	}
+}
+
+func Test_RegisterUser_BreachedPassword(t *testing.T) {
+	repo := &repositoryMock{
+		createUser: func(user *model.User, role string) error {
+			return nil
+		},
+	}
+	breached := &breachedMock{passwords: map[string]bool{"P@ssw0rd123": true}}
+	fuzzy := core.NewFuzzy(repo, nil, core.WithPasswordPolicy(core.DefaultPasswordPolicy, breached))
+
+	dto := model.RegisterDTO{FirstName: "Penko", LastName: "Penkov", Email: "test@gmail.com", Password: "P@ssw0rd123"}
+	err := fuzzy.RegisterUser(dto)
+	var policyErr *core.PolicyError
+	if !errors.As(err, &policyErr) || !errors.Is(err, core.ErrWeakPassword) {
+		t.Fatalf("error does not match, expected: %s, got: %v", core.ErrWeakPassword, err)
+	}
+	if len(policyErr.Violations) != 1 || !strings.Contains(policyErr.Violations[0], "breach") {
+		t.Fatalf("unexpected violations: %v", policyErr.Violations)
+	}
+}
+
+func Test_ChangePassword_History(t *testing.T) {
+	hasher := core.NewArgon2Hasher(testArgon2Params)
+	current, _ := hasher.Hash("current-secret")
+	older, _ := hasher.Hash("older-secret")
+	user := model.User{Model: gorm.Model{ID: 2}, Email: "test@test.com", PasswordHash: current}
+	var recorded []string
+	repo := &repositoryMock{
+		getUserByID: func(id uint) (model.User, error) {
+			return user, nil
+		},
+		getPasswordHistory: func(userID uint, limit int) ([]model.PasswordHistory, error) {
+			if limit != 2 {
+				return nil, fmt.Errorf("unexpected limit %d", limit)
+			}
+			return []model.PasswordHistory{{UserID: userID, PasswordHash: older}}, nil
+		},
+		create: func(a any) error {
+			if entry, ok := a.(*model.PasswordHistory); ok {
+				recorded = append(recorded, entry.PasswordHash)
+			}
+			return nil
+		},
+		updatePasswordHash: func(userID uint, passwordHash string) error {
+			user.PasswordHash = passwordHash
+			return nil
+		},
+		revokeUserSessions: func(userID uint, revokedAt time.Time) error {
+			return nil
+		},
+	}
+	policy := core.DefaultPasswordPolicy
+	policy.HistorySize = 3
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithPasswordHasher(hasher),
+		core.WithPasswordPolicy(policy, nil),
+	)
+
+	for _, reused := range []string{"current-secret", "older-secret"} {
+		_, err := fuzzy.ChangePassword(user.ID, model.ChangePasswordDTO{CurrentPassword: "current-secret", NewPassword: reused})
+		if !errors.Is(err, core.ErrWeakPassword) {
+			t.Fatalf("reused password %q accepted: %v", reused, err)
+		}
+	}
+
+	if _, err := fuzzy.ChangePassword(user.ID, model.ChangePasswordDTO{CurrentPassword: "current-secret", NewPassword: "brand-new-secret"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(recorded) != 1 || recorded[0] != current {
+		t.Fatalf("previous hash not kept in history: %v", recorded)
+	}
+}
@@ -1,13 +1,130 @@
 package core
 
 import (
+	"time"
+
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/throttle"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn"
 	"github.com/golang-jwt/jwt"
 )
 
 type Repository interface {
 	Create(any) error
-	GetUser(email string) (model.User, error)
+	// CreateUser stores the user and, for users of an organization, their
+	// membership with the role in one transaction
+	CreateUser(user *model.User, role string) error
+	// GetUser looks the email up within the tenant
+	GetUser(tenantID uint, email string) (model.User, error)
+	GetUserByID(id uint) (model.User, error)
+	GetRefreshToken(tokenHash string) (model.RefreshToken, error)
+	ConsumeRefreshToken(id uint, usedAt time.Time) (bool, error)
+	RevokeRefreshTokenFamily(familyID string, revokedAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	RevokeUserSessions(userID uint, revokedAt time.Time) error
+	PurgeExpiredTokens(now time.Time) error
+	GetAuthorizationCode(codeHash string) (model.AuthorizationCode, error)
+	ConsumeAuthorizationCode(id uint, usedAt time.Time, accessTokenID string) (bool, error)
+	GetOAuthClient(clientID string) (model.OAuthClient, error)
+	MarkEmailVerified(userID uint, email string) (bool, error)
+	GetPasswordResetToken(tokenHash string) (model.PasswordResetToken, error)
+	// ConsumePasswordResetToken uses the token up along with all other
+	// unused reset tokens of its user
+	ConsumePasswordResetToken(id uint, usedAt time.Time) (bool, error)
+	UpdatePasswordHash(userID uint, passwordHash string) error
+	UpdateEmail(userID uint, oldEmail, newEmail string) (bool, error)
+	GetPasswordHistory(userID uint, limit int) ([]model.PasswordHistory, error)
+	SetTOTPSecret(userID uint, secret string) (bool, error)
+	EnableTOTP(userID uint, enabledAt time.Time, step int64, codes []model.RecoveryCode) (bool, error)
+	UpdateTOTPStep(userID uint, step int64) (bool, error)
+	ConsumeRecoveryCode(userID uint, codeHash string, usedAt time.Time) (bool, error)
+	GetWebAuthnCredentials(userID uint) ([]model.WebAuthnCredential, error)
+	GetWebAuthnCredential(credentialID string) (model.WebAuthnCredential, error)
+	UpdateWebAuthnSignCount(id uint, signCount uint32, usedAt time.Time) (bool, error)
+	GetOrganization(slug string) (model.Organization, error)
+	GetOrganizationByID(id uint) (model.Organization, error)
+	GetOrganizations() ([]model.Organization, error)
+	GetMembership(organizationID, userID uint) (model.Membership, error)
+	GetMembers(organizationID uint) ([]model.Member, error)
+	UpdateMemberRole(organizationID, userID uint, role string) (bool, error)
+	DeleteMembership(organizationID, userID uint) (bool, error)
+	GetInvitation(id uint) (model.Invitation, error)
+	GetInvitationByToken(tokenID string) (model.Invitation, error)
+	GetInvitations(organizationID uint) ([]model.Invitation, error)
+	RenewInvitation(id uint, tokenID string, expiresAt time.Time) (bool, error)
+	RevokeInvitation(id uint, revokedAt time.Time) (bool, error)
+	// AcceptInvitation marks the pending invitation with the token as
+	// accepted and makes the user a member with the role, registering it
+	// first when it has no ID. It reports false when the invitation is no
+	// longer pending and changes nothing when any step fails.
+	AcceptInvitation(id uint, tokenID string, acceptedAt time.Time, user *model.User, role string) (bool, error)
+	GetUsers(filter model.UserFilter) ([]model.User, error)
+	GetUserUnscoped(id uint) (model.User, error)
+	UpdateUser(id uint, fields map[string]any) (bool, error)
+	SetUserDisabled(id uint, disabledAt *time.Time) (bool, error)
+	DeleteUser(id uint, hard bool) (bool, error)
+}
+
+// PasswordHasher hashes passwords and checks them against stored hashes.
+// Verify returns ErrInvalidPassword when the password does not match.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) error
+	// NeedsRehash reports whether the hash was written with an outdated
+	// algorithm or parameters
+	NeedsRehash(hash string) bool
+}
+
+// BreachedPasswords reports whether a password appears in a corpus of
+// passwords exposed in data breaches
+type BreachedPasswords interface {
+	IsBreached(password string) (bool, error)
+}
+
+// RelyingParty runs the server side of WebAuthn ceremonies
+type RelyingParty interface {
+	CreationOptions(user webauthn.UserEntity, challenge []byte, exclude []webauthn.CredentialDescriptor) webauthn.CreationOptions
+	VerifyRegistration(cred webauthn.AttestationCredential, challenge []byte) (webauthn.Credential, error)
+	RequestOptions(challenge []byte, allow []webauthn.CredentialDescriptor) webauthn.RequestOptions
+	VerifyAssertion(cred webauthn.AssertionCredential, challenge, publicKey []byte) (webauthn.Assertion, error)
+}
+
+// ThrottleStore keeps the counters of failed logins and the token buckets
+// of clients. A store shared by all replicas keeps the limits global.
+type ThrottleStore interface {
+	// ReserveLoginAttempt counts an attempt for key as failed, unless the
+	// failures counted so far leave it to wait. Then it returns the wait
+	// and counts nothing. The count starts over when the previous failure
+	// is older than window. Checking and counting must be atomic.
+	ReserveLoginAttempt(key string, now time.Time, window time.Duration, delay func(failures int) time.Duration) (time.Duration, error)
+	ResetLoginFailures(key string) error
+	TakeRateToken(key string, limit throttle.Limit, now time.Time) (throttle.Result, error)
+	// PurgeThrottle removes failures and buckets not touched since before
+	PurgeThrottle(before time.Time) error
+}
+
+// RoleRepository keeps roles, their permissions and the users they are
+// assigned to
+type RoleRepository interface {
+	// GetUserRoles returns the roles of the user with their permissions
+	GetUserRoles(userID uint) ([]model.Role, error)
+	GetRoles() ([]model.Role, error)
+	// SaveRole creates the role or updates the one with the same name, and
+	// replaces its permissions
+	SaveRole(role model.Role, permissions []string) error
+	AssignRole(userID uint, roleName string) (bool, error)
+	RemoveRole(userID uint, roleName string) (bool, error)
+}
+
+// UserSearcher finds the users of a tenant whose names or emails are
+// similar to the query, best matches first. Soft deleted users are left out.
+type UserSearcher interface {
+	SearchUsers(tenantID uint, query string, limit int) ([]model.UserMatch, error)
+}
+
+// Mailer renders and delivers messages to users
+type Mailer interface {
+	Send(mail model.Mail) error
 }
 
 type JwtIssuer interface {
@@ -0,0 +1,230 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"gorm.io/gorm"
+)
+
+// Permissions checked by the admin endpoints of the service
+const (
+	PermissionUsersRead      = "users:read"
+	PermissionUsersWrite     = "users:write"
+	PermissionRolesManage    = "roles:manage"
+	PermissionSessionsRevoke = "sessions:revoke"
+	PermissionClientsManage  = "clients:manage"
+	PermissionKeysManage     = "keys:manage"
+)
+
+// AdminRole is granted every permission of the service by BootstrapRoles
+const AdminRole = "admin"
+
+var AdminPermissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionRolesManage,
+	PermissionSessionsRevoke,
+	PermissionClientsManage,
+	PermissionKeysManage,
+	PermissionOrganizationsManage,
+}
+
+var (
+	ErrRolesDisabled     error = errors.New("roles are not enabled")
+	ErrUnknownRole       error = errors.New("unknown role")
+	ErrInvalidPermission error = errors.New("invalid permission name")
+)
+
+// WithRoles writes the roles of users and their permissions to the "roles"
+// and "permissions" claims of the issued access tokens
+func WithRoles(roles RoleRepository) Option {
+	return func(f *fuzzy) {
+		f.roles = roles
+	}
+}
+
+// BootstrapRoles makes sure the admin role exists with every permission of
+// the service and assigns it to the user with adminEmail, when there is one.
+// The user has to have verified the address, otherwise whoever registered
+// it first would become an admin.
+func (f *fuzzy) BootstrapRoles(adminEmail string) error {
+	if f.roles == nil {
+		return ErrRolesDisabled
+	}
+	role := model.Role{Name: AdminRole, Description: "Manages users and the service"}
+	if err := f.roles.SaveRole(role, AdminPermissions); err != nil {
+		return fmt.Errorf("save admin role: %w", err)
+	}
+	if adminEmail == "" {
+		return nil
+	}
+
+	user, err := f.repo.GetUser(0, adminEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user: %w", err)
+	}
+	if !user.EmailVerified {
+		f.logs.Warnw(
+			"admin role not assigned, email not verified",
+			"user_id", user.ID,
+		)
+		return nil
+	}
+	if _, err := f.roles.AssignRole(user.ID, AdminRole); err != nil {
+		return fmt.Errorf("assign admin role: %w", err)
+	}
+	return nil
+}
+
+// ListRoles returns every role with its permissions
+func (f *fuzzy) ListRoles() ([]model.RoleDTO, error) {
+	if f.roles == nil {
+		return nil, ErrRolesDisabled
+	}
+	roles, err := f.roles.GetRoles()
+	if err != nil {
+		return nil, fmt.Errorf("get roles: %w", err)
+	}
+	res := make([]model.RoleDTO, 0, len(roles))
+	for _, role := range roles {
+		res = append(res, model.RoleDTO{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: permissionNames(role),
+		})
+	}
+	return res, nil
+}
+
+// SaveRole creates a role or replaces the description and permissions of
+// the existing one with the same name
+func (f *fuzzy) SaveRole(dto model.SaveRoleDTO) error {
+	if f.roles == nil {
+		return ErrRolesDisabled
+	}
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+	for _, perm := range dto.Permissions {
+		// permissions are joined with spaces in the permissions claim
+		if strings.ContainsAny(perm, " \t\r\n") {
+			return fmt.Errorf("%w: %q", ErrInvalidPermission, perm)
+		}
+		// a permission named like a client scope would blur the line
+		// between what clients and users are allowed to do
+		if f.clientScopes[perm] {
+			return fmt.Errorf("%w: %q is a client scope", ErrInvalidPermission, perm)
+		}
+	}
+
+	role := model.Role{Name: dto.Name, Description: dto.Description}
+	if err := f.roles.SaveRole(role, dto.Permissions); err != nil {
+		return fmt.Errorf("save role: %w", err)
+	}
+	return nil
+}
+
+// AssignRole gives the user a role. It takes effect with the next access
+// token the user gets.
+func (f *fuzzy) AssignRole(dto model.RoleAssignmentDTO) error {
+	if f.roles == nil {
+		return ErrRolesDisabled
+	}
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+	_, err := f.repo.GetUserByID(dto.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user by id: %w", err)
+	}
+
+	ok, err := f.roles.AssignRole(dto.UserID, dto.Role)
+	if err != nil {
+		return fmt.Errorf("assign role: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownRole, dto.Role)
+	}
+	return nil
+}
+
+// RemoveRole takes a role away from the user. Access tokens issued before
+// keep it until they expire.
+func (f *fuzzy) RemoveRole(dto model.RoleAssignmentDTO) error {
+	if f.roles == nil {
+		return ErrRolesDisabled
+	}
+	if err := validator.New().Struct(dto); err != nil {
+		return fmt.Errorf("validate struct: %w", err)
+	}
+	ok, err := f.roles.RemoveRole(dto.UserID, dto.Role)
+	if err != nil {
+		return fmt.Errorf("remove role: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: user %d has no role %s", ErrUnknownRole, dto.UserID, dto.Role)
+	}
+	return nil
+}
+
+// withRoles adds the roles of the user to the "roles" claim and their
+// permissions to the "permissions" claim of the token. Permissions are kept
+// out of the "scope" claim, which client credentials tokens carry as well.
+func (f *fuzzy) withRoles(user model.User, info model.TokenInfo) (model.TokenInfo, error) {
+	if f.roles == nil {
+		return info, nil
+	}
+	roles, err := f.roles.GetUserRoles(user.ID)
+	if err != nil {
+		return model.TokenInfo{}, fmt.Errorf("get user roles: %w", err)
+	}
+
+	names := make([]string, 0, len(roles))
+	seen := map[string]bool{}
+	var perms []string
+	for _, role := range roles {
+		names = append(names, role.Name)
+		for _, perm := range permissionNames(role) {
+			if !seen[perm] {
+				seen[perm] = true
+				perms = append(perms, perm)
+			}
+		}
+	}
+	sort.Strings(perms)
+
+	info = withClaims(info, map[string]any{
+		"roles":       names,
+		"permissions": strings.Join(perms, " "),
+	})
+	return info, nil
+}
+
+func isAdminPermission(name string) bool {
+	for _, perm := range AdminPermissions {
+		if perm == name {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionNames(role model.Role) []string {
+	names := make([]string, 0, len(role.Permissions))
+	for _, perm := range role.Permissions {
+		names = append(names, perm.Name)
+	}
+	sort.Strings(names)
+	return names
+}
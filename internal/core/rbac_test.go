@@ -0,0 +1,142 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+// roleRepoMock keeps roles and their assignments in memory
+type roleRepoMock struct {
+	roles    map[string]model.Role
+	assigned map[uint][]string
+}
+
+func newRoleRepoMock() *roleRepoMock {
+	return &roleRepoMock{roles: map[string]model.Role{}, assigned: map[uint][]string{}}
+}
+
+func (r *roleRepoMock) GetUserRoles(userID uint) ([]model.Role, error) {
+	var res []model.Role
+	for _, name := range r.assigned[userID] {
+		res = append(res, r.roles[name])
+	}
+	return res, nil
+}
+
+func (r *roleRepoMock) GetRoles() ([]model.Role, error) {
+	var res []model.Role
+	for _, role := range r.roles {
+		res = append(res, role)
+	}
+	return res, nil
+}
+
+func (r *roleRepoMock) SaveRole(role model.Role, permissions []string) error {
+	for _, name := range permissions {
+		role.Permissions = append(role.Permissions, model.Permission{Name: name})
+	}
+	r.roles[role.Name] = role
+	return nil
+}
+
+func (r *roleRepoMock) AssignRole(userID uint, roleName string) (bool, error) {
+	if _, ok := r.roles[roleName]; !ok {
+		return false, nil
+	}
+	r.assigned[userID] = append(r.assigned[userID], roleName)
+	return true, nil
+}
+
+func (r *roleRepoMock) RemoveRole(userID uint, roleName string) (bool, error) {
+	for i, name := range r.assigned[userID] {
+		if name == roleName {
+			r.assigned[userID] = append(r.assigned[userID][:i], r.assigned[userID][i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func Test_Roles_InAccessToken(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 5}, Email: "admin@test.com", EmailVerified: true}
+	repo := newMemoryStore(t, user)
+	roles := newRoleRepoMock()
+	jwtgen.TimeNow = time.Now
+	issuer := jwtgen.NewJwtGenerator([]byte("test_secret"))
+	fuzzy := core.NewFuzzy(repo, issuer, core.WithRoles(roles))
+
+	if err := fuzzy.BootstrapRoles(user.Email); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	support := model.SaveRoleDTO{Name: "support", Permissions: []string{core.PermissionUsersRead, "tickets:write"}}
+	if err := fuzzy.SaveRole(support); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := fuzzy.AssignRole(model.RoleAssignmentDTO{UserID: user.ID, Role: "support"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tokens, err := fuzzy.LoginUser(model.LoginDTO{Email: user.Email, Password: "current"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	claims, err := fuzzy.VerifyUser(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedPerms := "clients:manage keys:manage organizations:manage roles:manage sessions:revoke tickets:write users:read users:write"
+	if claims["permissions"] != expectedPerms {
+		t.Fatalf("permissions do not match, expected: %s, got: %v", expectedPerms, claims["permissions"])
+	}
+	if _, ok := claims["scope"]; ok {
+		t.Fatalf("permissions leaked into the scope claim: %v", claims["scope"])
+	}
+	names, _ := claims["roles"].([]any)
+	if len(names) != 2 || names[0] != core.AdminRole || names[1] != "support" {
+		t.Fatalf("unexpected roles claim: %v", claims["roles"])
+	}
+}
+
+func Test_BootstrapRoles_UnverifiedAdmin(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 5}, Email: "admin@test.com"}
+	roles := newRoleRepoMock()
+	fuzzy := core.NewFuzzy(newMemoryStore(t, user), nil, core.WithRoles(roles))
+
+	if err := fuzzy.BootstrapRoles(user.Email); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := roles.roles[core.AdminRole]; !ok {
+		t.Fatal("admin role not created")
+	}
+	if len(roles.assigned[user.ID]) != 0 {
+		t.Fatalf("admin role assigned to an unverified address: %v", roles.assigned[user.ID])
+	}
+}
+
+func Test_Roles_Errors(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 5}, Email: "admin@test.com"}
+	repo := newMemoryStore(t, user)
+	fuzzy := core.NewFuzzy(repo, nil, core.WithRoles(newRoleRepoMock()))
+
+	if err := fuzzy.AssignRole(model.RoleAssignmentDTO{UserID: user.ID, Role: "missing"}); !errors.Is(err, core.ErrUnknownRole) {
+		t.Fatalf("expected unknown role, got %v", err)
+	}
+	if err := fuzzy.AssignRole(model.RoleAssignmentDTO{UserID: 99, Role: core.AdminRole}); !errors.Is(err, core.ErrUserNotFound) {
+		t.Fatalf("expected user not found, got %v", err)
+	}
+	if err := fuzzy.SaveRole(model.SaveRoleDTO{Name: "bad", Permissions: []string{"users read"}}); !errors.Is(err, core.ErrInvalidPermission) {
+		t.Fatalf("expected invalid permission, got %v", err)
+	}
+	if err := fuzzy.SaveRole(model.SaveRoleDTO{Name: "bad", Permissions: []string{"token:revoke"}}); !errors.Is(err, core.ErrInvalidPermission) {
+		t.Fatalf("expected invalid permission for a client scope, got %v", err)
+	}
+	if err := core.NewFuzzy(repo, nil).SaveRole(model.SaveRoleDTO{Name: "x"}); !errors.Is(err, core.ErrRolesDisabled) {
+		t.Fatalf("expected roles disabled, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken error = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  error = errors.New("refresh token reused")
+)
+
+// RefreshTokens exchanges a refresh token for a new access and refresh token pair.
+// The presented token is consumed. Presenting a token that was already consumed
+// revokes every token of its family, since one of the parties holding it is
+// not the legitimate client.
+func (f *fuzzy) RefreshTokens(refreshToken string) (model.AuthTokens, error) {
+	stored, err := f.repo.GetRefreshToken(hashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.AuthTokens{}, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo get refresh token: %w", err)
+	}
+
+	now := TimeNow()
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		if err := f.repo.RevokeRefreshTokenFamily(stored.FamilyID, now); err != nil {
+			return model.AuthTokens{}, fmt.Errorf("revoke token family: %w", err)
+		}
+		return model.AuthTokens{}, ErrRefreshTokenReused
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return model.AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	consumed, err := f.repo.ConsumeRefreshToken(stored.ID, now)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("consume refresh token: %w", err)
+	}
+	if !consumed {
+		// a concurrent request consumed the token first
+		if err := f.repo.RevokeRefreshTokenFamily(stored.FamilyID, now); err != nil {
+			return model.AuthTokens{}, fmt.Errorf("revoke token family: %w", err)
+		}
+		return model.AuthTokens{}, ErrRefreshTokenReused
+	}
+
+	user, err := f.repo.GetUserByID(stored.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// the user was deleted, the rest of the family is of no use either
+		if err := f.repo.RevokeRefreshTokenFamily(stored.FamilyID, now); err != nil {
+			return model.AuthTokens{}, fmt.Errorf("revoke token family: %w", err)
+		}
+		return model.AuthTokens{}, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("repo get user by id: %w", err)
+	}
+
+	tokens, err := f.issueTokens(user, f.loginTokenInfo(user), stored.FamilyID)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("issue tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// issueTokens signs an access token for the given info and stores
+// a new refresh token of the given family for the user.
+func (f *fuzzy) issueTokens(user model.User, info model.TokenInfo, familyID string) (model.AuthTokens, error) {
+	now := TimeNow()
+	if user.DisabledAt != nil {
+		return model.AuthTokens{}, ErrAccountDisabled
+	}
+
+	info, err := f.withTenant(user, info)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("token tenant: %w", err)
+	}
+	info, err = f.withRoles(user, info)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("token roles: %w", err)
+	}
+	token := f.jwtIssuer.Generate(&info)
+	accessToken, err := f.jwtIssuer.Sign(token)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("token signing: %w", err)
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("new refresh token: %w", err)
+	}
+
+	stored := model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(f.refreshTokenTTL),
+	}
+	if err := f.repo.Create(&stored); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("create refresh token: %w", err)
+	}
+
+	return model.AuthTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  now.Add(info.Expiration),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("rand read: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package core_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+func hashed(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func signingIssuerMock() *jwtIssuerMock {
+	return &jwtIssuerMock{
+		generate: func(info *model.TokenInfo) *jwt.Token {
+			return jwt.New(jwt.SigningMethodHS512)
+		},
+		sign: func(token *jwt.Token) (string, error) {
+			return "fake_access_token", nil
+		},
+	}
+}
+
+func Test_RefreshTokens_Rotates(t *testing.T) {
+	presented := "presented_refresh_token"
+	stored := model.RefreshToken{
+		UserID:    7,
+		FamilyID:  "family",
+		TokenHash: hashed(presented),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	stored.ID = 3
+
+	var created model.RefreshToken
+	var consumedID uint
+	repo := &repositoryMock{
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			if tokenHash == stored.TokenHash {
+				return stored, nil
+			}
+			return model.RefreshToken{}, gorm.ErrRecordNotFound
+		},
+		consumeRefreshToken: func(id uint, usedAt time.Time) (bool, error) {
+			consumedID = id
+			return true, nil
+		},
+		getUserByID: func(id uint) (model.User, error) {
+			user := model.User{Email: "test@test.com"}
+			user.ID = id
+			return user, nil
+		},
+		create: func(a any) error {
+			token, ok := a.(*model.RefreshToken)
+			if !ok {
+				return errors.New("unexpected entity")
+			}
+			created = *token
+			return nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, signingIssuerMock())
+	tokens, err := fuzzy.RefreshTokens(presented)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if consumedID != stored.ID {
+		t.Fatalf("presented token not consumed, expected id: %d, got: %d", stored.ID, consumedID)
+	}
+	if tokens.RefreshToken == "" || tokens.RefreshToken == presented {
+		t.Fatalf("refresh token was not rotated, got: %q", tokens.RefreshToken)
+	}
+	if created.FamilyID != stored.FamilyID || created.UserID != stored.UserID {
+		t.Fatalf("rotated token left the family, expected: %s/%d, got: %s/%d", stored.FamilyID, stored.UserID, created.FamilyID, created.UserID)
+	}
+	if created.TokenHash != hashed(tokens.RefreshToken) {
+		t.Fatal("stored hash does not match the issued refresh token")
+	}
+	if tokens.AccessToken != "fake_access_token" {
+		t.Fatalf("unexpected access token, got: %s", tokens.AccessToken)
+	}
+}
+
+func Test_RefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	stored := model.RefreshToken{
+		FamilyID:  "family",
+		ExpiresAt: time.Now().Add(time.Hour),
+		UsedAt:    &usedAt,
+	}
+
+	var revokedFamily string
+	repo := &repositoryMock{
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			return stored, nil
+		},
+		revokeRefreshTokenFamily: func(familyID string, revokedAt time.Time) error {
+			revokedFamily = familyID
+			return nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, nil)
+	_, err := fuzzy.RefreshTokens("reused_token")
+
+	if !errors.Is(err, core.ErrRefreshTokenReused) {
+		t.Fatalf("unexpected error, expected: %s, got: %s", core.ErrRefreshTokenReused, err)
+	}
+	if revokedFamily != stored.FamilyID {
+		t.Fatalf("family not revoked, expected: %s, got: %s", stored.FamilyID, revokedFamily)
+	}
+}
+
+func Test_RefreshTokens_Expired(t *testing.T) {
+	repo := &repositoryMock{
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			return model.RefreshToken{ExpiresAt: time.Now().Add(-time.Minute)}, nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, nil)
+	_, err := fuzzy.RefreshTokens("expired_token")
+
+	if !errors.Is(err, core.ErrInvalidRefreshToken) {
+		t.Fatalf("unexpected error, expected: %s, got: %s", core.ErrInvalidRefreshToken, err)
+	}
+}
+
+func Test_RefreshTokens_Unknown(t *testing.T) {
+	repo := &repositoryMock{
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			return model.RefreshToken{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, nil)
+	_, err := fuzzy.RefreshTokens("unknown_token")
+
+	if !errors.Is(err, core.ErrInvalidRefreshToken) {
+		t.Fatalf("unexpected error, expected: %s, got: %s", core.ErrInvalidRefreshToken, err)
+	}
+}
+
+func Test_RefreshTokens_DeletedUser(t *testing.T) {
+	presented := "presented_refresh_token"
+	stored := model.RefreshToken{
+		UserID:    7,
+		FamilyID:  "family",
+		TokenHash: hashed(presented),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	var revokedFamily string
+	repo := &repositoryMock{
+		getRefreshToken: func(tokenHash string) (model.RefreshToken, error) {
+			return stored, nil
+		},
+		consumeRefreshToken: func(id uint, usedAt time.Time) (bool, error) {
+			return true, nil
+		},
+		getUserByID: func(id uint) (model.User, error) {
+			return model.User{}, fmt.Errorf("mock error: %w", gorm.ErrRecordNotFound)
+		},
+		revokeRefreshTokenFamily: func(familyID string, revokedAt time.Time) error {
+			revokedFamily = familyID
+			return nil
+		},
+	}
+
+	fuzzy := core.NewFuzzy(repo, signingIssuerMock())
+	_, err := fuzzy.RefreshTokens(presented)
+	if !errors.Is(err, core.ErrInvalidRefreshToken) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", core.ErrInvalidRefreshToken, err)
+	}
+	if revokedFamily != stored.FamilyID {
+		t.Fatalf("family not revoked, expected: %s, got: %s", stored.FamilyID, revokedFamily)
+	}
+}
@@ -1,11 +1,12 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
 	"github.com/go-playground/validator"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func (f *fuzzy) RegisterUser(dto model.RegisterDTO) error {
@@ -15,30 +16,68 @@ func (f *fuzzy) RegisterUser(dto model.RegisterDTO) error {
 		return fmt.Errorf("validate register dto: %w", err)
 	}
 
+	if err := f.checkSignup(dto.TenantID); err != nil {
+		return fmt.Errorf("check signup: %w", err)
+	}
+
 	user, err := f.prepareUserRegister(dto)
 	if err != nil {
 		return fmt.Errorf("prapare user register: %w", err)
 	}
 
-	if err := f.repo.Create(&user); err != nil {
+	err = f.createUser(&user, model.MemberRoleMember)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return f.registrationAttempt(dto.TenantID, dto.Email)
+	}
+	if err != nil {
 		return fmt.Errorf("create user: %w", err)
 	}
+
+	if err := f.sendEmailVerification(user); err != nil {
+		return fmt.Errorf("send email verification: %w", err)
+	}
+	return nil
+}
+
+// createUser stores the user and makes users of an organization its
+// members with the given role
+func (f *fuzzy) createUser(user *model.User, role string) error {
+	if err := f.repo.CreateUser(user, role); err != nil {
+		return fmt.Errorf("repo create user: %w", err)
+	}
 	return nil
 }
 
 func (f *fuzzy) prepareUserRegister(dto model.RegisterDTO) (model.User, error) {
 	var res model.User
-	hs, err := bcrypt.GenerateFromPassword([]byte(dto.Password), 10)
-	if err != nil {
-		return model.User{}, fmt.Errorf("bcrypt generate password hash: %w", err)
-	}
 	res.FirstName = dto.FirstName
 	res.LastName = dto.LastName
 	res.Email = dto.Email
-	res.PasswordHash = string(hs)
+	res.Locale = dto.Locale
+	res.TenantID = dto.TenantID
+	if dto.Password == "" {
+		return res, nil
+	}
+
+	if err := f.checkPasswordPolicy(dto.Password, res); err != nil {
+		return model.User{}, fmt.Errorf("check password policy: %w", err)
+	}
+	hs, err := f.hashPassword(dto.Password)
+	if err != nil {
+		return model.User{}, fmt.Errorf("hash password: %w", err)
+	}
+	res.PasswordHash = hs
 	return res, nil
 }
 
+func (f *fuzzy) hashPassword(password string) (string, error) {
+	hs, err := f.hasher.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("hasher hash: %w", err)
+	}
+	return hs, nil
+}
+
 func validateRegisterDTO(dto model.RegisterDTO) error {
 	validate := validator.New()
 	err := validate.Struct(dto)
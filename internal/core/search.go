@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/search"
+	"github.com/go-playground/validator"
+)
+
+const (
+	defaultSearchLimit = 20
+	searchScanPageSize = 500
+)
+
+// WithUserSearch hands user searches to the searcher. Without one the users
+// of the tenant are scored in memory, which suits small user bases and
+// repositories without a fuzzy search of their own.
+func WithUserSearch(searcher UserSearcher) Option {
+	return func(f *fuzzy) {
+		f.userSearch = searcher
+	}
+}
+
+// SearchUsers finds the users of the tenant whose names or emails are close
+// to the query, tolerating typos, best matches first
+func (f *fuzzy) SearchUsers(tenantID uint, dto model.UserSearchDTO) ([]model.UserMatchDTO, error) {
+	if err := validator.New().Struct(dto); err != nil {
+		return nil, fmt.Errorf("validate struct: %w", err)
+	}
+	limit := dto.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
+	var matches []model.UserMatch
+	var err error
+	if f.userSearch != nil {
+		matches, err = f.userSearch.SearchUsers(tenantID, dto.Query, limit)
+		if err != nil {
+			return nil, fmt.Errorf("search users: %w", err)
+		}
+	} else {
+		matches, err = f.scanUsers(tenantID, dto.Query, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]model.UserMatchDTO, 0, len(matches))
+	for _, match := range matches {
+		res = append(res, model.UserMatchDTO{
+			AdminUserDTO: adminUserDTO(match.User),
+			Score:        match.Score,
+		})
+	}
+	return res, nil
+}
+
+// scanUsers scores every user of the tenant against the query, a page at a
+// time, and keeps the best matches
+func (f *fuzzy) scanUsers(tenantID uint, query string, limit int) ([]model.UserMatch, error) {
+	var matches []model.UserMatch
+	filter := model.UserFilter{TenantID: tenantID, Limit: searchScanPageSize}
+	for {
+		users, err := f.repo.GetUsers(filter)
+		if err != nil {
+			return nil, fmt.Errorf("repo get users: %w", err)
+		}
+		for _, user := range users {
+			score := search.Score(query, user.FirstName+" "+user.LastName, user.Email)
+			if score >= search.Threshold {
+				matches = append(matches, model.UserMatch{User: user, Score: score})
+			}
+		}
+		if len(users) < filter.Limit {
+			break
+		}
+		filter.AfterID = users[len(users)-1].ID
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
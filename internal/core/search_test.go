@@ -0,0 +1,99 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"gorm.io/gorm"
+)
+
+type searcherMock struct {
+	search func(tenantID uint, query string, limit int) ([]model.UserMatch, error)
+}
+
+func (m *searcherMock) SearchUsers(tenantID uint, query string, limit int) ([]model.UserMatch, error) {
+	return m.search(tenantID, query, limit)
+}
+
+func Test_SearchUsers_Fallback(t *testing.T) {
+	stored := []model.User{
+		{Model: gorm.Model{ID: 1}, FirstName: "Maria", LastName: "Ivanova", Email: "maria@test.com"},
+		{Model: gorm.Model{ID: 2}, FirstName: "John", LastName: "Smith", Email: "john.smith@test.com"},
+		{Model: gorm.Model{ID: 3}, FirstName: "Joan", LastName: "Smithers", Email: "joan@test.com"},
+		{Model: gorm.Model{ID: 4}, FirstName: "Peter", LastName: "Petrov", Email: "peter@test.com"},
+	}
+	var pages int
+	repo := &repositoryMock{
+		getUsers: func(filter model.UserFilter) ([]model.User, error) {
+			pages++
+			if filter.TenantID != 2 {
+				t.Fatalf("unexpected tenant: %d", filter.TenantID)
+			}
+			var res []model.User
+			for _, user := range stored {
+				if user.ID > filter.AfterID && len(res) < filter.Limit {
+					res = append(res, user)
+				}
+			}
+			return res, nil
+		},
+	}
+	fuzzy := core.NewFuzzy(repo, nil)
+
+	matches, err := fuzzy.SearchUsers(2, model.UserSearchDTO{Query: "jon smiht"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var ids []uint
+	for _, match := range matches {
+		ids = append(ids, match.ID)
+	}
+	if fmt.Sprint(ids) != "[2 3]" {
+		t.Fatalf("unexpected matches: %v", ids)
+	}
+	if matches[0].Score <= matches[1].Score {
+		t.Fatalf("expected the closest match first, got scores %f and %f", matches[0].Score, matches[1].Score)
+	}
+	if pages != 1 {
+		t.Fatalf("expected one page of users, got %d", pages)
+	}
+
+	matches, err = fuzzy.SearchUsers(2, model.UserSearchDTO{Query: "petrof", Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(matches) != 1 || matches[0].ID != 4 {
+		t.Fatalf("unexpected matches: %+v", matches)
+	}
+}
+
+func Test_SearchUsers_Searcher(t *testing.T) {
+	var gotLimit int
+	searcher := &searcherMock{
+		search: func(tenantID uint, query string, limit int) ([]model.UserMatch, error) {
+			gotLimit = limit
+			return []model.UserMatch{{User: model.User{Model: gorm.Model{ID: 7}, EmailVerified: true}, Score: 0.8}}, nil
+		},
+	}
+	fuzzy := core.NewFuzzy(&repositoryMock{}, nil, core.WithUserSearch(searcher))
+
+	matches, err := fuzzy.SearchUsers(0, model.UserSearchDTO{Query: "ana"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if gotLimit != 20 {
+		t.Fatalf("expected the default limit of 20, got %d", gotLimit)
+	}
+	if len(matches) != 1 || matches[0].ID != 7 || matches[0].Score != 0.8 || matches[0].Status != model.UserStatusActive {
+		t.Fatalf("unexpected matches: %+v", matches)
+	}
+
+	var validationErr validator.ValidationErrors
+	if _, err := fuzzy.SearchUsers(0, model.UserSearchDTO{Query: "a"}); !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"gorm.io/gorm"
+)
+
+// PermissionOrganizationsManage allows creating organizations
+const PermissionOrganizationsManage = "organizations:manage"
+
+var (
+	ErrUnknownTenant      error = errors.New("unknown tenant")
+	ErrOrganizationExists error = errors.New("organization already exists")
+	ErrSignupClosed       error = errors.New("organization does not allow signup")
+	ErrNotMember          error = errors.New("user is not a member of the organization")
+)
+
+// ResolveTenant returns the ID of the organization with the given slug, or
+// the default tenant 0 for an empty slug
+func (f *fuzzy) ResolveTenant(slug string) (uint, error) {
+	if slug == "" {
+		return 0, nil
+	}
+	org, err := f.repo.GetOrganization(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownTenant, slug)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("repo get organization: %w", err)
+	}
+	return org.ID, nil
+}
+
+// CreateOrganization adds a tenant. Its members are added through
+// registration, when it allows signup, or invitations.
+func (f *fuzzy) CreateOrganization(dto model.CreateOrganizationDTO) (model.OrganizationDTO, error) {
+	if err := validator.New().Struct(dto); err != nil {
+		return model.OrganizationDTO{}, fmt.Errorf("validate struct: %w", err)
+	}
+
+	org := model.Organization{
+		Slug:        dto.Slug,
+		Name:        dto.Name,
+		AllowSignup: dto.AllowSignup,
+	}
+	err := f.repo.Create(&org)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return model.OrganizationDTO{}, fmt.Errorf("%w: %s", ErrOrganizationExists, dto.Slug)
+	}
+	if err != nil {
+		return model.OrganizationDTO{}, fmt.Errorf("create organization: %w", err)
+	}
+	return organizationDTO(org), nil
+}
+
+// ListOrganizations returns every organization
+func (f *fuzzy) ListOrganizations() ([]model.OrganizationDTO, error) {
+	orgs, err := f.repo.GetOrganizations()
+	if err != nil {
+		return nil, fmt.Errorf("repo get organizations: %w", err)
+	}
+	res := make([]model.OrganizationDTO, 0, len(orgs))
+	for _, org := range orgs {
+		res = append(res, organizationDTO(org))
+	}
+	return res, nil
+}
+
+// checkSignup refuses registrations in organizations which only take
+// invited users
+func (f *fuzzy) checkSignup(tenantID uint) error {
+	if tenantID == 0 {
+		return nil
+	}
+	org, err := f.repo.GetOrganizationByID(tenantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUnknownTenant
+	}
+	if err != nil {
+		return fmt.Errorf("repo get organization by id: %w", err)
+	}
+	if !org.AllowSignup {
+		return ErrSignupClosed
+	}
+	return nil
+}
+
+// withTenant adds the tenant of the user and the user's role within the
+// organization to the "tenant" and "org_role" claims. Users of an
+// organization who are no longer members get no tokens.
+func (f *fuzzy) withTenant(user model.User, info model.TokenInfo) (model.TokenInfo, error) {
+	if user.TenantID == 0 {
+		return info, nil
+	}
+	membership, err := f.repo.GetMembership(user.TenantID, user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.TokenInfo{}, ErrNotMember
+	}
+	if err != nil {
+		return model.TokenInfo{}, fmt.Errorf("repo get membership: %w", err)
+	}
+	return withClaims(info, map[string]any{
+		"tenant":   user.TenantID,
+		"org_role": membership.Role,
+	}), nil
+}
+
+// withClaims adds claims to the token without touching the map of info
+func withClaims(info model.TokenInfo, claims map[string]any) model.TokenInfo {
+	merged := make(map[string]any, len(info.Claims)+len(claims))
+	for k, v := range info.Claims {
+		merged[k] = v
+	}
+	for k, v := range claims {
+		merged[k] = v
+	}
+	info.Claims = merged
+	return info
+}
+
+func organizationDTO(org model.Organization) model.OrganizationDTO {
+	return model.OrganizationDTO{
+		ID:          org.ID,
+		Slug:        org.Slug,
+		Name:        org.Name,
+		AllowSignup: org.AllowSignup,
+		CreatedAt:   org.CreatedAt,
+	}
+}
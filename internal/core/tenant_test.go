@@ -0,0 +1,86 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_ResolveTenant(t *testing.T) {
+	org := model.Organization{Model: gorm.Model{ID: 3}, Slug: "acme"}
+	repo := newMemoryStore(t, &model.User{}, org)
+	fuzzy := core.NewFuzzy(repo, nil)
+
+	if id, err := fuzzy.ResolveTenant(""); err != nil || id != 0 {
+		t.Fatalf("expected default tenant, got %d, %v", id, err)
+	}
+	if id, err := fuzzy.ResolveTenant("acme"); err != nil || id != org.ID {
+		t.Fatalf("expected tenant %d, got %d, %v", org.ID, id, err)
+	}
+	if _, err := fuzzy.ResolveTenant("other"); !errors.Is(err, core.ErrUnknownTenant) {
+		t.Fatalf("expected unknown tenant, got %v", err)
+	}
+}
+
+func Test_RegisterUser_Tenant(t *testing.T) {
+	org := model.Organization{Model: gorm.Model{ID: 3}, Slug: "acme"}
+	user := &model.User{Model: gorm.Model{ID: 5}, Email: "test@test.com"}
+	repo := newMemoryStore(t, user, org)
+	fuzzy := core.NewFuzzy(repo, nil)
+	dto := model.RegisterDTO{FirstName: "Penko", LastName: "Penkov", Email: user.Email, Password: "s3curePass", TenantID: org.ID}
+
+	if err := fuzzy.RegisterUser(dto); !errors.Is(err, core.ErrSignupClosed) {
+		t.Fatalf("expected signup closed, got %v", err)
+	}
+
+	org.AllowSignup = true
+	repo = newMemoryStore(t, user, org)
+	fuzzy = core.NewFuzzy(repo, nil)
+	if err := fuzzy.RegisterUser(dto); err != nil {
+		t.Fatalf("the same email is free in another tenant, got %v", err)
+	}
+	if len(repo.memberships) != 1 {
+		t.Fatalf("expected 1 membership, got %d", len(repo.memberships))
+	}
+	for _, m := range repo.memberships {
+		if m.OrganizationID != org.ID || m.Role != model.MemberRoleMember {
+			t.Fatalf("unexpected membership: %+v", m)
+		}
+	}
+}
+
+func Test_LoginUser_Tenant(t *testing.T) {
+	org := model.Organization{Model: gorm.Model{ID: 3}, Slug: "acme"}
+	user := &model.User{Model: gorm.Model{ID: 5}, Email: "test@test.com", TenantID: org.ID, EmailVerified: true}
+	repo := newMemoryStore(t, user, org)
+	repo.memberships[user.ID] = model.Membership{OrganizationID: org.ID, UserID: user.ID, Role: model.MemberRoleAdmin}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")))
+
+	if _, err := fuzzy.LoginUser(model.LoginDTO{Email: user.Email, Password: "current"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected user not found in the default tenant, got %v", err)
+	}
+
+	dto := model.LoginDTO{Email: user.Email, Password: "current", TenantID: org.ID}
+	tokens, err := fuzzy.LoginUser(dto)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	claims, err := fuzzy.VerifyUser(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if claims["tenant"] != float64(org.ID) || claims["org_role"] != model.MemberRoleAdmin {
+		t.Fatalf("unexpected tenant claims: %v, %v", claims["tenant"], claims["org_role"])
+	}
+
+	delete(repo.memberships, user.ID)
+	if _, err := fuzzy.LoginUser(dto); !errors.Is(err, core.ErrNotMember) {
+		t.Fatalf("expected not a member, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/throttle"
+)
+
+var ErrTooManyAttempts error = errors.New("too many login attempts")
+
+// ThrottledError is returned instead of checking the credentials while the
+// account is locked or the client ran out of attempts
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrTooManyAttempts, e.RetryAfter)
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// LoginThrottle holds the limits which slow down password guessing
+type LoginThrottle struct {
+	// FreeAttempts is the number of failed logins an account may have
+	// before each further attempt has to wait
+	FreeAttempts int
+	// BaseDelay is the wait after the first failure past FreeAttempts. It
+	// doubles with every further failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is the time after which failed logins are forgotten
+	Window time.Duration
+	// PerIP limits the login attempts of a single client, successful or not
+	PerIP throttle.Limit
+}
+
+var DefaultLoginThrottle = LoginThrottle{
+	FreeAttempts: 5,
+	BaseDelay:    time.Second,
+	MaxDelay:     15 * time.Minute,
+	Window:       24 * time.Hour,
+	PerIP:        throttle.PerMinute(10, 20),
+}
+
+// WithLoginThrottle limits failed logins per account and login attempts per
+// client, with the counters kept in store
+func WithLoginThrottle(store ThrottleStore, limits LoginThrottle) Option {
+	return func(f *fuzzy) {
+		f.throttle = store
+		f.loginThrottle = limits
+	}
+}
+
+// Delay returns how long an account has to wait after the given number of
+// failed logins
+func (t LoginThrottle) Delay(failures int) time.Duration {
+	excess := failures - t.FreeAttempts
+	if excess < 0 || t.BaseDelay <= 0 {
+		return 0
+	}
+	if excess > 30 {
+		return t.MaxDelay
+	}
+	delay := t.BaseDelay << excess
+	if t.MaxDelay > 0 && delay > t.MaxDelay {
+		return t.MaxDelay
+	}
+	return delay
+}
+
+// reserveAttempt takes one of the attempts of the client and counts the
+// attempt as a failure of the account up front, or refuses it while the
+// account waits out its failures. Counting before the credentials are
+// checked keeps parallel guesses from all passing the check; the attempt
+// is forgotten with resetFailures once the credentials match. It is a
+// no-op when no throttle store is configured.
+func (f *fuzzy) reserveAttempt(account, clientIP string) error {
+	if f.throttle == nil {
+		return nil
+	}
+	now := TimeNow()
+
+	if clientIP != "" && f.loginThrottle.PerIP.Enabled() {
+		res, err := f.throttle.TakeRateToken("login_ip:"+clientIP, f.loginThrottle.PerIP, now)
+		if err != nil {
+			return fmt.Errorf("take rate token: %w", err)
+		}
+		if !res.Allowed {
+			return &ThrottledError{RetryAfter: res.RetryAfter}
+		}
+	}
+
+	wait, err := f.throttle.ReserveLoginAttempt(account, now, f.loginThrottle.Window, f.loginThrottle.Delay)
+	if err != nil {
+		return fmt.Errorf("reserve login attempt: %w", err)
+	}
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// resetFailures forgets the failed logins of the account. Failures are not
+// fatal, the count runs out with the window anyway.
+func (f *fuzzy) resetFailures(account string) {
+	if f.throttle == nil {
+		return
+	}
+	_ = f.throttle.ResetLoginFailures(account)
+}
+
+// passwordThrottleKey keeps the key of the default tenant unprefixed, so
+// failures recorded before organizations existed still count
+func passwordThrottleKey(tenantID uint, email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if tenantID == 0 {
+		return "login:" + email
+	}
+	return "login:" + strconv.FormatUint(uint64(tenantID), 10) + ":" + email
+}
+
+func mfaThrottleKey(userID uint) string {
+	return "mfa:" + strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,148 @@
+package core_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/throttle"
+	"gorm.io/gorm"
+)
+
+func Test_LoginThrottle_Delay(t *testing.T) {
+	limits := core.LoginThrottle{FreeAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	cases := map[int]time.Duration{
+		0:  0,
+		2:  0,
+		3:  time.Second,
+		4:  2 * time.Second,
+		6:  8 * time.Second,
+		7:  10 * time.Second,
+		90: 10 * time.Second,
+	}
+	for failures, expected := range cases {
+		if got := limits.Delay(failures); got != expected {
+			t.Errorf("delay after %d failures: expected %s, got %s", failures, expected, got)
+		}
+	}
+}
+
+func Test_LoginUser_LocksAccount(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 4}, Email: "test@test.com", EmailVerified: true}
+	repo := newMemoryStore(t, user)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	core.TimeNow = func() time.Time { return now }
+	defer func() { core.TimeNow = time.Now }()
+	jwtgen.TimeNow = time.Now
+	limits := core.LoginThrottle{
+		FreeAttempts: 2,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+		Window:       24 * time.Hour,
+	}
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithLoginThrottle(throttle.NewMemoryStore(), limits),
+	)
+	wrong := model.LoginDTO{Email: user.Email, Password: "wrong"}
+	right := model.LoginDTO{Email: user.Email, Password: "current"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := fuzzy.LoginUser(wrong); !errors.Is(err, core.ErrInvalidPassword) {
+			t.Fatalf("expected invalid password, got %v", err)
+		}
+	}
+
+	var throttled *core.ThrottledError
+	_, err := fuzzy.LoginUser(right)
+	if !errors.As(err, &throttled) {
+		t.Fatalf("expected locked account, got %v", err)
+	}
+	if throttled.RetryAfter != time.Minute {
+		t.Errorf("expected to wait a minute, got %s", throttled.RetryAfter)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := fuzzy.LoginUser(wrong); !errors.Is(err, core.ErrInvalidPassword) {
+		t.Fatalf("expected invalid password, got %v", err)
+	}
+	_, err = fuzzy.LoginUser(right)
+	if !errors.As(err, &throttled) || throttled.RetryAfter != 2*time.Minute {
+		t.Fatalf("expected to wait two minutes, got %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := fuzzy.LoginUser(right); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := fuzzy.LoginUser(wrong); !errors.Is(err, core.ErrInvalidPassword) {
+		t.Fatalf("login did not reset the failures: %v", err)
+	}
+}
+
+func Test_LoginUser_LimitsClient(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 4}, Email: "test@test.com", EmailVerified: true}
+	repo := newMemoryStore(t, user)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	core.TimeNow = func() time.Time { return now }
+	defer func() { core.TimeNow = time.Now }()
+	limits := core.DefaultLoginThrottle
+	limits.PerIP = throttle.PerMinute(1, 2)
+	fuzzy := core.NewFuzzy(
+		repo,
+		jwtgen.NewJwtGenerator([]byte("test_secret")),
+		core.WithLoginThrottle(throttle.NewMemoryStore(), limits),
+	)
+
+	for i := 0; i < 2; i++ {
+		dto := model.LoginDTO{Email: "unknown@test.com", Password: "wrong", ClientIP: "10.0.0.1"}
+		if _, err := fuzzy.LoginUser(dto); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("expected unknown user, got %v", err)
+		}
+	}
+
+	var throttled *core.ThrottledError
+	_, err := fuzzy.LoginUser(model.LoginDTO{Email: user.Email, Password: "current", ClientIP: "10.0.0.1"})
+	if !errors.As(err, &throttled) || throttled.RetryAfter != time.Minute {
+		t.Fatalf("expected to wait a minute, got %v", err)
+	}
+	if _, err := fuzzy.LoginUser(model.LoginDTO{Email: user.Email, Password: "current", ClientIP: "10.0.0.2"}); err != nil {
+		t.Fatalf("unexpected error for another client: %s", err)
+	}
+}
+
+func Test_LoginUser_ParallelGuesses(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 4}, Email: "test@test.com", EmailVerified: true}
+	repo := newMemoryStore(t, user)
+	limits := core.LoginThrottle{
+		FreeAttempts: 3,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+		Window:       24 * time.Hour,
+	}
+	fuzzy := core.NewFuzzy(repo, nil, core.WithLoginThrottle(throttle.NewMemoryStore(), limits))
+
+	var wg sync.WaitGroup
+	var checked atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := fuzzy.LoginUser(model.LoginDTO{Email: user.Email, Password: "wrong"})
+			if errors.Is(err, core.ErrInvalidPassword) {
+				checked.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if checked.Load() != 3 {
+		t.Fatalf("expected 3 passwords checked before the lock, got %d", checked.Load())
+	}
+}
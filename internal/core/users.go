@@ -0,0 +1,256 @@
+package core
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"gorm.io/gorm"
+)
+
+const defaultUsersPageSize = 50
+
+var (
+	ErrInvalidCursor   error = errors.New("invalid cursor")
+	ErrAccountDisabled error = errors.New("account disabled")
+)
+
+// ListUsers returns a page of the tenant's users ordered by ID. Soft
+// deleted users are only listed with the "deleted" status.
+func (f *fuzzy) ListUsers(tenantID uint, query model.UserQuery) (model.UserPageDTO, error) {
+	if err := validator.New().Struct(query); err != nil {
+		return model.UserPageDTO{}, fmt.Errorf("validate struct: %w", err)
+	}
+	afterID, err := decodeCursor(query.Cursor)
+	if err != nil {
+		return model.UserPageDTO{}, err
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultUsersPageSize
+	}
+
+	// one more user than asked for tells whether there is a next page
+	users, err := f.repo.GetUsers(model.UserFilter{
+		TenantID:      tenantID,
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+		Status:        query.Status,
+		Domain:        query.Domain,
+		AfterID:       afterID,
+		Limit:         limit + 1,
+	})
+	if err != nil {
+		return model.UserPageDTO{}, fmt.Errorf("repo get users: %w", err)
+	}
+
+	page := model.UserPageDTO{Users: make([]model.AdminUserDTO, 0, limit)}
+	if len(users) > limit {
+		users = users[:limit]
+		page.NextCursor = encodeCursor(users[limit-1].ID)
+	}
+	for _, user := range users {
+		page.Users = append(page.Users, adminUserDTO(user))
+	}
+	return page, nil
+}
+
+// GetUserDetails returns a user of the tenant, soft deleted or not
+func (f *fuzzy) GetUserDetails(tenantID, userID uint) (model.AdminUserDTO, error) {
+	user, err := f.tenantUser(tenantID, userID)
+	if err != nil {
+		return model.AdminUserDTO{}, err
+	}
+	return adminUserDTO(user), nil
+}
+
+// UpdateUser changes the fields set in the dto. Email addresses stay unique
+// within the tenant. Like a change by the user, a new email address ends the
+// user's sessions and needs to be verified again, unless the dto says
+// otherwise.
+func (f *fuzzy) UpdateUser(tenantID, userID uint, dto model.UpdateUserDTO) (model.AdminUserDTO, error) {
+	if err := validator.New().Struct(dto); err != nil {
+		return model.AdminUserDTO{}, fmt.Errorf("validate struct: %w", err)
+	}
+	user, err := f.tenantUser(tenantID, userID)
+	if err != nil {
+		return model.AdminUserDTO{}, err
+	}
+
+	fields := map[string]any{}
+	if dto.FirstName != nil {
+		fields["first_name"] = *dto.FirstName
+	}
+	if dto.LastName != nil {
+		fields["last_name"] = *dto.LastName
+	}
+	emailChanged := dto.Email != nil && *dto.Email != user.Email
+	if emailChanged {
+		fields["email"] = *dto.Email
+		fields["email_verified"] = false
+	}
+	if dto.EmailVerified != nil {
+		fields["email_verified"] = *dto.EmailVerified
+	}
+	if dto.Locale != nil {
+		fields["locale"] = *dto.Locale
+	}
+	if len(fields) == 0 {
+		return adminUserDTO(user), nil
+	}
+
+	updated, err := f.repo.UpdateUser(user.ID, fields)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return model.AdminUserDTO{}, ErrEmailTaken
+	}
+	if err != nil {
+		return model.AdminUserDTO{}, fmt.Errorf("repo update user: %w", err)
+	}
+	if !updated {
+		return model.AdminUserDTO{}, ErrUserNotFound
+	}
+	if emailChanged {
+		if err := f.repo.RevokeUserSessions(user.ID, TimeNow()); err != nil {
+			return model.AdminUserDTO{}, fmt.Errorf("repo revoke user sessions: %w", err)
+		}
+	}
+	return f.GetUserDetails(tenantID, userID)
+}
+
+// DisableUser keeps the user from logging in and ends the user's sessions
+func (f *fuzzy) DisableUser(tenantID, userID uint) error {
+	user, err := f.tenantUser(tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+	now := TimeNow()
+	if err := f.setDisabled(user.ID, &now); err != nil {
+		return err
+	}
+	if err := f.repo.RevokeUserSessions(user.ID, now); err != nil {
+		return fmt.Errorf("repo revoke user sessions: %w", err)
+	}
+	return nil
+}
+
+// EnableUser lets a disabled user log in again
+func (f *fuzzy) EnableUser(tenantID, userID uint) error {
+	user, err := f.tenantUser(tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt == nil {
+		return nil
+	}
+	return f.setDisabled(user.ID, nil)
+}
+
+// DeleteUser soft deletes the user and ends the user's sessions. The email
+// address stays taken until the user is hard deleted, which also removes
+// everything stored for the user.
+func (f *fuzzy) DeleteUser(tenantID, userID uint, hard bool) error {
+	user, err := f.tenantUser(tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if !hard && user.DeletedAt.Valid {
+		return nil
+	}
+	// A hard delete removes the row the revocation time would be stored in.
+	// Access tokens of the user are rejected anyway, since checkRevoked no
+	// longer finds the user.
+	if !hard {
+		if err := f.repo.RevokeUserSessions(user.ID, TimeNow()); err != nil {
+			return fmt.Errorf("repo revoke user sessions: %w", err)
+		}
+	}
+
+	deleted, err := f.repo.DeleteUser(user.ID, hard)
+	if err != nil {
+		return fmt.Errorf("repo delete user: %w", err)
+	}
+	if !deleted {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (f *fuzzy) setDisabled(userID uint, disabledAt *time.Time) error {
+	updated, err := f.repo.SetUserDisabled(userID, disabledAt)
+	if err != nil {
+		return fmt.Errorf("repo set user disabled: %w", err)
+	}
+	if !updated {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// tenantUser loads a user of the tenant, including soft deleted ones
+func (f *fuzzy) tenantUser(tenantID, userID uint) (model.User, error) {
+	user, err := f.repo.GetUserUnscoped(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return model.User{}, fmt.Errorf("repo get user unscoped: %w", err)
+	}
+	if user.TenantID != tenantID {
+		return model.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func encodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodeCursor(cursor string) (uint, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return uint(id), nil
+}
+
+func adminUserDTO(user model.User) model.AdminUserDTO {
+	res := model.AdminUserDTO{
+		ID:            user.ID,
+		TenantID:      user.TenantID,
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Locale:        user.Locale,
+		Status:        model.UserStatusActive,
+		EmailVerified: user.EmailVerified,
+		HasPassword:   user.PasswordHash != "",
+		MFAEnabled:    user.TOTPEnabledAt != nil,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		DisabledAt:    user.DisabledAt,
+	}
+	switch {
+	case user.DeletedAt.Valid:
+		res.Status = model.UserStatusDeleted
+		res.DeletedAt = &user.DeletedAt.Time
+	case user.DisabledAt != nil:
+		res.Status = model.UserStatusDisabled
+	case !user.EmailVerified:
+		res.Status = model.UserStatusUnverified
+	}
+	return res
+}
@@ -0,0 +1,159 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	jwtgen "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func Test_ListUsers_Pages(t *testing.T) {
+	var stored []model.User
+	for id := uint(1); id <= 5; id++ {
+		stored = append(stored, model.User{Model: gorm.Model{ID: id}, Email: fmt.Sprintf("user%d@test.com", id)})
+	}
+	var filters []model.UserFilter
+	repo := &repositoryMock{
+		getUsers: func(filter model.UserFilter) ([]model.User, error) {
+			filters = append(filters, filter)
+			var res []model.User
+			for _, user := range stored {
+				if user.ID > filter.AfterID && len(res) < filter.Limit {
+					res = append(res, user)
+				}
+			}
+			return res, nil
+		},
+	}
+	fuzzy := core.NewFuzzy(repo, nil)
+
+	var ids []uint
+	query := model.UserQuery{Limit: 2, Domain: "test.com"}
+	for pages := 1; ; pages++ {
+		page, err := fuzzy.ListUsers(3, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		for _, user := range page.Users {
+			ids = append(ids, user.ID)
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Fatalf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Fatalf("unexpected users: %v", ids)
+	}
+	if filters[0].TenantID != 3 || filters[0].Domain != "test.com" || filters[0].Limit != 3 {
+		t.Fatalf("unexpected filter: %+v", filters[0])
+	}
+
+	if _, err := fuzzy.ListUsers(3, model.UserQuery{Cursor: "not a cursor"}); !errors.Is(err, core.ErrInvalidCursor) {
+		t.Fatalf("expected invalid cursor, got %v", err)
+	}
+}
+
+func Test_DisableUser(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 4}, Email: "test@test.com", EmailVerified: true}
+	repo := newMemoryStore(t, user)
+	repo.getUserUnscoped = repo.getUserByID
+	repo.setUserDisabled = func(id uint, disabledAt *time.Time) (bool, error) {
+		user.DisabledAt = disabledAt
+		return true, nil
+	}
+	jwtgen.TimeNow = time.Now
+	fuzzy := core.NewFuzzy(repo, jwtgen.NewJwtGenerator([]byte("test_secret")))
+	login := model.LoginDTO{Email: user.Email, Password: "current"}
+
+	if err := fuzzy.DisableUser(7, user.ID); !errors.Is(err, core.ErrUserNotFound) {
+		t.Fatalf("expected users of other tenants to be hidden, got %v", err)
+	}
+	if err := fuzzy.DisableUser(0, user.ID); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(repo.sessionRevocations) != 1 {
+		t.Fatalf("expected sessions to be revoked, got %d revocations", len(repo.sessionRevocations))
+	}
+	if _, err := fuzzy.LoginUser(login); !errors.Is(err, core.ErrAccountDisabled) {
+		t.Fatalf("expected account disabled, got %v", err)
+	}
+	details, err := fuzzy.GetUserDetails(0, user.ID)
+	if err != nil || details.Status != model.UserStatusDisabled {
+		t.Fatalf("expected disabled status, got %+v, %v", details, err)
+	}
+
+	if err := fuzzy.EnableUser(0, user.ID); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := fuzzy.LoginUser(login); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func Test_UpdateUser(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 4}, FirstName: "Penko", Email: "test@test.com"}
+	repo := newMemoryStore(t, user)
+	repo.getUserUnscoped = repo.getUserByID
+	var got map[string]any
+	repo.updateUser = func(id uint, fields map[string]any) (bool, error) {
+		got = fields
+		if fields["email"] == "taken@test.com" {
+			return false, fmt.Errorf("mock error: %w", gorm.ErrDuplicatedKey)
+		}
+		return true, nil
+	}
+	fuzzy := core.NewFuzzy(repo, nil)
+
+	name, verified, email := "Stoyan", true, "test@test.com"
+	if _, err := fuzzy.UpdateUser(0, user.ID, model.UpdateUserDTO{FirstName: &name, EmailVerified: &verified, Email: &email}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(got) != 2 || got["first_name"] != name || got["email_verified"] != true {
+		t.Fatalf("expected only the changed fields, got %v", got)
+	}
+
+	taken := "taken@test.com"
+	if _, err := fuzzy.UpdateUser(0, user.ID, model.UpdateUserDTO{Email: &taken}); !errors.Is(err, core.ErrEmailTaken) {
+		t.Fatalf("expected email taken, got %v", err)
+	}
+}
+
+func Test_UpdateUser_EmailChange(t *testing.T) {
+	user := &model.User{Model: gorm.Model{ID: 4}, Email: "test@test.com", EmailVerified: true}
+	repo := newMemoryStore(t, user)
+	repo.getUserUnscoped = repo.getUserByID
+	var got map[string]any
+	repo.updateUser = func(id uint, fields map[string]any) (bool, error) {
+		got = fields
+		return true, nil
+	}
+	fuzzy := core.NewFuzzy(repo, nil)
+
+	email := "new@test.com"
+	if _, err := fuzzy.UpdateUser(0, user.ID, model.UpdateUserDTO{Email: &email}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["email"] != email || got["email_verified"] != false {
+		t.Fatalf("expected the new email to be unverified, got %v", got)
+	}
+	if len(repo.sessionRevocations) != 1 {
+		t.Fatalf("expected the user's sessions to be revoked, got %d revocations", len(repo.sessionRevocations))
+	}
+
+	verified := true
+	if _, err := fuzzy.UpdateUser(0, user.ID, model.UpdateUserDTO{Email: &email, EmailVerified: &verified}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["email_verified"] != true {
+		t.Fatalf("expected an explicit verified flag to win, got %v", got)
+	}
+}
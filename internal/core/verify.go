@@ -1,14 +1,106 @@
 package core
 
 import (
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTokenRevoked error = errors.New("token revoked")
+	ErrTokenUse     error = errors.New("token is not an access token")
 )
 
+// tokenUseClaim marks tokens which must not be accepted as access tokens
+const tokenUseClaim = "token_use"
+
+// oidcAccessUse marks access tokens issued to OpenID Connect relying
+// parties. They only grant access to /userinfo and introspection, not to
+// the endpoints of the service itself.
+const oidcAccessUse = "oidc_access"
+
 func (f *fuzzy) VerifyUser(jwtToken string) (map[string]any, error) {
+	return f.verifyAccessToken(jwtToken, "access")
+}
+
+// verifyAccessToken validates the token and checks it has not been revoked.
+// Tokens carrying a token_use claim are only accepted for the given uses.
+func (f *fuzzy) verifyAccessToken(jwtToken string, uses ...string) (map[string]any, error) {
 	claims, err := f.jwtIssuer.Validate(jwtToken)
 	if err != nil {
 		return nil, fmt.Errorf("jwt validate: %w", err)
 	}
 
+	if use, ok := claims[tokenUseClaim]; ok {
+		if use, _ := use.(string); !contains(uses, use) {
+			return nil, ErrTokenUse
+		}
+	}
+
+	if err := f.checkRevoked(claims); err != nil {
+		return nil, fmt.Errorf("check revoked: %w", err)
+	}
+
 	return map[string]any(claims), nil
 }
+
+// checkRevoked looks the token up in the revocation list and compares its
+// issue time with the user's sessions revocation time. Tokens issued before
+// the jti and uid claims were introduced carry neither and are only
+// subject to their expiration. Tokens of deleted users are revoked as well.
+func (f *fuzzy) checkRevoked(claims jwt.MapClaims) error {
+	if jti, ok := claims["jti"].(string); ok {
+		revoked, err := f.repo.IsTokenRevoked(jti)
+		if err != nil {
+			return fmt.Errorf("repo is token revoked: %w", err)
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
+	}
+
+	userID, ok := numericClaim(claims, "uid")
+	if !ok {
+		return nil
+	}
+	user, err := f.repo.GetUserByID(uint(userID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTokenRevoked
+	}
+	if err != nil {
+		return fmt.Errorf("repo get user by id: %w", err)
+	}
+	if user.SessionsRevokedAt == nil {
+		return nil
+	}
+	issuedAt, _ := numericClaim(claims, "iat")
+	if issuedAt < user.SessionsRevokedAt.Unix() {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+func numericClaim(claims jwt.MapClaims, key string) (int64, bool) {
+	switch v := claims[key].(type) {
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case uint:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func claimExpiration(claims jwt.MapClaims) time.Time {
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return TimeNow()
+	}
+	return time.Unix(exp, 0)
+}
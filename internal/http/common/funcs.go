@@ -3,7 +3,11 @@ package common
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
 )
@@ -25,3 +29,153 @@ func WriteResponse(w http.ResponseWriter, message string, statusCode int) error
 	}
 	return nil
 }
+
+// SetAuthCookies sets the access token in the "Authentication" cookie
+// and the refresh token in the "Refresh" cookie.
+func SetAuthCookies(w http.ResponseWriter, tokens model.AuthTokens) {
+	access := http.Cookie{}
+	access.Name = "Authentication"
+	access.Value = tokens.AccessToken
+	access.Expires = tokens.AccessExpiresAt
+	access.Secure = false
+	access.HttpOnly = true
+	access.Path = "/"
+	http.SetCookie(w, &access)
+
+	if tokens.RefreshToken == "" {
+		return
+	}
+	refresh := http.Cookie{}
+	refresh.Name = "Refresh"
+	refresh.Value = tokens.RefreshToken
+	refresh.Expires = tokens.RefreshExpiresAt
+	refresh.Secure = false
+	refresh.HttpOnly = true
+	refresh.Path = "/api"
+	http.SetCookie(w, &refresh)
+}
+
+// ClearAuthCookies instructs the client to drop the cookies set by SetAuthCookies.
+func ClearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: "Authentication", Path: "/", MaxAge: -1, HttpOnly: true})
+	http.SetCookie(w, &http.Cookie{Name: "Refresh", Path: "/api", MaxAge: -1, HttpOnly: true})
+}
+
+// WriteJSON writes the JSON encoding of body as the response
+func WriteJSON(w http.ResponseWriter, body any, statusCode int) error {
+	resp, err := json.Marshal(body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		if _, err := w.Write([]byte("Something went wrong!")); err != nil {
+			return fmt.Errorf("response write: %w", err)
+		}
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(resp); err != nil {
+		return fmt.Errorf("response write: %w", err)
+	}
+	return nil
+}
+
+// SetRetryAfter tells the client in the "Retry-After" header how many
+// seconds to wait before trying again
+func SetRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// ClientIP returns the address the request came from. Behind a proxy it is
+// the address middleware.ForwardedFor took from the proxy headers.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>" header
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// AuthToken extracts the access token from the "Authorization" header and
+// falls back to the "Authentication" cookie set on login
+func AuthToken(r *http.Request) (string, bool) {
+	if token, ok := BearerToken(r); ok {
+		return token, true
+	}
+	cookie, err := r.Cookie("Authentication")
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// CurrentUserID returns the uid claim of the user authenticated by the
+// middleware.Authenticate wrapper. Tokens issued to clients carry none.
+func CurrentUserID(r *http.Request) (uint, bool) {
+	claims, ok := r.Context().Value(model.CurrentUser).(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	switch uid := claims["uid"].(type) {
+	case float64:
+		return uint(uid), uid > 0
+	case json.Number:
+		id, err := strconv.ParseUint(string(uid), 10, 64)
+		return uint(id), err == nil && id > 0
+	}
+	return 0, false
+}
+
+// CurrentTenant returns the tenant the request is served for, as resolved
+// from the "X-Tenant" header or the access token. It is 0 for the default
+// tenant.
+func CurrentTenant(r *http.Request) uint {
+	tenantID, _ := r.Context().Value(model.Tenant).(uint)
+	return tenantID
+}
+
+// TokenTenant returns the tenant claim of an access token, 0 for tokens of
+// the default tenant
+func TokenTenant(claims map[string]any) uint {
+	switch tenant := claims["tenant"].(type) {
+	case float64:
+		return uint(tenant)
+	case json.Number:
+		id, _ := strconv.ParseUint(string(tenant), 10, 64)
+		return uint(id)
+	}
+	return 0
+}
+
+// OrgActor describes who manages the organization of the request. Routes
+// opened with the admin key carry no claims and act as an owner.
+func OrgActor(r *http.Request) model.OrgActor {
+	actor := model.OrgActor{TenantID: CurrentTenant(r), Role: model.MemberRoleOwner}
+	claims, ok := r.Context().Value(model.CurrentUser).(map[string]any)
+	if !ok {
+		return actor
+	}
+	actor.UserID, _ = CurrentUserID(r)
+	actor.Role, _ = claims["org_role"].(string)
+	return actor
+}
+
+// WriteOAuthError writes an error response as defined in RFC 6749, section 5.2
+func WriteOAuthError(w http.ResponseWriter, code, description string, statusCode int) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	body := model.OAuthErrorResponse{Error: code, ErrorDescription: description}
+	return WriteJSON(w, body, statusCode)
+}
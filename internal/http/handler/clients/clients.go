@@ -0,0 +1,106 @@
+package clients
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type clientsHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewClientsHandler(logger *zap.SugaredLogger, reg Registry) *clientsHandler {
+	return &clientsHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *clientsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.RegisterClientDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	creds, err := m.registry.RegisterClient(dto)
+	if err != nil {
+		m.logs.Errorw(
+			"register client failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, core.ErrInvalidClientScope) {
+			msg = "scope is not allowed for clients"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (register client)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err := common.WriteJSON(w, creds, http.StatusCreated); err != nil {
+		m.logs.Errorw(
+			"write response failed (register client success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"registered client",
+		"client_id", creds.ClientID,
+		"name", creds.Name,
+		"request_id", requestID,
+	)
+}
@@ -0,0 +1,66 @@
+package clients_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/clients"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type clientsMock struct {
+	registerClient func(dto model.RegisterClientDTO) (model.ClientCredentials, error)
+}
+
+func (c *clientsMock) RegisterClient(dto model.RegisterClientDTO) (model.ClientCredentials, error) {
+	return c.registerClient(dto)
+}
+
+func Test_ServeHTTP_Success(t *testing.T) {
+	registry := &clientsMock{
+		registerClient: func(dto model.RegisterClientDTO) (model.ClientCredentials, error) {
+			return model.ClientCredentials{
+				ClientID:     "client-id",
+				ClientSecret: "client-secret",
+				Name:         dto.Name,
+				Scope:        strings.Join(dto.Scopes, " "),
+			}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(clients.NewClientsHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"name": "nightly-report", "scopes": ["reports:read"]}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/clients", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var got model.ClientCredentials
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.ClientSecret != "client-secret" || got.Scope != "reports:read" {
+		t.Fatalf("unexpected credentials: %+v", got)
+	}
+	if http.StatusCreated != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusCreated, response.Code)
+	}
+}
+
+func Test_ServeHTTP_InvalidBody(t *testing.T) {
+	handler := middleware.SetContextRequestID(clients.NewClientsHandler(zap.NewNop().Sugar(), &clientsMock{}))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/clients", strings.NewReader("{ invalid json }"))
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
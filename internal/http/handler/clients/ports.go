@@ -0,0 +1,7 @@
+package clients
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	RegisterClient(dto model.RegisterClientDTO) (model.ClientCredentials, error)
+}
@@ -0,0 +1,88 @@
+package email_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/email"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"go.uber.org/zap"
+)
+
+type registryMock struct {
+	verifyEmail           func(token string) error
+	sendEmailVerification func(email string) error
+}
+
+func (r *registryMock) VerifyEmail(token string) error {
+	return r.verifyEmail(token)
+}
+func (r *registryMock) SendEmailVerification(tenantID uint, email string) error {
+	return r.sendEmailVerification(email)
+}
+
+func Test_VerifyEmail_Link(t *testing.T) {
+	registry := &registryMock{
+		verifyEmail: func(token string) error {
+			if token != "verification_token" {
+				return fmt.Errorf("%w: unexpected token", core.ErrInvalidVerificationToken)
+			}
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(email.NewVerifyEmailHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/verify-email?token=verification_token", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_VerifyEmail_InvalidToken(t *testing.T) {
+	registry := &registryMock{
+		verifyEmail: func(token string) error {
+			return fmt.Errorf("%w: token already used", core.ErrInvalidVerificationToken)
+		},
+	}
+	handler := middleware.SetContextRequestID(email.NewVerifyEmailHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/verify-email", strings.NewReader(`{"token": "used_token"}`))
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
+
+func Test_ResendVerification(t *testing.T) {
+	var sentTo string
+	registry := &registryMock{
+		sendEmailVerification: func(email string) error {
+			sentTo = email
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(email.NewResendVerificationHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/verify-email/resend", strings.NewReader(`{"email": "test@test.com"}`))
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	if sentTo != "test@test.com" {
+		t.Fatalf("email does not match, expected: %s, got: %s", "test@test.com", sentTo)
+	}
+}
@@ -0,0 +1,6 @@
+package email
+
+type Registry interface {
+	VerifyEmail(token string) error
+	SendEmailVerification(tenantID uint, email string) error
+}
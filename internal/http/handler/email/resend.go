@@ -0,0 +1,85 @@
+package email
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type resendVerificationHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewResendVerificationHandler sends a new verification token. It replies the
+// same way whether or not an unverified account with the email exists.
+func NewResendVerificationHandler(logger *zap.SugaredLogger, reg Registry) *resendVerificationHandler {
+	return &resendVerificationHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *resendVerificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.ResendVerificationDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil || dto.Email == "" {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.SendEmailVerification(common.CurrentTenant(r), dto.Email); err != nil {
+		m.logs.Errorw(
+			"send email verification failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "something went wrong on our end", http.StatusInternalServerError); err != nil {
+			m.logs.Errorw(
+				"write response failed (resend verification)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	msg := "if the account exists and is not verified, a verification email has been sent"
+	if err := common.WriteResponse(w, msg, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (resend verification success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
@@ -0,0 +1,104 @@
+package email
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type verifyEmailHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewVerifyEmailHandler accepts the verification token either in the
+// "token" query parameter of an emailed link or in a JSON body
+func NewVerifyEmailHandler(logger *zap.SugaredLogger, reg Registry) *verifyEmailHandler {
+	return &verifyEmailHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *verifyEmailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto model.VerifyEmailDTO
+	switch r.Method {
+	case http.MethodGet:
+		dto.Token = r.URL.Query().Get("token")
+	case http.MethodPost:
+		defer r.Body.Close()
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			m.logs.Warnw(
+				"json decode failed",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+	default:
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if dto.Token == "" {
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (missing token)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.VerifyEmail(dto.Token); err != nil {
+		m.logs.Warnw(
+			"email verification failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrInvalidVerificationToken) {
+			msg = "invalid or expired verification token"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (verify email)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteResponse(w, "email verified successfully", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (verify email success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"email verified",
+		"request_id", requestID,
+	)
+}
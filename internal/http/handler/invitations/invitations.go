@@ -0,0 +1,339 @@
+package invitations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type invitationsHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewInvitationsHandler lists the invitations of the organization on GET
+// and invites someone on POST
+func NewInvitationsHandler(logger *zap.SugaredLogger, reg Registry) *invitationsHandler {
+	return &invitationsHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *invitationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		m.list(w, r, requestID)
+		return
+	case http.MethodPost:
+	default:
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.CreateInvitationDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	actor := common.OrgActor(r)
+	invitation, err := m.registry.CreateInvitation(actor, dto)
+	if err != nil {
+		m.logs.Errorw(
+			"create invitation failed",
+			"error", err,
+			"tenant", actor.TenantID,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (create invitation)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	m.logs.Infow(
+		"invitation sent",
+		"invitation_id", invitation.ID,
+		"tenant", actor.TenantID,
+		"request_id", requestID,
+	)
+	if err := common.WriteJSON(w, invitation, http.StatusCreated); err != nil {
+		m.logs.Errorw(
+			"write response failed (create invitation success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+func (m *invitationsHandler) list(w http.ResponseWriter, r *http.Request, requestID string) {
+	invitations, err := m.registry.ListInvitations(common.OrgActor(r))
+	if err != nil {
+		m.logs.Errorw(
+			"list invitations failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (list invitations)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err := common.WriteJSON(w, invitations, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (list invitations success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+type invitationActionHandler struct {
+	logs   *zap.SugaredLogger
+	action func(actor model.OrgActor, id uint) error
+	done   string
+}
+
+// NewRevokeInvitationHandler revokes the pending invitation with the
+// posted id
+func NewRevokeInvitationHandler(logger *zap.SugaredLogger, reg Registry) *invitationActionHandler {
+	return &invitationActionHandler{
+		logs:   logger,
+		action: reg.RevokeInvitation,
+		done:   "invitation revoked",
+	}
+}
+
+// NewResendInvitationHandler emails a new link for the pending invitation
+// with the posted id
+func NewResendInvitationHandler(logger *zap.SugaredLogger, reg Registry) *invitationActionHandler {
+	return &invitationActionHandler{
+		logs:   logger,
+		action: reg.ResendInvitation,
+		done:   "invitation resent",
+	}
+}
+
+func (m *invitationActionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.InvitationActionDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil || dto.ID == 0 {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.action(common.OrgActor(r), dto.ID); err != nil {
+		m.logs.Errorw(
+			"invitation action failed",
+			"error", err,
+			"invitation_id", dto.ID,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (invitation action)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	m.logs.Infow(
+		m.done,
+		"invitation_id", dto.ID,
+		"request_id", requestID,
+	)
+	if err := common.WriteResponse(w, m.done, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (invitation action success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+type acceptInvitationHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewAcceptInvitationHandler adds the holder of an invitation token to the
+// organization, registering the user when needed
+func NewAcceptInvitationHandler(logger *zap.SugaredLogger, reg Registry) *acceptInvitationHandler {
+	return &acceptInvitationHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *acceptInvitationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.AcceptInvitationDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	err := m.registry.AcceptInvitation(dto)
+	var policyErr *core.PolicyError
+	if errors.As(err, &policyErr) {
+		m.logs.Warnw(
+			"password rejected by policy",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, policyErr.Error(), http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (weak password)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err != nil {
+		m.logs.Errorw(
+			"accept invitation failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if errors.Is(err, core.ErrInvalidInvitation) {
+			msg = "invalid or expired invitation"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (accept invitation)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	m.logs.Infow(
+		"invitation accepted",
+		"request_id", requestID,
+	)
+	if err := common.WriteResponse(w, "invitation accepted", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (accept invitation success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+func errorResponse(err error) (string, int) {
+	var validationErr validator.ValidationErrors
+	switch {
+	case errors.Is(err, core.ErrUnknownTenant):
+		return "no organization selected", http.StatusBadRequest
+	case errors.Is(err, core.ErrInvitationNotFound):
+		return "invitation not found", http.StatusNotFound
+	case errors.Is(err, core.ErrAlreadyMember):
+		return "user is already a member", http.StatusConflict
+	case errors.Is(err, core.ErrUserExists):
+		return "user already exists", http.StatusConflict
+	case errors.Is(err, core.ErrOwnerRequired):
+		return "only owners can manage owners", http.StatusForbidden
+	case errors.As(err, &validationErr):
+		return "invalid request body", http.StatusBadRequest
+	}
+	return "internal server error", http.StatusInternalServerError
+}
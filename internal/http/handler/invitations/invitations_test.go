@@ -0,0 +1,116 @@
+package invitations_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/invitations"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type invitationsMock struct {
+	create func(actor model.OrgActor, dto model.CreateInvitationDTO) (model.InvitationDTO, error)
+	list   func(actor model.OrgActor) ([]model.InvitationDTO, error)
+	revoke func(actor model.OrgActor, id uint) error
+	resend func(actor model.OrgActor, id uint) error
+	accept func(dto model.AcceptInvitationDTO) error
+}
+
+func (m *invitationsMock) CreateInvitation(actor model.OrgActor, dto model.CreateInvitationDTO) (model.InvitationDTO, error) {
+	return m.create(actor, dto)
+}
+
+func (m *invitationsMock) ListInvitations(actor model.OrgActor) ([]model.InvitationDTO, error) {
+	return m.list(actor)
+}
+
+func (m *invitationsMock) RevokeInvitation(actor model.OrgActor, id uint) error {
+	return m.revoke(actor, id)
+}
+
+func (m *invitationsMock) ResendInvitation(actor model.OrgActor, id uint) error {
+	return m.resend(actor, id)
+}
+
+func (m *invitationsMock) AcceptInvitation(dto model.AcceptInvitationDTO) error {
+	return m.accept(dto)
+}
+
+func Test_Invitations_Create(t *testing.T) {
+	var got model.OrgActor
+	registry := &invitationsMock{
+		create: func(actor model.OrgActor, dto model.CreateInvitationDTO) (model.InvitationDTO, error) {
+			got = actor
+			return model.InvitationDTO{ID: 1, Email: dto.Email, Role: model.MemberRoleMember}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(invitations.NewInvitationsHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/org/invitations", strings.NewReader(`{"email": "new@test.com"}`))
+	ctx := context.WithValue(request.Context(), model.CurrentUser, map[string]any{"uid": float64(4), "org_role": "admin"})
+	ctx = context.WithValue(ctx, model.Tenant, uint(3))
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request.WithContext(ctx))
+
+	if http.StatusCreated != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusCreated, response.Code)
+	}
+	expected := model.OrgActor{TenantID: 3, UserID: 4, Role: model.MemberRoleAdmin}
+	if got != expected {
+		t.Fatalf("actor does not match, expected: %+v, got: %+v", expected, got)
+	}
+}
+
+func Test_Invitations_Revoke(t *testing.T) {
+	registry := &invitationsMock{
+		revoke: func(actor model.OrgActor, id uint) error {
+			if id != 2 {
+				return core.ErrInvitationNotFound
+			}
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(invitations.NewRevokeInvitationHandler(zap.NewNop().Sugar(), registry))
+
+	cases := map[string]int{
+		`{"id": 2}`: http.StatusOK,
+		`{"id": 9}`: http.StatusNotFound,
+		`{}`:        http.StatusBadRequest,
+	}
+	for body, expected := range cases {
+		request, _ := http.NewRequest(http.MethodPost, "/api/org/invitations/revoke", strings.NewReader(body))
+		response := httptest.NewRecorder()
+
+		handler.ServeHTTP(response, request)
+
+		if expected != response.Code {
+			t.Errorf("%s: response code does not match, expected: %d, got: %d", body, expected, response.Code)
+		}
+	}
+}
+
+func Test_Invitations_AcceptInvalid(t *testing.T) {
+	registry := &invitationsMock{
+		accept: func(dto model.AcceptInvitationDTO) error {
+			return fmt.Errorf("%w: already used", core.ErrInvalidInvitation)
+		},
+	}
+	handler := middleware.SetContextRequestID(invitations.NewAcceptInvitationHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/invitations/accept", strings.NewReader(`{"token": "used"}`))
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
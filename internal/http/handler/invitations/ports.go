@@ -0,0 +1,11 @@
+package invitations
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	CreateInvitation(actor model.OrgActor, dto model.CreateInvitationDTO) (model.InvitationDTO, error)
+	ListInvitations(actor model.OrgActor) ([]model.InvitationDTO, error)
+	RevokeInvitation(actor model.OrgActor, id uint) error
+	ResendInvitation(actor model.OrgActor, id uint) error
+	AcceptInvitation(dto model.AcceptInvitationDTO) error
+}
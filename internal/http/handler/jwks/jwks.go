@@ -0,0 +1,65 @@
+package jwks
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type jwksHandler struct {
+	logs *zap.SugaredLogger
+	keys KeySet
+}
+
+func NewJwksHandler(logger *zap.SugaredLogger, keys KeySet) *jwksHandler {
+	return &jwksHandler{
+		logs: logger,
+		keys: keys,
+	}
+}
+
+func (m *jwksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	set, err := m.keys.JWKS()
+	if err != nil {
+		m.logs.Errorw(
+			"failed building key set",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "something went wrong on our end", http.StatusInternalServerError); err != nil {
+			m.logs.Errorw(
+				"write response failed (jwks)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := common.WriteJSON(w, set, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (jwks success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
@@ -0,0 +1,66 @@
+package jwks_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/jwks"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"go.uber.org/zap"
+)
+
+type keySetMock struct {
+	jwks func() (jwt.JSONWebKeySet, error)
+}
+
+func (k *keySetMock) JWKS() (jwt.JSONWebKeySet, error) {
+	return k.jwks()
+}
+
+func Test_ServeHTTP_Success(t *testing.T) {
+	expected := jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{{Kty: "OKP", Kid: "key-1", Crv: "Ed25519", X: "abc"}}}
+	keys := &keySetMock{
+		jwks: func() (jwt.JSONWebKeySet, error) {
+			return expected, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(jwks.NewJwksHandler(zap.NewNop().Sugar(), keys))
+
+	request, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var got jwt.JSONWebKeySet
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if len(got.Keys) != 1 || got.Keys[0] != expected.Keys[0] {
+		t.Fatalf("key set does not match, expected: %+v, got: %+v", expected, got)
+	}
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_ServeHTTP_Failed(t *testing.T) {
+	keys := &keySetMock{
+		jwks: func() (jwt.JSONWebKeySet, error) {
+			return jwt.JSONWebKeySet{}, errors.New("mock error")
+		},
+	}
+	handler := middleware.SetContextRequestID(jwks.NewJwksHandler(zap.NewNop().Sugar(), keys))
+
+	request, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusInternalServerError != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusInternalServerError, response.Code)
+	}
+}
@@ -0,0 +1,7 @@
+package jwks
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+
+type KeySet interface {
+	JWKS() (jwt.JSONWebKeySet, error)
+}
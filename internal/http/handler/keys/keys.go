@@ -0,0 +1,133 @@
+package keys
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type keysHandler struct {
+	logs *zap.SugaredLogger
+	ring KeyRing
+}
+
+// NewKeysHandler lists the signing keys on GET and
+// promotes or retires a key on POST
+func NewKeysHandler(logger *zap.SugaredLogger, ring KeyRing) *keysHandler {
+	return &keysHandler{
+		logs: logger,
+		ring: ring,
+	}
+}
+
+func (m *keysHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		if err := common.WriteJSON(w, m.ring.Keys(), http.StatusOK); err != nil {
+			m.logs.Errorw(
+				"write response failed (list keys)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	case http.MethodPost:
+	default:
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.KeyRotationDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil || dto.KeyID == "" {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	var err error
+	switch dto.Action {
+	case "promote":
+		err = m.ring.Promote(dto.KeyID)
+	case "retire":
+		retireAt := time.Now()
+		if dto.RetireAt != nil {
+			retireAt = *dto.RetireAt
+		}
+		err = m.ring.Retire(dto.KeyID, retireAt)
+	default:
+		err = fmt.Errorf("unknown action %q", dto.Action)
+	}
+	if err != nil {
+		m.logs.Errorw(
+			"key rotation failed",
+			"error", err,
+			"action", dto.Action,
+			"kid", dto.KeyID,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, jwt.ErrUnknownKey):
+			msg = fmt.Sprintf("unknown key %s", dto.KeyID)
+			status = http.StatusNotFound
+		case errors.Is(err, jwt.ErrActiveKey):
+			msg = "the active key can not be retired"
+			status = http.StatusConflict
+		case dto.Action != "promote" && dto.Action != "retire":
+			msg = fmt.Sprintf("unknown action %q", dto.Action)
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (key rotation)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	m.logs.Infow(
+		"signing keys updated",
+		"action", dto.Action,
+		"kid", dto.KeyID,
+		"request_id", requestID,
+	)
+
+	if err := common.WriteJSON(w, m.ring.Keys(), http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (key rotation success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
@@ -0,0 +1,99 @@
+package keys_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/keys"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"go.uber.org/zap"
+)
+
+type keyRingMock struct {
+	keys    func() []jwt.KeyStatus
+	promote func(kid string) error
+	retire  func(kid string, at time.Time) error
+}
+
+func (k *keyRingMock) Keys() []jwt.KeyStatus {
+	return k.keys()
+}
+func (k *keyRingMock) Promote(kid string) error {
+	return k.promote(kid)
+}
+func (k *keyRingMock) Retire(kid string, at time.Time) error {
+	return k.retire(kid, at)
+}
+
+func Test_ServeHTTP_List(t *testing.T) {
+	ring := &keyRingMock{
+		keys: func() []jwt.KeyStatus {
+			return []jwt.KeyStatus{{ID: "key-1", Algorithm: "EdDSA", Active: true}}
+		},
+	}
+	handler := middleware.SetContextRequestID(keys.NewKeysHandler(zap.NewNop().Sugar(), ring))
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/admin/keys", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var got []jwt.KeyStatus
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if len(got) != 1 || got[0].ID != "key-1" || !got[0].Active {
+		t.Fatalf("unexpected keys: %+v", got)
+	}
+}
+
+func Test_ServeHTTP_Promote(t *testing.T) {
+	var promoted string
+	ring := &keyRingMock{
+		keys: func() []jwt.KeyStatus {
+			return nil
+		},
+		promote: func(kid string) error {
+			promoted = kid
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(keys.NewKeysHandler(zap.NewNop().Sugar(), ring))
+
+	body := strings.NewReader(`{"action": "promote", "kid": "key-2"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/keys", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if promoted != "key-2" {
+		t.Fatalf("unexpected key promoted, expected: %s, got: %s", "key-2", promoted)
+	}
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_ServeHTTP_RetireActive(t *testing.T) {
+	ring := &keyRingMock{
+		retire: func(kid string, at time.Time) error {
+			return jwt.ErrActiveKey
+		},
+	}
+	handler := middleware.SetContextRequestID(keys.NewKeysHandler(zap.NewNop().Sugar(), ring))
+
+	body := strings.NewReader(`{"action": "retire", "kid": "key-1"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/keys", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusConflict != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusConflict, response.Code)
+	}
+}
@@ -0,0 +1,13 @@
+package keys
+
+import (
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+)
+
+type KeyRing interface {
+	Keys() []jwt.KeyStatus
+	Promote(kid string) error
+	Retire(kid string, at time.Time) error
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/dgdraganov/fuzzy-user-api/internal/core"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
@@ -59,7 +58,26 @@ func (m *loginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := m.registry.LoginUser(dto)
+	dto.ClientIP = common.ClientIP(r)
+	dto.TenantID = common.CurrentTenant(r)
+	tokens, err := m.registry.LoginUser(dto)
+	var challenge *core.MFARequiredError
+	if errors.As(err, &challenge) {
+		resp := model.MFAChallenge{Message: "second factor required", MFAToken: challenge.Token}
+		if err := common.WriteJSON(w, resp, http.StatusOK); err != nil {
+			m.logs.Errorw(
+				"write response failed (mfa challenge)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		m.logs.Infow(
+			"second factor required",
+			"email", dto.Email,
+			"request_id", requestID,
+		)
+		return
+	}
 	if err != nil {
 		m.logs.Errorw(
 			"login user failed",
@@ -68,9 +86,31 @@ func (m *loginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		)
 		msg := "internal server error"
 		status := http.StatusInternalServerError
+		var throttled *core.ThrottledError
+		if errors.As(err, &throttled) {
+			msg = "too many login attempts, try again later"
+			status = http.StatusTooManyRequests
+			common.SetRetryAfter(w, throttled.RetryAfter)
+		}
 		if errors.Is(err, core.ErrInvalidPassword) {
 			msg = "incorrect password"
-			status = http.StatusOK
+			status = http.StatusUnauthorized
+		}
+		if errors.Is(err, core.ErrUserNotFound) {
+			msg = "user not found"
+			status = http.StatusNotFound
+		}
+		if errors.Is(err, core.ErrEmailNotVerified) {
+			msg = "email address not verified"
+			status = http.StatusForbidden
+		}
+		if errors.Is(err, core.ErrNotMember) {
+			msg = "not a member of the organization"
+			status = http.StatusForbidden
+		}
+		if errors.Is(err, core.ErrAccountDisabled) {
+			msg = "account disabled"
+			status = http.StatusForbidden
 		}
 		if err := common.WriteResponse(w, msg, status); err != nil {
 			m.logs.Errorw(
@@ -82,15 +122,7 @@ func (m *loginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie := http.Cookie{}
-	cookie.Name = "Authentication"
-	cookie.Value = token
-	cookie.Expires = time.Now().Add(60 * time.Hour)
-	cookie.Secure = false
-	cookie.HttpOnly = true
-	cookie.Path = "/"
-
-	http.SetCookie(w, &cookie)
+	common.SetAuthCookies(w, tokens)
 
 	if err := common.WriteResponse(w, "login successful", http.StatusOK); err != nil {
 		m.logs.Errorw(
@@ -103,7 +135,7 @@ func (m *loginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	m.logs.Infow(
 		"successfully loged in",
-		"jwt", token,
+		"jwt", tokens.AccessToken,
 		"email", dto.Email,
 		"request_id", requestID,
 	)
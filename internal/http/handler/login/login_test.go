@@ -9,7 +9,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/login"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
@@ -17,18 +19,49 @@ import (
 )
 
 type loginMock struct {
-	loginUser func(model.LoginDTO) (string, error)
+	loginUser func(model.LoginDTO) (model.AuthTokens, error)
+	loginMFA  func(model.MFALoginDTO) (model.AuthTokens, error)
+	begin     func() (model.PasskeyRequestOptions, error)
+	finish    func(model.PasskeyLoginDTO) (model.AuthTokens, error)
+	sendMagic func(model.MagicLinkDTO) error
+	magic     func(token string) (model.AuthTokens, error)
 }
 
-func (r *loginMock) LoginUser(dto model.LoginDTO) (string, error) {
+func (r *loginMock) LoginUser(dto model.LoginDTO) (model.AuthTokens, error) {
 	return r.loginUser(dto)
 }
 
+func (r *loginMock) LoginMFA(dto model.MFALoginDTO) (model.AuthTokens, error) {
+	return r.loginMFA(dto)
+}
+
+func (r *loginMock) BeginPasskeyLogin() (model.PasskeyRequestOptions, error) {
+	return r.begin()
+}
+
+func (r *loginMock) FinishPasskeyLogin(dto model.PasskeyLoginDTO) (model.AuthTokens, error) {
+	return r.finish(dto)
+}
+
+func (r *loginMock) SendMagicLink(dto model.MagicLinkDTO) error {
+	return r.sendMagic(dto)
+}
+
+func (r *loginMock) LoginMagicLink(token string) (model.AuthTokens, error) {
+	return r.magic(token)
+}
+
 func Test_ServeHTTP_Success(t *testing.T) {
 	expectedToken := "fake_jwt_token"
+	expectedRefresh := "fake_refresh_token"
 	registry := &loginMock{
-		loginUser: func(rd model.LoginDTO) (string, error) {
-			return expectedToken, nil
+		loginUser: func(rd model.LoginDTO) (model.AuthTokens, error) {
+			return model.AuthTokens{
+				AccessToken:      expectedToken,
+				AccessExpiresAt:  time.Now().Add(time.Hour),
+				RefreshToken:     expectedRefresh,
+				RefreshExpiresAt: time.Now().Add(24 * time.Hour),
+			}, nil
 		},
 	}
 	reg := login.NewLoginHandler(zap.NewNop().Sugar(), registry)
@@ -50,11 +83,13 @@ func Test_ServeHTTP_Success(t *testing.T) {
 	loginHandler.ServeHTTP(response, request)
 
 	cookies := response.Result().Cookies()
-	var authCookie string
+	var authCookie, refreshCookie string
 	for _, cookie := range cookies {
-		if cookie.Name == "Authentication" {
+		switch cookie.Name {
+		case "Authentication":
 			authCookie = cookie.Value
-			break
+		case "Refresh":
+			refreshCookie = cookie.Value
 		}
 	}
 
@@ -74,6 +109,9 @@ func Test_ServeHTTP_Success(t *testing.T) {
 	if authCookie != expectedToken {
 		t.Fatalf("cookie does not match, expected: %s, got: %s", expectedToken, authCookie)
 	}
+	if refreshCookie != expectedRefresh {
+		t.Fatalf("refresh cookie does not match, expected: %s, got: %s", expectedRefresh, refreshCookie)
+	}
 	if string(expectedBody) != string(got) {
 		t.Fatalf("response does not match, expected: %s, got: %s", expectedBody, got)
 	}
@@ -144,3 +182,72 @@ func Test_ServeHTTP_InvalidBody(t *testing.T) {
 		t.Fatalf("response code does not match, expected: %d, got: %d", expectedCode, response.Code)
 	}
 }
+
+func Test_ServeHTTP_Throttled(t *testing.T) {
+	var clientIP string
+	registry := &loginMock{
+		loginUser: func(dto model.LoginDTO) (model.AuthTokens, error) {
+			clientIP = dto.ClientIP
+			return model.AuthTokens{}, fmt.Errorf("check throttle: %w", &core.ThrottledError{RetryAfter: 1500 * time.Millisecond})
+		},
+	}
+	reg := login.NewLoginHandler(zap.NewNop().Sugar(), registry)
+	loginHandler := middleware.SetContextRequestID(reg)
+
+	body := strings.NewReader(`{"email": "test@gmail.com", "password": "testPass"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/login", body)
+	request.RemoteAddr = "192.0.2.7:51234"
+	response := httptest.NewRecorder()
+
+	loginHandler.ServeHTTP(response, request)
+
+	if response.Code != http.StatusTooManyRequests {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusTooManyRequests, response.Code)
+	}
+	if got := response.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("retry after does not match, expected: 2, got: %s", got)
+	}
+	if clientIP != "192.0.2.7" {
+		t.Fatalf("client ip does not match, expected: 192.0.2.7, got: %s", clientIP)
+	}
+}
+
+func Test_ServeHTTP_IncorrectPassword(t *testing.T) {
+	registry := &loginMock{
+		loginUser: func(dto model.LoginDTO) (model.AuthTokens, error) {
+			return model.AuthTokens{}, fmt.Errorf("prepare token info: %w", core.ErrInvalidPassword)
+		},
+	}
+	reg := login.NewLoginHandler(zap.NewNop().Sugar(), registry)
+	loginHandler := middleware.SetContextRequestID(reg)
+
+	body := strings.NewReader(`{"email": "test@gmail.com", "password": "wrong"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/login", body)
+	response := httptest.NewRecorder()
+
+	loginHandler.ServeHTTP(response, request)
+
+	if response.Code != http.StatusUnauthorized {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusUnauthorized, response.Code)
+	}
+}
+
+func Test_ServeHTTP_UnknownUser(t *testing.T) {
+	registry := &loginMock{
+		loginUser: func(dto model.LoginDTO) (model.AuthTokens, error) {
+			return model.AuthTokens{}, fmt.Errorf("get password hash: %w", core.ErrUserNotFound)
+		},
+	}
+	reg := login.NewLoginHandler(zap.NewNop().Sugar(), registry)
+	loginHandler := middleware.SetContextRequestID(reg)
+
+	body := strings.NewReader(`{"email": "unknown@gmail.com", "password": "testPass"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/login", body)
+	response := httptest.NewRecorder()
+
+	loginHandler.ServeHTTP(response, request)
+
+	if response.Code != http.StatusNotFound {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusNotFound, response.Code)
+	}
+}
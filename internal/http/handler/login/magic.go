@@ -0,0 +1,197 @@
+package login
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+const magicLinkMessage = "if an account with this email exists, a sign-in link has been sent"
+
+type magicLinkHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewMagicLinkHandler emails a sign-in link. The reply is the same whether
+// or not the account exists.
+func NewMagicLinkHandler(logger *zap.SugaredLogger, reg Registry) *magicLinkHandler {
+	return &magicLinkHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *magicLinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.MagicLinkDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil || dto.Email == "" {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	// failures are only logged, the reply must not differ for known emails
+	dto.TenantID = common.CurrentTenant(r)
+	if err := m.registry.SendMagicLink(dto); err != nil {
+		m.logs.Errorw(
+			"send magic link failed",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+
+	if err := common.WriteResponse(w, magicLinkMessage, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (magic link)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+type magicLinkCallbackHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewMagicLinkCallbackHandler logs the user in with the token of an emailed
+// sign-in link, given either in the "token" query parameter or in a JSON
+// body, and sets the same cookies as a password login
+func NewMagicLinkCallbackHandler(logger *zap.SugaredLogger, reg Registry) *magicLinkCallbackHandler {
+	return &magicLinkCallbackHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *magicLinkCallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto model.MagicLinkLoginDTO
+	switch r.Method {
+	case http.MethodGet:
+		dto.Token = r.URL.Query().Get("token")
+	case http.MethodPost:
+		defer r.Body.Close()
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			m.logs.Warnw(
+				"json decode failed",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+	default:
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if dto.Token == "" {
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (missing token)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	tokens, err := m.registry.LoginMagicLink(dto.Token)
+	var challenge *core.MFARequiredError
+	if errors.As(err, &challenge) {
+		resp := model.MFAChallenge{Message: "second factor required", MFAToken: challenge.Token}
+		if err := common.WriteJSON(w, resp, http.StatusOK); err != nil {
+			m.logs.Errorw(
+				"write response failed (mfa challenge)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err != nil {
+		m.logs.Warnw(
+			"magic link login failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "internal server error"
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrInvalidMagicLink) {
+			msg = "invalid or expired sign-in link"
+			status = http.StatusUnauthorized
+		}
+		if errors.Is(err, core.ErrNotMember) {
+			msg = "not a member of the organization"
+			status = http.StatusForbidden
+		}
+		if errors.Is(err, core.ErrAccountDisabled) {
+			msg = "account disabled"
+			status = http.StatusForbidden
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (magic link login)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	common.SetAuthCookies(w, tokens)
+
+	if err := common.WriteResponse(w, "login successful", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (magic link login success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"successfully logged in with magic link",
+		"request_id", requestID,
+	)
+}
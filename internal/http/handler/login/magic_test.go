@@ -0,0 +1,100 @@
+package login_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/login"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+func Test_MagicLink_SameResponse(t *testing.T) {
+	registry := &loginMock{
+		sendMagic: func(dto model.MagicLinkDTO) error {
+			if dto.Email == "broken@test.com" {
+				return errors.New("mailer unavailable")
+			}
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(login.NewMagicLinkHandler(zap.NewNop().Sugar(), registry))
+
+	var bodies []string
+	for _, email := range []string{"known@test.com", "broken@test.com"} {
+		body := strings.NewReader(fmt.Sprintf(`{"email": %q}`, email))
+		request, _ := http.NewRequest(http.MethodPost, "/api/login/magic", body)
+		response := httptest.NewRecorder()
+
+		handler.ServeHTTP(response, request)
+
+		if http.StatusOK != response.Code {
+			t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+		}
+		bodies = append(bodies, response.Body.String())
+	}
+	if bodies[0] != bodies[1] {
+		t.Fatalf("responses differ: %s, %s", bodies[0], bodies[1])
+	}
+}
+
+func Test_MagicLinkCallback(t *testing.T) {
+	registry := &loginMock{
+		magic: func(token string) (model.AuthTokens, error) {
+			if token != "valid" {
+				return model.AuthTokens{}, core.ErrInvalidMagicLink
+			}
+			return model.AuthTokens{AccessToken: "access", RefreshToken: "refresh"}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(login.NewMagicLinkCallbackHandler(zap.NewNop().Sugar(), registry))
+
+	cases := []struct {
+		name     string
+		request  func() *http.Request
+		expected int
+	}{
+		{"link", func() *http.Request {
+			r, _ := http.NewRequest(http.MethodGet, "/api/login/magic/callback?token=valid", nil)
+			return r
+		}, http.StatusOK},
+		{"json body", func() *http.Request {
+			r, _ := http.NewRequest(http.MethodPost, "/api/login/magic/callback", strings.NewReader(`{"token": "valid"}`))
+			return r
+		}, http.StatusOK},
+		{"used link", func() *http.Request {
+			r, _ := http.NewRequest(http.MethodGet, "/api/login/magic/callback?token=used", nil)
+			return r
+		}, http.StatusUnauthorized},
+		{"missing token", func() *http.Request {
+			r, _ := http.NewRequest(http.MethodGet, "/api/login/magic/callback", nil)
+			return r
+		}, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, c.request())
+
+			if c.expected != response.Code {
+				t.Fatalf("response code does not match, expected: %d, got: %d", c.expected, response.Code)
+			}
+			authenticated := false
+			for _, cookie := range response.Result().Cookies() {
+				if cookie.Name == "Authentication" && cookie.Value == "access" {
+					authenticated = true
+				}
+			}
+			if authenticated != (c.expected == http.StatusOK) {
+				t.Fatalf("unexpected session cookies: %v", response.Result().Cookies())
+			}
+		})
+	}
+}
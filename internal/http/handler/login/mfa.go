@@ -0,0 +1,122 @@
+package login
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type mfaLoginHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewMFALoginHandler finishes a login which returned an mfa_token by
+// exchanging it and a TOTP or recovery code for the session cookies
+func NewMFALoginHandler(logger *zap.SugaredLogger, reg Registry) *mfaLoginHandler {
+	return &mfaLoginHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *mfaLoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.MFALoginDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	dto.ClientIP = common.ClientIP(r)
+	tokens, err := m.registry.LoginMFA(dto)
+	if err != nil {
+		m.logs.Warnw(
+			"mfa login failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "internal server error"
+		status := http.StatusInternalServerError
+		var validationErr validator.ValidationErrors
+		var throttled *core.ThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			msg = "too many attempts, try again later"
+			status = http.StatusTooManyRequests
+			common.SetRetryAfter(w, throttled.RetryAfter)
+		case errors.Is(err, core.ErrInvalidMFAToken):
+			msg = "invalid or expired mfa token"
+			status = http.StatusUnauthorized
+		case errors.Is(err, core.ErrInvalidMFACode):
+			msg = "invalid code"
+			status = http.StatusUnauthorized
+		case errors.Is(err, core.ErrNotMember):
+			msg = "not a member of the organization"
+			status = http.StatusForbidden
+		case errors.Is(err, core.ErrAccountDisabled):
+			msg = "account disabled"
+			status = http.StatusForbidden
+		case errors.As(err, &validationErr):
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (mfa login)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	common.SetAuthCookies(w, tokens)
+
+	if err := common.WriteResponse(w, "login successful", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (mfa login success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"successfully logged in with second factor",
+		"request_id", requestID,
+	)
+}
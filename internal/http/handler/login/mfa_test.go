@@ -0,0 +1,91 @@
+package login_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/login"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+func Test_ServeHTTP_MFARequired(t *testing.T) {
+	registry := &loginMock{
+		loginUser: func(dto model.LoginDTO) (model.AuthTokens, error) {
+			return model.AuthTokens{}, &core.MFARequiredError{Token: "pending_token"}
+		},
+	}
+	handler := middleware.SetContextRequestID(login.NewLoginHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"email": "test@gmail.com", "password": "testPass"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/login", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	if cookies := response.Result().Cookies(); len(cookies) != 0 {
+		t.Fatalf("session cookies set before the second factor: %v", cookies)
+	}
+	var got model.MFAChallenge
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.MFAToken != "pending_token" {
+		t.Fatalf("mfa token does not match, expected: pending_token, got: %s", got.MFAToken)
+	}
+}
+
+func Test_MFALogin(t *testing.T) {
+	registry := &loginMock{
+		loginMFA: func(dto model.MFALoginDTO) (model.AuthTokens, error) {
+			if dto.MFAToken != "pending_token" {
+				return model.AuthTokens{}, core.ErrInvalidMFAToken
+			}
+			if dto.Code != "123456" {
+				return model.AuthTokens{}, core.ErrInvalidMFACode
+			}
+			return model.AuthTokens{AccessToken: "access", RefreshToken: "refresh"}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(login.NewMFALoginHandler(zap.NewNop().Sugar(), registry))
+
+	cases := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"success", `{"mfa_token": "pending_token", "code": "123456"}`, http.StatusOK},
+		{"wrong code", `{"mfa_token": "pending_token", "code": "654321"}`, http.StatusUnauthorized},
+		{"wrong token", `{"mfa_token": "other", "code": "123456"}`, http.StatusUnauthorized},
+		{"invalid body", `{ invalid json }`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodPost, "/api/login/mfa", strings.NewReader(c.body))
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, request)
+
+			if c.expected != response.Code {
+				t.Fatalf("response code does not match, expected: %d, got: %d", c.expected, response.Code)
+			}
+			authenticated := false
+			for _, cookie := range response.Result().Cookies() {
+				if cookie.Name == "Authentication" && cookie.Value == "access" {
+					authenticated = true
+				}
+			}
+			if authenticated != (c.expected == http.StatusOK) {
+				t.Fatalf("unexpected session cookies: %v", response.Result().Cookies())
+			}
+		})
+	}
+}
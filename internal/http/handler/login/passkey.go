@@ -0,0 +1,196 @@
+package login
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type passkeyOptionsHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewPasskeyOptionsHandler replies with the options the browser passes to
+// navigator.credentials.get() to log in with a passkey
+func NewPasskeyOptionsHandler(logger *zap.SugaredLogger, reg Registry) *passkeyOptionsHandler {
+	return &passkeyOptionsHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *passkeyOptionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	options, err := m.registry.BeginPasskeyLogin()
+	if err != nil {
+		m.logs.Warnw(
+			"begin passkey login failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "internal server error"
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrPasskeysDisabled) {
+			msg = "passkeys are not enabled"
+			status = http.StatusNotFound
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (passkey options)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteJSON(w, options, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (passkey options success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+type passkeyLoginHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewPasskeyLoginHandler logs the user in with the assertion of a passkey
+// instead of the password
+func NewPasskeyLoginHandler(logger *zap.SugaredLogger, reg Registry) *passkeyLoginHandler {
+	return &passkeyLoginHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *passkeyLoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.PasskeyLoginDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	tokens, err := m.registry.FinishPasskeyLogin(dto)
+	var challenge *core.MFARequiredError
+	if errors.As(err, &challenge) {
+		resp := model.MFAChallenge{Message: "second factor required", MFAToken: challenge.Token}
+		if err := common.WriteJSON(w, resp, http.StatusOK); err != nil {
+			m.logs.Errorw(
+				"write response failed (mfa challenge)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err != nil {
+		m.logs.Warnw(
+			"passkey login failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "internal server error"
+		status := http.StatusInternalServerError
+		var validationErr validator.ValidationErrors
+		switch {
+		case errors.Is(err, core.ErrPasskeysDisabled):
+			msg = "passkeys are not enabled"
+			status = http.StatusNotFound
+		case errors.Is(err, core.ErrInvalidPasskeySession):
+			msg = "invalid or expired passkey session"
+			status = http.StatusBadRequest
+		case errors.Is(err, core.ErrInvalidPasskey):
+			msg = "passkey verification failed"
+			status = http.StatusUnauthorized
+		case errors.Is(err, core.ErrEmailNotVerified):
+			msg = "email address not verified"
+			status = http.StatusForbidden
+		case errors.Is(err, core.ErrNotMember):
+			msg = "not a member of the organization"
+			status = http.StatusForbidden
+		case errors.Is(err, core.ErrAccountDisabled):
+			msg = "account disabled"
+			status = http.StatusForbidden
+		case errors.As(err, &validationErr):
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (passkey login)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	common.SetAuthCookies(w, tokens)
+
+	if err := common.WriteResponse(w, "login successful", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (passkey login success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"successfully logged in with passkey",
+		"request_id", requestID,
+	)
+}
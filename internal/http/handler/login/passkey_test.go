@@ -0,0 +1,92 @@
+package login_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/login"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn"
+	"go.uber.org/zap"
+)
+
+func Test_PasskeyOptions(t *testing.T) {
+	registry := &loginMock{
+		begin: func() (model.PasskeyRequestOptions, error) {
+			return model.PasskeyRequestOptions{
+				Session:   "session",
+				PublicKey: webauthn.RequestOptions{Challenge: []byte{1, 2, 3}, RPID: "fuzzy.local"},
+			}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(login.NewPasskeyOptionsHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/login/passkey/options", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	var got struct {
+		Session   string         `json:"session"`
+		PublicKey map[string]any `json:"publicKey"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.Session != "session" || got.PublicKey["challenge"] != "AQID" || got.PublicKey["rpId"] != "fuzzy.local" {
+		t.Fatalf("unexpected options: %+v", got)
+	}
+}
+
+func Test_PasskeyLogin(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected int
+		cookies  bool
+	}{
+		{"success", nil, http.StatusOK, true},
+		{"invalid passkey", fmt.Errorf("%w: invalid signature", core.ErrInvalidPasskey), http.StatusUnauthorized, false},
+		{"expired session", core.ErrInvalidPasskeySession, http.StatusBadRequest, false},
+		{"second factor", &core.MFARequiredError{Token: "pending"}, http.StatusOK, false},
+		{"disabled", core.ErrPasskeysDisabled, http.StatusNotFound, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			registry := &loginMock{
+				finish: func(dto model.PasskeyLoginDTO) (model.AuthTokens, error) {
+					if dto.Session != "session" || string(dto.Credential.RawID) != "cred" {
+						return model.AuthTokens{}, fmt.Errorf("unexpected request: %+v", dto)
+					}
+					if c.err != nil {
+						return model.AuthTokens{}, c.err
+					}
+					return model.AuthTokens{AccessToken: "access", RefreshToken: "refresh"}, nil
+				},
+			}
+			handler := middleware.SetContextRequestID(login.NewPasskeyLoginHandler(zap.NewNop().Sugar(), registry))
+
+			body := strings.NewReader(`{"session": "session", "credential": {"id": "Y3JlZA", "rawId": "Y3JlZA", "type": "public-key", "response": {}}}`)
+			request, _ := http.NewRequest(http.MethodPost, "/api/login/passkey", body)
+			response := httptest.NewRecorder()
+
+			handler.ServeHTTP(response, request)
+
+			if c.expected != response.Code {
+				t.Fatalf("response code does not match, expected: %d, got: %d", c.expected, response.Code)
+			}
+			if got := len(response.Result().Cookies()) > 0; got != c.cookies {
+				t.Fatalf("unexpected session cookies: %v", response.Result().Cookies())
+			}
+		})
+	}
+}
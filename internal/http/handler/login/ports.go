@@ -5,5 +5,10 @@ import (
 )
 
 type Registry interface {
-	LoginUser(dto model.LoginDTO) (string, error)
+	LoginUser(dto model.LoginDTO) (model.AuthTokens, error)
+	LoginMFA(dto model.MFALoginDTO) (model.AuthTokens, error)
+	BeginPasskeyLogin() (model.PasskeyRequestOptions, error)
+	FinishPasskeyLogin(dto model.PasskeyLoginDTO) (model.AuthTokens, error)
+	SendMagicLink(dto model.MagicLinkDTO) error
+	LoginMagicLink(token string) (model.AuthTokens, error)
 }
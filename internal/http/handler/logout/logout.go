@@ -0,0 +1,100 @@
+package logout
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type logoutHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewLogoutHandler(logger *zap.SugaredLogger, reg Registry) *logoutHandler {
+	return &logoutHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *logoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	token, ok := common.AuthToken(r)
+	if !ok {
+		m.logs.Warnw(
+			"logout without authentication token",
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "missing authentication token", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (missing token)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	dto := model.LogoutDTO{AccessToken: token}
+	if refresh, err := r.Cookie("Refresh"); err == nil {
+		dto.RefreshToken = refresh.Value
+	}
+
+	if err := m.registry.LogoutUser(dto); err != nil {
+		m.logs.Errorw(
+			"logout user failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		if errors.Is(err, jwt.ErrTokenNotValid) {
+			msg = "invalid authentication token"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (logout)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	common.ClearAuthCookies(w)
+
+	if err := common.WriteResponse(w, "logout successful", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (logout success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"successfully logged out",
+		"request_id", requestID,
+	)
+}
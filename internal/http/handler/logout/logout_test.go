@@ -0,0 +1,97 @@
+package logout_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/logout"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type logoutMock struct {
+	logoutUser func(dto model.LogoutDTO) error
+}
+
+func (l *logoutMock) LogoutUser(dto model.LogoutDTO) error {
+	return l.logoutUser(dto)
+}
+
+func Test_ServeHTTP_Success(t *testing.T) {
+	var got model.LogoutDTO
+	registry := &logoutMock{
+		logoutUser: func(dto model.LogoutDTO) error {
+			got = dto
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(logout.NewLogoutHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/logout", nil)
+	request.AddCookie(&http.Cookie{Name: "Authentication", Value: "fake_jwt_token"})
+	request.AddCookie(&http.Cookie{Name: "Refresh", Value: "fake_refresh_token"})
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if got.AccessToken != "fake_jwt_token" || got.RefreshToken != "fake_refresh_token" {
+		t.Fatalf("unexpected logout dto: %+v", got)
+	}
+	for _, cookie := range response.Result().Cookies() {
+		if cookie.MaxAge >= 0 {
+			t.Fatalf("cookie %s not cleared", cookie.Name)
+		}
+	}
+	expectedBody, err := json.Marshal(model.ResponseMessage{Message: "logout successful"})
+	if err != nil {
+		t.Fatal("failed to marshal ResponseMessage")
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal("failed to read response body")
+	}
+	if string(expectedBody) != string(body) {
+		t.Fatalf("response does not match, expected: %s, got: %s", expectedBody, body)
+	}
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_ServeHTTP_InvalidToken(t *testing.T) {
+	registry := &logoutMock{
+		logoutUser: func(dto model.LogoutDTO) error {
+			return fmt.Errorf("mock error: %w", jwt.ErrTokenNotValid)
+		},
+	}
+	handler := middleware.SetContextRequestID(logout.NewLogoutHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/logout", nil)
+	request.AddCookie(&http.Cookie{Name: "Authentication", Value: "expired_jwt_token"})
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
+
+func Test_ServeHTTP_MissingCookie(t *testing.T) {
+	handler := middleware.SetContextRequestID(logout.NewLogoutHandler(zap.NewNop().Sugar(), &logoutMock{}))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/logout", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
@@ -0,0 +1,7 @@
+package logout
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	LogoutUser(dto model.LogoutDTO) error
+}
@@ -0,0 +1,218 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type changeEmailHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewChangeEmailHandler starts an email change for the user authenticated
+// by the middleware.Authenticate wrapper
+func NewChangeEmailHandler(logger *zap.SugaredLogger, reg Registry) *changeEmailHandler {
+	return &changeEmailHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *changeEmailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := common.CurrentUserID(r)
+	if !ok {
+		if err := common.WriteResponse(w, "only users can change their email", http.StatusForbidden); err != nil {
+			m.logs.Errorw(
+				"write response failed (not a user)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	var dto model.ChangeEmailDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.RequestEmailChange(userID, dto); err != nil {
+		m.logs.Warnw(
+			"request email change failed",
+			"error", err,
+			"user_id", userID,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		var validationErr validator.ValidationErrors
+		switch {
+		case errors.Is(err, core.ErrInvalidPassword):
+			msg = "incorrect password"
+			status = http.StatusForbidden
+		case errors.Is(err, core.ErrEmailTaken):
+			msg = "email already in use"
+			status = http.StatusConflict
+		case errors.As(err, &validationErr):
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (change email)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteResponse(w, "confirmation sent to the new email address", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (change email success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"email change requested",
+		"user_id", userID,
+		"request_id", requestID,
+	)
+}
+
+type confirmEmailChangeHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewConfirmEmailChangeHandler accepts the confirmation token either in the
+// "token" query parameter of the emailed link or in a JSON body
+func NewConfirmEmailChangeHandler(logger *zap.SugaredLogger, reg Registry) *confirmEmailChangeHandler {
+	return &confirmEmailChangeHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *confirmEmailChangeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto model.VerifyEmailDTO
+	switch r.Method {
+	case http.MethodGet:
+		dto.Token = r.URL.Query().Get("token")
+	case http.MethodPost:
+		defer r.Body.Close()
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			m.logs.Warnw(
+				"json decode failed",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+	default:
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if dto.Token == "" {
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (missing token)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.ConfirmEmailChange(dto.Token); err != nil {
+		m.logs.Warnw(
+			"confirm email change failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, core.ErrInvalidEmailChangeToken):
+			msg = "invalid or expired confirmation token"
+			status = http.StatusBadRequest
+		case errors.Is(err, core.ErrEmailTaken):
+			msg = "email already in use"
+			status = http.StatusConflict
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (confirm email change)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	common.ClearAuthCookies(w)
+
+	if err := common.WriteResponse(w, "email changed successfully, please log in again", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (confirm email change success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"email changed",
+		"request_id", requestID,
+	)
+}
@@ -0,0 +1,273 @@
+package me_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/me"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type registryMock struct {
+	changePassword     func(userID uint, dto model.ChangePasswordDTO) (model.AuthTokens, error)
+	requestEmailChange func(userID uint, dto model.ChangeEmailDTO) error
+	confirmEmailChange func(token string) error
+	enrollTOTP         func(userID uint) (model.TOTPEnrollment, error)
+	confirmTOTP        func(userID uint, dto model.ConfirmTOTPDTO) (model.RecoveryCodes, error)
+	beginPasskey       func(userID uint) (model.PasskeyCreationOptions, error)
+	finishPasskey      func(userID uint, dto model.PasskeyRegistrationDTO) error
+}
+
+func (r *registryMock) ChangePassword(userID uint, dto model.ChangePasswordDTO) (model.AuthTokens, error) {
+	return r.changePassword(userID, dto)
+}
+func (r *registryMock) RequestEmailChange(userID uint, dto model.ChangeEmailDTO) error {
+	return r.requestEmailChange(userID, dto)
+}
+func (r *registryMock) ConfirmEmailChange(token string) error {
+	return r.confirmEmailChange(token)
+}
+func (r *registryMock) EnrollTOTP(userID uint) (model.TOTPEnrollment, error) {
+	return r.enrollTOTP(userID)
+}
+func (r *registryMock) ConfirmTOTP(userID uint, dto model.ConfirmTOTPDTO) (model.RecoveryCodes, error) {
+	return r.confirmTOTP(userID, dto)
+}
+func (r *registryMock) BeginPasskeyRegistration(userID uint) (model.PasskeyCreationOptions, error) {
+	return r.beginPasskey(userID)
+}
+func (r *registryMock) FinishPasskeyRegistration(userID uint, dto model.PasskeyRegistrationDTO) error {
+	return r.finishPasskey(userID, dto)
+}
+
+// withClaims stands in for middleware.Authenticate
+func withClaims(claims map[string]any, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), model.CurrentUser, claims)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func Test_ChangePassword_Success(t *testing.T) {
+	registry := &registryMock{
+		changePassword: func(userID uint, dto model.ChangePasswordDTO) (model.AuthTokens, error) {
+			if userID != 5 || dto.CurrentPassword != "old" || dto.NewPassword != "new" {
+				return model.AuthTokens{}, errors.New("unexpected request")
+			}
+			return model.AuthTokens{AccessToken: "new_access", RefreshToken: "new_refresh"}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(withClaims(
+		map[string]any{"uid": float64(5)},
+		me.NewChangePasswordHandler(zap.NewNop().Sugar(), registry),
+	))
+
+	body := strings.NewReader(`{"current_password": "old", "new_password": "new"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/me/password", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	cookies := map[string]string{}
+	for _, cookie := range response.Result().Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	if cookies["Authentication"] != "new_access" || cookies["Refresh"] != "new_refresh" {
+		t.Fatalf("session cookies not renewed: %v", cookies)
+	}
+}
+
+func Test_ChangePassword_WrongPassword(t *testing.T) {
+	registry := &registryMock{
+		changePassword: func(userID uint, dto model.ChangePasswordDTO) (model.AuthTokens, error) {
+			return model.AuthTokens{}, core.ErrInvalidPassword
+		},
+	}
+	handler := middleware.SetContextRequestID(withClaims(
+		map[string]any{"uid": float64(5)},
+		me.NewChangePasswordHandler(zap.NewNop().Sugar(), registry),
+	))
+
+	body := strings.NewReader(`{"current_password": "wrong", "new_password": "new"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/me/password", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusForbidden != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusForbidden, response.Code)
+	}
+}
+
+func Test_ChangeEmail_ClientToken(t *testing.T) {
+	handler := middleware.SetContextRequestID(withClaims(
+		map[string]any{"sub": "client", "principal": "client"},
+		me.NewChangeEmailHandler(zap.NewNop().Sugar(), &registryMock{}),
+	))
+
+	body := strings.NewReader(`{"new_email": "new@test.com", "password": "pass"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/me/email", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusForbidden != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusForbidden, response.Code)
+	}
+}
+
+func Test_ChangeEmail_Taken(t *testing.T) {
+	registry := &registryMock{
+		requestEmailChange: func(userID uint, dto model.ChangeEmailDTO) error {
+			return core.ErrEmailTaken
+		},
+	}
+	handler := middleware.SetContextRequestID(withClaims(
+		map[string]any{"uid": float64(5)},
+		me.NewChangeEmailHandler(zap.NewNop().Sugar(), registry),
+	))
+
+	body := strings.NewReader(`{"new_email": "taken@test.com", "password": "pass"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/me/email", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusConflict != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusConflict, response.Code)
+	}
+}
+
+func Test_ConfirmEmailChange(t *testing.T) {
+	registry := &registryMock{
+		confirmEmailChange: func(token string) error {
+			if token != "change_token" {
+				return core.ErrInvalidEmailChangeToken
+			}
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(me.NewConfirmEmailChangeHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/me/email/confirm?token=change_token", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_EnrollTOTP(t *testing.T) {
+	registry := &registryMock{
+		enrollTOTP: func(userID uint) (model.TOTPEnrollment, error) {
+			if userID != 5 {
+				return model.TOTPEnrollment{}, errors.New("unexpected user")
+			}
+			return model.TOTPEnrollment{Secret: "JBSWY3DPEHPK3PXP", URI: "otpauth://totp/Fuzzy:test?secret=JBSWY3DPEHPK3PXP"}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(withClaims(
+		map[string]any{"uid": float64(5)},
+		me.NewEnrollTOTPHandler(zap.NewNop().Sugar(), registry),
+	))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/me/mfa/totp", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	var got model.TOTPEnrollment
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.Secret != "JBSWY3DPEHPK3PXP" || got.URI == "" {
+		t.Fatalf("unexpected enrollment: %+v", got)
+	}
+}
+
+func Test_ConfirmTOTP(t *testing.T) {
+	registry := &registryMock{
+		confirmTOTP: func(userID uint, dto model.ConfirmTOTPDTO) (model.RecoveryCodes, error) {
+			if dto.Code != "123456" {
+				return model.RecoveryCodes{}, core.ErrInvalidMFACode
+			}
+			return model.RecoveryCodes{Codes: []string{"abcde-fghjk"}}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(withClaims(
+		map[string]any{"uid": float64(5)},
+		me.NewConfirmTOTPHandler(zap.NewNop().Sugar(), registry),
+	))
+
+	cases := []struct {
+		code     string
+		expected int
+	}{
+		{"123456", http.StatusOK},
+		{"000000", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		body := strings.NewReader(`{"code": "` + c.code + `"}`)
+		request, _ := http.NewRequest(http.MethodPost, "/api/me/mfa/totp/confirm", body)
+		response := httptest.NewRecorder()
+
+		handler.ServeHTTP(response, request)
+
+		if c.expected != response.Code {
+			t.Fatalf("response code does not match, expected: %d, got: %d", c.expected, response.Code)
+		}
+	}
+}
+
+func Test_RegisterPasskey(t *testing.T) {
+	registry := &registryMock{
+		finishPasskey: func(userID uint, dto model.PasskeyRegistrationDTO) error {
+			if userID != 5 || dto.Name != "laptop" {
+				return errors.New("unexpected request")
+			}
+			if dto.Session != "session" {
+				return core.ErrInvalidPasskeySession
+			}
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(withClaims(
+		map[string]any{"uid": float64(5)},
+		me.NewRegisterPasskeyHandler(zap.NewNop().Sugar(), registry),
+	))
+
+	cases := []struct {
+		session  string
+		expected int
+	}{
+		{"session", http.StatusOK},
+		{"expired", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		body := strings.NewReader(`{"session": "` + c.session + `", "name": "laptop", "credential": {"type": "public-key"}}`)
+		request, _ := http.NewRequest(http.MethodPost, "/api/me/passkeys", body)
+		response := httptest.NewRecorder()
+
+		handler.ServeHTTP(response, request)
+
+		if c.expected != response.Code {
+			t.Fatalf("response code does not match, expected: %d, got: %d", c.expected, response.Code)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type passkeyOptionsHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewPasskeyOptionsHandler replies with the options the browser passes to
+// navigator.credentials.create() to add a passkey to the authenticated user
+func NewPasskeyOptionsHandler(logger *zap.SugaredLogger, reg Registry) *passkeyOptionsHandler {
+	return &passkeyOptionsHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *passkeyOptionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	userID, ok := common.CurrentUserID(r)
+	if !ok {
+		if err := common.WriteResponse(w, "only users can register passkeys", http.StatusForbidden); err != nil {
+			m.logs.Errorw(
+				"write response failed (not a user)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	options, err := m.registry.BeginPasskeyRegistration(userID)
+	if err != nil {
+		m.logs.Warnw(
+			"begin passkey registration failed",
+			"error", err,
+			"user_id", userID,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrPasskeysDisabled) {
+			msg = "passkeys are not enabled"
+			status = http.StatusNotFound
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (passkey options)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteJSON(w, options, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (passkey options success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+type registerPasskeyHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewRegisterPasskeyHandler stores the passkey created by the browser for
+// the authenticated user
+func NewRegisterPasskeyHandler(logger *zap.SugaredLogger, reg Registry) *registerPasskeyHandler {
+	return &registerPasskeyHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *registerPasskeyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := common.CurrentUserID(r)
+	if !ok {
+		if err := common.WriteResponse(w, "only users can register passkeys", http.StatusForbidden); err != nil {
+			m.logs.Errorw(
+				"write response failed (not a user)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	var dto model.PasskeyRegistrationDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.FinishPasskeyRegistration(userID, dto); err != nil {
+		m.logs.Warnw(
+			"finish passkey registration failed",
+			"error", err,
+			"user_id", userID,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		var validationErr validator.ValidationErrors
+		switch {
+		case errors.Is(err, core.ErrPasskeysDisabled):
+			msg = "passkeys are not enabled"
+			status = http.StatusNotFound
+		case errors.Is(err, core.ErrInvalidPasskeySession):
+			msg = "invalid or expired passkey session"
+			status = http.StatusBadRequest
+		case errors.Is(err, core.ErrInvalidPasskey):
+			msg = "passkey verification failed"
+			status = http.StatusBadRequest
+		case errors.As(err, &validationErr):
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (register passkey)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteResponse(w, "passkey registered successfully", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (register passkey success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"passkey registered",
+		"user_id", userID,
+		"request_id", requestID,
+	)
+}
@@ -0,0 +1,125 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type changePasswordHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewChangePasswordHandler changes the password of the user authenticated
+// by the middleware.Authenticate wrapper and renews the caller's cookies
+func NewChangePasswordHandler(logger *zap.SugaredLogger, reg Registry) *changePasswordHandler {
+	return &changePasswordHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *changePasswordHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := common.CurrentUserID(r)
+	if !ok {
+		if err := common.WriteResponse(w, "only users can change their password", http.StatusForbidden); err != nil {
+			m.logs.Errorw(
+				"write response failed (not a user)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	var dto model.ChangePasswordDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	tokens, err := m.registry.ChangePassword(userID, dto)
+	if err != nil {
+		m.logs.Warnw(
+			"change password failed",
+			"error", err,
+			"user_id", userID,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		var validationErr validator.ValidationErrors
+		var policyErr *core.PolicyError
+		switch {
+		case errors.Is(err, core.ErrInvalidPassword):
+			msg = "incorrect password"
+			status = http.StatusForbidden
+		case errors.As(err, &policyErr):
+			msg = policyErr.Error()
+			status = http.StatusBadRequest
+		case errors.As(err, &validationErr):
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (change password)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	common.SetAuthCookies(w, tokens)
+
+	if err := common.WriteResponse(w, "password changed successfully", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (change password success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"password changed",
+		"user_id", userID,
+		"request_id", requestID,
+	)
+}
@@ -0,0 +1,13 @@
+package me
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	ChangePassword(userID uint, dto model.ChangePasswordDTO) (model.AuthTokens, error)
+	RequestEmailChange(userID uint, dto model.ChangeEmailDTO) error
+	ConfirmEmailChange(token string) error
+	EnrollTOTP(userID uint) (model.TOTPEnrollment, error)
+	ConfirmTOTP(userID uint, dto model.ConfirmTOTPDTO) (model.RecoveryCodes, error)
+	BeginPasskeyRegistration(userID uint) (model.PasskeyCreationOptions, error)
+	FinishPasskeyRegistration(userID uint, dto model.PasskeyRegistrationDTO) error
+}
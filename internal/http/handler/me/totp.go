@@ -0,0 +1,208 @@
+package me
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type enrollTOTPHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewEnrollTOTPHandler starts a TOTP enrollment for the authenticated user
+// and replies with the secret and the otpauth URI to show as a QR code
+func NewEnrollTOTPHandler(logger *zap.SugaredLogger, reg Registry) *enrollTOTPHandler {
+	return &enrollTOTPHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *enrollTOTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	userID, ok := common.CurrentUserID(r)
+	if !ok {
+		if err := common.WriteResponse(w, "only users can enroll in two-factor authentication", http.StatusForbidden); err != nil {
+			m.logs.Errorw(
+				"write response failed (not a user)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	enrollment, err := m.registry.EnrollTOTP(userID)
+	if err != nil {
+		m.logs.Warnw(
+			"enroll totp failed",
+			"error", err,
+			"user_id", userID,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrTOTPAlreadyEnabled) {
+			msg = "two-factor authentication is already enabled"
+			status = http.StatusConflict
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (enroll totp)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteJSON(w, enrollment, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (enroll totp success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"totp enrollment started",
+		"user_id", userID,
+		"request_id", requestID,
+	)
+}
+
+type confirmTOTPHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewConfirmTOTPHandler enables two-factor authentication once the user
+// posts a first code from the authenticator and replies with the
+// recovery codes
+func NewConfirmTOTPHandler(logger *zap.SugaredLogger, reg Registry) *confirmTOTPHandler {
+	return &confirmTOTPHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *confirmTOTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := common.CurrentUserID(r)
+	if !ok {
+		if err := common.WriteResponse(w, "only users can enroll in two-factor authentication", http.StatusForbidden); err != nil {
+			m.logs.Errorw(
+				"write response failed (not a user)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	var dto model.ConfirmTOTPDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	codes, err := m.registry.ConfirmTOTP(userID, dto)
+	if err != nil {
+		m.logs.Warnw(
+			"confirm totp failed",
+			"error", err,
+			"user_id", userID,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		var validationErr validator.ValidationErrors
+		switch {
+		case errors.Is(err, core.ErrInvalidMFACode):
+			msg = "invalid code"
+			status = http.StatusBadRequest
+		case errors.Is(err, core.ErrTOTPNotEnrolled):
+			msg = "two-factor enrollment has not been started"
+			status = http.StatusConflict
+		case errors.Is(err, core.ErrTOTPAlreadyEnabled):
+			msg = "two-factor authentication is already enabled"
+			status = http.StatusConflict
+		case errors.As(err, &validationErr):
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (confirm totp)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteJSON(w, codes, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (confirm totp success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"totp enabled",
+		"user_id", userID,
+		"request_id", requestID,
+	)
+}
@@ -0,0 +1,155 @@
+package members
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type membersHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewMembersHandler lists the members of the organization on GET, changes
+// the role of one on PATCH and removes one on DELETE
+func NewMembersHandler(logger *zap.SugaredLogger, reg Registry) *membersHandler {
+	return &membersHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *membersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		m.list(w, r, requestID)
+		return
+	case http.MethodPatch, http.MethodDelete:
+	default:
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.UpdateMemberDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	actor := common.OrgActor(r)
+	var err error
+	msg := "member updated"
+	if r.Method == http.MethodPatch {
+		err = m.registry.UpdateMember(actor, dto)
+	} else {
+		err = m.registry.RemoveMember(actor, dto.UserID)
+		msg = "member removed"
+	}
+	if err != nil {
+		m.logs.Errorw(
+			"change member failed",
+			"error", err,
+			"user_id", dto.UserID,
+			"tenant", actor.TenantID,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (change member)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	m.logs.Infow(
+		msg,
+		"user_id", dto.UserID,
+		"role", dto.Role,
+		"tenant", actor.TenantID,
+		"request_id", requestID,
+	)
+	if err := common.WriteResponse(w, msg, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (change member success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+func (m *membersHandler) list(w http.ResponseWriter, r *http.Request, requestID string) {
+	members, err := m.registry.ListMembers(common.OrgActor(r))
+	if err != nil {
+		m.logs.Errorw(
+			"list members failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (list members)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err := common.WriteJSON(w, members, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (list members success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+func errorResponse(err error) (string, int) {
+	var validationErr validator.ValidationErrors
+	switch {
+	case errors.Is(err, core.ErrUnknownTenant):
+		return "no organization selected", http.StatusBadRequest
+	case errors.Is(err, core.ErrUserNotFound):
+		return "member not found", http.StatusNotFound
+	case errors.Is(err, core.ErrOwnerRequired):
+		return "only owners can manage owners", http.StatusForbidden
+	case errors.Is(err, core.ErrLastOwner):
+		return "the organization needs another owner first", http.StatusConflict
+	case errors.As(err, &validationErr):
+		return "invalid request body", http.StatusBadRequest
+	}
+	return "internal server error", http.StatusInternalServerError
+}
@@ -0,0 +1,62 @@
+package members_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/members"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type membersMock struct {
+	list   func(actor model.OrgActor) ([]model.MemberDTO, error)
+	update func(actor model.OrgActor, dto model.UpdateMemberDTO) error
+	remove func(actor model.OrgActor, userID uint) error
+}
+
+func (m *membersMock) ListMembers(actor model.OrgActor) ([]model.MemberDTO, error) {
+	return m.list(actor)
+}
+
+func (m *membersMock) UpdateMember(actor model.OrgActor, dto model.UpdateMemberDTO) error {
+	return m.update(actor, dto)
+}
+
+func (m *membersMock) RemoveMember(actor model.OrgActor, userID uint) error {
+	return m.remove(actor, userID)
+}
+
+func Test_Members(t *testing.T) {
+	registry := &membersMock{
+		update: func(actor model.OrgActor, dto model.UpdateMemberDTO) error {
+			return nil
+		},
+		remove: func(actor model.OrgActor, userID uint) error {
+			return fmt.Errorf("remove member: %w", core.ErrLastOwner)
+		},
+	}
+	handler := middleware.SetContextRequestID(members.NewMembersHandler(zap.NewNop().Sugar(), registry))
+
+	cases := map[string]int{
+		http.MethodPatch:  http.StatusOK,
+		http.MethodDelete: http.StatusConflict,
+		http.MethodPost:   http.StatusMethodNotAllowed,
+	}
+	for method, expected := range cases {
+		body := strings.NewReader(`{"user_id": 3, "role": "admin"}`)
+		request, _ := http.NewRequest(method, "/api/org/members", body)
+		response := httptest.NewRecorder()
+
+		handler.ServeHTTP(response, request)
+
+		if expected != response.Code {
+			t.Errorf("%s: response code does not match, expected: %d, got: %d", method, expected, response.Code)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package members
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	ListMembers(actor model.OrgActor) ([]model.MemberDTO, error)
+	UpdateMember(actor model.OrgActor, dto model.UpdateMemberDTO) error
+	RemoveMember(actor model.OrgActor, userID uint) error
+}
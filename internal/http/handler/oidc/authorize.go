@@ -0,0 +1,122 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type authorizeHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewAuthorizeHandler serves the authorization endpoint for the user
+// logged in with the "Authentication" cookie or a bearer token
+func NewAuthorizeHandler(logger *zap.SugaredLogger, reg Registry) *authorizeHandler {
+	return &authorizeHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *authorizeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	query := r.URL.Query()
+	req := model.AuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		Nonce:               query.Get("nonce"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	accessToken, _ := common.AuthToken(r)
+
+	code, err := m.registry.Authorize(req, accessToken)
+	if errors.Is(err, core.ErrInvalidClient) {
+		m.logs.Warnw(
+			"authorize with invalid client",
+			"client_id", req.ClientID,
+			"redirect_uri", req.RedirectURI,
+			"request_id", requestID,
+		)
+		if err := common.WriteOAuthError(w, core.ErrInvalidClient.Code, core.ErrInvalidClient.Description, http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (invalid client)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	params := url.Values{}
+	if err != nil {
+		m.logs.Warnw(
+			"authorize failed",
+			"error", err,
+			"client_id", req.ClientID,
+			"request_id", requestID,
+		)
+		oauthErr := &core.OAuthError{Code: "server_error", Description: "something went wrong on our end"}
+		errors.As(err, &oauthErr)
+		params.Set("error", oauthErr.Code)
+		params.Set("error_description", oauthErr.Description)
+	} else {
+		params.Set("code", code)
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	redirect, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if err := common.WriteOAuthError(w, core.ErrInvalidClient.Code, core.ErrInvalidClient.Description, http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (parse redirect uri)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	values := redirect.Query()
+	for k := range params {
+		values.Set(k, params.Get(k))
+	}
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+
+	m.logs.Infow(
+		"authorization request completed",
+		"client_id", req.ClientID,
+		"error", params.Get("error"),
+		"request_id", requestID,
+	)
+}
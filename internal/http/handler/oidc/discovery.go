@@ -0,0 +1,77 @@
+package oidc
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type discoveryHandler struct {
+	logs   *zap.SugaredLogger
+	issuer string
+	keys   KeyRing
+}
+
+func NewDiscoveryHandler(logger *zap.SugaredLogger, issuer string, keys KeyRing) *discoveryHandler {
+	return &discoveryHandler{
+		logs:   logger,
+		issuer: issuer,
+		keys:   keys,
+	}
+}
+
+func (m *discoveryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := common.WriteJSON(w, m.configuration(), http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (discovery)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+func (m *discoveryHandler) configuration() model.OpenIDConfiguration {
+	var algs []string
+	for _, key := range m.keys.Keys() {
+		if key.Active {
+			algs = append(algs, key.Algorithm)
+		}
+	}
+
+	return model.OpenIDConfiguration{
+		Issuer:                            m.issuer,
+		AuthorizationEndpoint:             m.issuer + "/authorize",
+		TokenEndpoint:                     m.issuer + "/token",
+		UserinfoEndpoint:                  m.issuer + "/userinfo",
+		IntrospectionEndpoint:             m.issuer + "/oauth/introspect",
+		RevocationEndpoint:                m.issuer + "/oauth/revoke",
+		JwksURI:                           m.issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   []string{"openid", "profile", "email"},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algs,
+		TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_basic", "client_secret_post"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "name", "given_name", "family_name", "email"},
+	}
+}
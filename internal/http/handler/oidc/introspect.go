@@ -0,0 +1,120 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type introspectHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewIntrospectHandler(logger *zap.SugaredLogger, reg Registry) *introspectHandler {
+	return &introspectHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *introspectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	req, err := tokenActionRequest(r)
+	if err != nil {
+		m.logs.Warnw(
+			"invalid introspection request",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteOAuthError(w, core.ErrInvalidRequest.Code, core.ErrInvalidRequest.Description, http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (parse form)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	resp, err := m.registry.IntrospectToken(req)
+	if err != nil {
+		m.logs.Warnw(
+			"introspection failed",
+			"error", err,
+			"client_id", req.ClientID,
+			"request_id", requestID,
+		)
+		writeTokenActionError(w, err, m.logs, requestID)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err := common.WriteJSON(w, resp, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (introspection success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+// tokenActionRequest reads the form of an introspection or revocation request
+func tokenActionRequest(r *http.Request) (model.TokenActionRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return model.TokenActionRequest{}, fmt.Errorf("parse form: %w", err)
+	}
+
+	req := model.TokenActionRequest{
+		ClientID:      r.PostForm.Get("client_id"),
+		ClientSecret:  r.PostForm.Get("client_secret"),
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: r.PostForm.Get("token_type_hint"),
+	}
+	if id, secret, ok := clientBasicAuth(r); ok {
+		req.ClientID = id
+		req.ClientSecret = secret
+	}
+	if req.Token == "" {
+		return model.TokenActionRequest{}, errors.New("missing token")
+	}
+	return req, nil
+}
+
+func writeTokenActionError(w http.ResponseWriter, err error, logs *zap.SugaredLogger, requestID string) {
+	oauthErr := &core.OAuthError{Code: "server_error", Description: "something went wrong on our end"}
+	status := http.StatusInternalServerError
+	if errors.As(err, &oauthErr) {
+		status = http.StatusBadRequest
+		if oauthErr.Code == core.ErrClientAuthFailed.Code {
+			w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+			status = http.StatusUnauthorized
+		}
+	}
+	if err := common.WriteOAuthError(w, oauthErr.Code, oauthErr.Description, status); err != nil {
+		logs.Errorw(
+			"write response failed (token action)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
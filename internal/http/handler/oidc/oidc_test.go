@@ -0,0 +1,368 @@
+package oidc_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/oidc"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type registryMock struct {
+	authorize  func(req model.AuthorizeRequest, accessToken string) (string, error)
+	issueToken func(req model.TokenRequest) (model.TokenResponse, error)
+	userInfo   func(accessToken string) (map[string]any, error)
+	introspect func(req model.TokenActionRequest) (map[string]any, error)
+	revoke     func(req model.TokenActionRequest) error
+}
+
+func (r *registryMock) Authorize(req model.AuthorizeRequest, accessToken string) (string, error) {
+	return r.authorize(req, accessToken)
+}
+func (r *registryMock) IssueToken(req model.TokenRequest) (model.TokenResponse, error) {
+	return r.issueToken(req)
+}
+func (r *registryMock) UserInfo(accessToken string) (map[string]any, error) {
+	return r.userInfo(accessToken)
+}
+func (r *registryMock) IntrospectToken(req model.TokenActionRequest) (map[string]any, error) {
+	return r.introspect(req)
+}
+func (r *registryMock) RevokeToken(req model.TokenActionRequest) error {
+	return r.revoke(req)
+}
+
+type keyRingMock struct{}
+
+func (k *keyRingMock) Keys() []jwt.KeyStatus {
+	return []jwt.KeyStatus{{ID: "old", Algorithm: "RS256"}, {ID: "new", Algorithm: "EdDSA", Active: true}}
+}
+
+const authorizeQuery = "/authorize?response_type=code&client_id=spa&redirect_uri=https%3A%2F%2Fspa.test%2Fcallback&scope=openid&state=xyz&code_challenge=abc&code_challenge_method=S256"
+
+func Test_Authorize_Success(t *testing.T) {
+	registry := &registryMock{
+		authorize: func(req model.AuthorizeRequest, accessToken string) (string, error) {
+			if accessToken != "fake_jwt_token" || req.ClientID != "spa" || req.CodeChallenge != "abc" {
+				return "", fmt.Errorf("unexpected request %+v", req)
+			}
+			return "auth_code", nil
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewAuthorizeHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodGet, authorizeQuery, nil)
+	request.AddCookie(&http.Cookie{Name: "Authentication", Value: "fake_jwt_token"})
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusFound != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusFound, response.Code)
+	}
+	location, err := url.Parse(response.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect location: %s", err)
+	}
+	if location.Host != "spa.test" || location.Query().Get("code") != "auth_code" || location.Query().Get("state") != "xyz" {
+		t.Fatalf("unexpected redirect: %s", location)
+	}
+}
+
+func Test_Authorize_LoginRequired(t *testing.T) {
+	registry := &registryMock{
+		authorize: func(req model.AuthorizeRequest, accessToken string) (string, error) {
+			return "", fmt.Errorf("%w: no token", core.ErrLoginRequired)
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewAuthorizeHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodGet, authorizeQuery, nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	location, err := url.Parse(response.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect location: %s", err)
+	}
+	if location.Query().Get("error") != "login_required" || location.Query().Get("state") != "xyz" {
+		t.Fatalf("unexpected redirect: %s", location)
+	}
+}
+
+func Test_Authorize_InvalidClient_NoRedirect(t *testing.T) {
+	registry := &registryMock{
+		authorize: func(req model.AuthorizeRequest, accessToken string) (string, error) {
+			return "", core.ErrInvalidClient
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewAuthorizeHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodGet, authorizeQuery, nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+	if location := response.Header().Get("Location"); location != "" {
+		t.Fatalf("redirected to unverified uri: %s", location)
+	}
+}
+
+func Test_Token_InvalidGrant(t *testing.T) {
+	registry := &registryMock{
+		issueToken: func(req model.TokenRequest) (model.TokenResponse, error) {
+			return model.TokenResponse{}, fmt.Errorf("%w: code reused", core.ErrInvalidGrant)
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewTokenHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader("grant_type=authorization_code&code=used&client_id=spa")
+	request, _ := http.NewRequest(http.MethodPost, "/token", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var got model.OAuthErrorResponse
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.Error != "invalid_grant" {
+		t.Fatalf("error does not match, expected: %s, got: %s", "invalid_grant", got.Error)
+	}
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
+
+func Test_Token_Success(t *testing.T) {
+	registry := &registryMock{
+		issueToken: func(req model.TokenRequest) (model.TokenResponse, error) {
+			if req.CodeVerifier != "verifier" {
+				return model.TokenResponse{}, core.ErrInvalidGrant
+			}
+			return model.TokenResponse{AccessToken: "access", TokenType: "Bearer", IDToken: "id"}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewTokenHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader("grant_type=authorization_code&code=code&client_id=spa&code_verifier=verifier")
+	request, _ := http.NewRequest(http.MethodPost, "/token", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var got model.TokenResponse
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.AccessToken != "access" || got.IDToken != "id" {
+		t.Fatalf("unexpected token response: %+v", got)
+	}
+	if response.Header().Get("Cache-Control") != "no-store" {
+		t.Fatal("token response may be cached")
+	}
+}
+
+func Test_UserInfo_MissingBearer(t *testing.T) {
+	handler := middleware.SetContextRequestID(oidc.NewUserInfoHandler(zap.NewNop().Sugar(), &registryMock{}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/userinfo", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusUnauthorized != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusUnauthorized, response.Code)
+	}
+}
+
+func Test_UserInfo_Success(t *testing.T) {
+	registry := &registryMock{
+		userInfo: func(accessToken string) (map[string]any, error) {
+			return map[string]any{"sub": "7", "email": "test@test.com"}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewUserInfoHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodGet, "/userinfo", nil)
+	request.Header.Set("Authorization", "Bearer fake_jwt_token")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var got map[string]any
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got["sub"] != "7" {
+		t.Fatalf("unexpected userinfo: %+v", got)
+	}
+}
+
+func Test_Discovery(t *testing.T) {
+	handler := middleware.SetContextRequestID(oidc.NewDiscoveryHandler(zap.NewNop().Sugar(), "https://issuer.test", &keyRingMock{}))
+
+	request, _ := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var got model.OpenIDConfiguration
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.Issuer != "https://issuer.test" || got.TokenEndpoint != "https://issuer.test/token" {
+		t.Fatalf("unexpected configuration: %+v", got)
+	}
+	if len(got.IDTokenSigningAlgValuesSupported) != 1 || got.IDTokenSigningAlgValuesSupported[0] != "EdDSA" {
+		t.Fatalf("unexpected signing algorithms: %v", got.IDTokenSigningAlgValuesSupported)
+	}
+}
+
+func Test_Token_ClientCredentials_BasicAuth(t *testing.T) {
+	registry := &registryMock{
+		issueToken: func(req model.TokenRequest) (model.TokenResponse, error) {
+			if req.GrantType != "client_credentials" || req.ClientID != "job:1" || req.ClientSecret != "s3cret" || req.Scope != "reports" {
+				return model.TokenResponse{}, core.ErrClientAuthFailed
+			}
+			return model.TokenResponse{AccessToken: "access", TokenType: "Bearer", Scope: "reports"}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewTokenHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader("grant_type=client_credentials&scope=reports")
+	request, _ := http.NewRequest(http.MethodPost, "/oauth/token", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth(url.QueryEscape("job:1"), "s3cret")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_Token_ClientCredentials_InvalidClient(t *testing.T) {
+	registry := &registryMock{
+		issueToken: func(req model.TokenRequest) (model.TokenResponse, error) {
+			return model.TokenResponse{}, fmt.Errorf("authenticate client: %w", core.ErrClientAuthFailed)
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewTokenHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader("grant_type=client_credentials&client_id=job&client_secret=wrong")
+	request, _ := http.NewRequest(http.MethodPost, "/oauth/token", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusUnauthorized != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusUnauthorized, response.Code)
+	}
+}
+
+func Test_Introspect_Active(t *testing.T) {
+	registry := &registryMock{
+		introspect: func(req model.TokenActionRequest) (map[string]any, error) {
+			if req.ClientID != "gateway" || req.ClientSecret != "s3cret" || req.Token != "access" {
+				return nil, core.ErrClientAuthFailed
+			}
+			return map[string]any{"active": true, "sub": "42"}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewIntrospectHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader("token=access&token_type_hint=access_token")
+	request, _ := http.NewRequest(http.MethodPost, "/oauth/introspect", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth("gateway", "s3cret")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	var got map[string]any
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got["active"] != true || got["sub"] != "42" {
+		t.Fatalf("unexpected introspection response: %v", got)
+	}
+}
+
+func Test_Introspect_MissingToken(t *testing.T) {
+	handler := middleware.SetContextRequestID(oidc.NewIntrospectHandler(zap.NewNop().Sugar(), &registryMock{}))
+
+	body := strings.NewReader("client_id=gateway&client_secret=s3cret")
+	request, _ := http.NewRequest(http.MethodPost, "/oauth/introspect", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
+
+func Test_Revoke_InvalidClient(t *testing.T) {
+	registry := &registryMock{
+		revoke: func(req model.TokenActionRequest) error {
+			return fmt.Errorf("authenticate client: %w", core.ErrClientAuthFailed)
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewRevokeHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader("token=access&client_id=gateway&client_secret=wrong")
+	request, _ := http.NewRequest(http.MethodPost, "/oauth/revoke", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusUnauthorized != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusUnauthorized, response.Code)
+	}
+	if response.Header().Get("WWW-Authenticate") == "" {
+		t.Fatal("expected WWW-Authenticate header")
+	}
+}
+
+func Test_Revoke_NotOwner(t *testing.T) {
+	registry := &registryMock{
+		revoke: func(req model.TokenActionRequest) error {
+			return fmt.Errorf("revoke token: %w", core.ErrUnauthorizedClient)
+		},
+	}
+	handler := middleware.SetContextRequestID(oidc.NewRevokeHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader("token=access&client_id=gateway&client_secret=s3cret")
+	request, _ := http.NewRequest(http.MethodPost, "/oauth/revoke", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
@@ -0,0 +1,18 @@
+package oidc
+
+import (
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+type Registry interface {
+	Authorize(req model.AuthorizeRequest, accessToken string) (string, error)
+	IssueToken(req model.TokenRequest) (model.TokenResponse, error)
+	UserInfo(accessToken string) (map[string]any, error)
+	IntrospectToken(req model.TokenActionRequest) (map[string]any, error)
+	RevokeToken(req model.TokenActionRequest) error
+}
+
+type KeyRing interface {
+	Keys() []jwt.KeyStatus
+}
@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type revokeHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewRevokeHandler(logger *zap.SugaredLogger, reg Registry) *revokeHandler {
+	return &revokeHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *revokeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	req, err := tokenActionRequest(r)
+	if err != nil {
+		m.logs.Warnw(
+			"invalid revocation request",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteOAuthError(w, core.ErrInvalidRequest.Code, core.ErrInvalidRequest.Description, http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (parse form)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.RevokeToken(req); err != nil {
+		m.logs.Warnw(
+			"revocation failed",
+			"error", err,
+			"client_id", req.ClientID,
+			"request_id", requestID,
+		)
+		writeTokenActionError(w, err, m.logs, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	m.logs.Infow(
+		"token revoked",
+		"client_id", req.ClientID,
+		"request_id", requestID,
+	)
+}
@@ -0,0 +1,134 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type tokenHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewTokenHandler(logger *zap.SugaredLogger, reg Registry) *tokenHandler {
+	return &tokenHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *tokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		m.logs.Warnw(
+			"parse form failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteOAuthError(w, core.ErrInvalidRequest.Code, core.ErrInvalidRequest.Description, http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (parse form)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	req := model.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		Scope:        r.PostForm.Get("scope"),
+	}
+	if id, secret, ok := clientBasicAuth(r); ok {
+		req.ClientID = id
+		req.ClientSecret = secret
+	}
+
+	resp, err := m.registry.IssueToken(req)
+	if err != nil {
+		m.logs.Warnw(
+			"token request failed",
+			"error", err,
+			"client_id", req.ClientID,
+			"request_id", requestID,
+		)
+		oauthErr := &core.OAuthError{Code: "server_error", Description: "something went wrong on our end"}
+		status := http.StatusInternalServerError
+		if errors.As(err, &oauthErr) {
+			status = http.StatusBadRequest
+			if oauthErr.Code == core.ErrInvalidClient.Code {
+				w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+				status = http.StatusUnauthorized
+			}
+		}
+		if err := common.WriteOAuthError(w, oauthErr.Code, oauthErr.Description, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (token request)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err := common.WriteJSON(w, resp, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (token success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"issued tokens",
+		"client_id", req.ClientID,
+		"grant_type", req.GrantType,
+		"request_id", requestID,
+	)
+}
+
+// clientBasicAuth reads client credentials sent with HTTP Basic authentication.
+// Both parts are form-encoded as required by RFC 6749, section 2.3.1.
+func clientBasicAuth(r *http.Request) (string, string, bool) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return "", "", false
+	}
+	id, err := url.QueryUnescape(id)
+	if err != nil {
+		return "", "", false
+	}
+	secret, err = url.QueryUnescape(secret)
+	if err != nil {
+		return "", "", false
+	}
+	return id, secret, true
+}
@@ -0,0 +1,93 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type userInfoHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewUserInfoHandler(logger *zap.SugaredLogger, reg Registry) *userInfoHandler {
+	return &userInfoHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *userInfoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	token, ok := common.BearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		if err := common.WriteResponse(w, "missing bearer token", http.StatusUnauthorized); err != nil {
+			m.logs.Errorw(
+				"write response failed (missing bearer token)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	claims, err := m.registry.UserInfo(token)
+	if err != nil {
+		m.logs.Warnw(
+			"userinfo failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, core.ErrInsufficientScope):
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			msg = core.ErrInsufficientScope.Description
+			status = http.StatusForbidden
+		case errors.Is(err, jwt.ErrTokenNotValid), errors.Is(err, core.ErrTokenRevoked), errors.Is(err, core.ErrTokenUse):
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			msg = "invalid authentication token"
+			status = http.StatusUnauthorized
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (userinfo)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err := common.WriteJSON(w, claims, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (userinfo success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
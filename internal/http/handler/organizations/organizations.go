@@ -0,0 +1,137 @@
+package organizations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type organizationsHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewOrganizationsHandler lists the organizations on GET and creates one
+// on POST
+func NewOrganizationsHandler(logger *zap.SugaredLogger, reg Registry) *organizationsHandler {
+	return &organizationsHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *organizationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		m.list(w, requestID)
+		return
+	case http.MethodPost:
+	default:
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.CreateOrganizationDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	org, err := m.registry.CreateOrganization(dto)
+	if err != nil {
+		m.logs.Errorw(
+			"create organization failed",
+			"error", err,
+			"slug", dto.Slug,
+			"request_id", requestID,
+		)
+		msg := "internal server error"
+		status := http.StatusInternalServerError
+		var validationErr validator.ValidationErrors
+		switch {
+		case errors.Is(err, core.ErrOrganizationExists):
+			msg = fmt.Sprintf("organization %s already exists", dto.Slug)
+			status = http.StatusConflict
+		case errors.As(err, &validationErr):
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (create organization)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	m.logs.Infow(
+		"organization created",
+		"organization_id", org.ID,
+		"slug", org.Slug,
+		"request_id", requestID,
+	)
+	if err := common.WriteJSON(w, org, http.StatusCreated); err != nil {
+		m.logs.Errorw(
+			"write response failed (create organization success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+func (m *organizationsHandler) list(w http.ResponseWriter, requestID string) {
+	orgs, err := m.registry.ListOrganizations()
+	if err != nil {
+		m.logs.Errorw(
+			"list organizations failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "internal server error", http.StatusInternalServerError); err != nil {
+			m.logs.Errorw(
+				"write response failed (list organizations)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err := common.WriteJSON(w, orgs, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (list organizations success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
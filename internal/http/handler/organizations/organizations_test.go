@@ -0,0 +1,74 @@
+package organizations_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/organizations"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type organizationsMock struct {
+	list   func() ([]model.OrganizationDTO, error)
+	create func(dto model.CreateOrganizationDTO) (model.OrganizationDTO, error)
+}
+
+func (m *organizationsMock) ListOrganizations() ([]model.OrganizationDTO, error) {
+	return m.list()
+}
+
+func (m *organizationsMock) CreateOrganization(dto model.CreateOrganizationDTO) (model.OrganizationDTO, error) {
+	return m.create(dto)
+}
+
+func Test_Organizations_Create(t *testing.T) {
+	registry := &organizationsMock{
+		create: func(dto model.CreateOrganizationDTO) (model.OrganizationDTO, error) {
+			return model.OrganizationDTO{ID: 7, Slug: dto.Slug, Name: dto.Name, AllowSignup: dto.AllowSignup}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(organizations.NewOrganizationsHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"slug": "acme", "name": "Acme", "allow_signup": true}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/organizations", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusCreated != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusCreated, response.Code)
+	}
+	var got model.OrganizationDTO
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.ID != 7 || got.Slug != "acme" || !got.AllowSignup {
+		t.Fatalf("unexpected organization: %+v", got)
+	}
+}
+
+func Test_Organizations_CreateDuplicate(t *testing.T) {
+	registry := &organizationsMock{
+		create: func(dto model.CreateOrganizationDTO) (model.OrganizationDTO, error) {
+			return model.OrganizationDTO{}, fmt.Errorf("%w: %s", core.ErrOrganizationExists, dto.Slug)
+		},
+	}
+	handler := middleware.SetContextRequestID(organizations.NewOrganizationsHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"slug": "acme", "name": "Acme"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/organizations", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusConflict != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusConflict, response.Code)
+	}
+}
@@ -0,0 +1,8 @@
+package organizations
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	ListOrganizations() ([]model.OrganizationDTO, error)
+	CreateOrganization(dto model.CreateOrganizationDTO) (model.OrganizationDTO, error)
+}
@@ -0,0 +1,101 @@
+package password
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+const forgotPasswordMessage = "if an account with this email exists, a password reset link has been sent"
+
+type forgotPasswordHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+	jobs     Jobs
+}
+
+// NewForgotPasswordHandler replies the same way whether or not an account
+// with the email exists, so it cannot be used to look up users. The reset
+// itself runs on jobs after the reply.
+func NewForgotPasswordHandler(logger *zap.SugaredLogger, reg Registry, jobs Jobs) *forgotPasswordHandler {
+	return &forgotPasswordHandler{
+		logs:     logger,
+		registry: reg,
+		jobs:     jobs,
+	}
+}
+
+func (m *forgotPasswordHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.ForgotPasswordDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil || dto.Email == "" {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	// the reset runs after the reply, so neither the reply nor the time it
+	// takes differs for known emails. Failures are only logged.
+	dto.TenantID = common.CurrentTenant(r)
+	err := m.jobs.Submit(func() {
+		if err := m.registry.ForgotPassword(dto); err != nil {
+			m.logs.Errorw(
+				"forgot password failed",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+	})
+	if err != nil {
+		m.logs.Errorw(
+			"forgot password not queued",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "service busy, try again later", http.StatusServiceUnavailable); err != nil {
+			m.logs.Errorw(
+				"write response failed (forgot password not queued)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteResponse(w, forgotPasswordMessage, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (forgot password)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
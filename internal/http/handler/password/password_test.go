@@ -0,0 +1,200 @@
+package password_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/password"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/queue"
+	"go.uber.org/zap"
+)
+
+type registryMock struct {
+	forgotPassword func(model.ForgotPasswordDTO) error
+	resetPassword  func(model.ResetPasswordDTO) error
+}
+
+func (r *registryMock) ForgotPassword(dto model.ForgotPasswordDTO) error {
+	return r.forgotPassword(dto)
+}
+func (r *registryMock) ResetPassword(dto model.ResetPasswordDTO) error {
+	return r.resetPassword(dto)
+}
+
+type jobsMock struct {
+	submit func(job func()) error
+}
+
+func (j *jobsMock) Submit(job func()) error {
+	return j.submit(job)
+}
+
+// inline runs every job before Submit returns
+var inline = &jobsMock{submit: func(job func()) error {
+	job()
+	return nil
+}}
+
+func Test_ForgotPassword_SameResponse(t *testing.T) {
+	registry := &registryMock{
+		forgotPassword: func(dto model.ForgotPasswordDTO) error {
+			if dto.Email == "broken@test.com" {
+				return errors.New("mailer unavailable")
+			}
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(password.NewForgotPasswordHandler(zap.NewNop().Sugar(), registry, inline))
+
+	var bodies []string
+	for _, email := range []string{"known@test.com", "broken@test.com"} {
+		body := strings.NewReader(fmt.Sprintf(`{"email": %q}`, email))
+		request, _ := http.NewRequest(http.MethodPost, "/api/password/forgot", body)
+		response := httptest.NewRecorder()
+
+		handler.ServeHTTP(response, request)
+
+		if http.StatusOK != response.Code {
+			t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+		}
+		bodies = append(bodies, response.Body.String())
+	}
+	if bodies[0] != bodies[1] {
+		t.Fatalf("responses differ: %s, %s", bodies[0], bodies[1])
+	}
+}
+
+func Test_ForgotPassword_DoesNotWait(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan model.ForgotPasswordDTO)
+	registry := &registryMock{
+		forgotPassword: func(dto model.ForgotPasswordDTO) error {
+			<-release
+			done <- dto
+			return nil
+		},
+	}
+	jobs := queue.New(1, 1)
+	handler := middleware.SetContextRequestID(password.NewForgotPasswordHandler(zap.NewNop().Sugar(), registry, jobs))
+
+	body := strings.NewReader(`{"email": "known@test.com"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/password/forgot", body)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	close(release)
+	if dto := <-done; dto.Email != "known@test.com" {
+		t.Fatalf("unexpected email: %s", dto.Email)
+	}
+	if err := jobs.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func Test_ForgotPassword_Busy(t *testing.T) {
+	registry := &registryMock{
+		forgotPassword: func(dto model.ForgotPasswordDTO) error {
+			t.Fatal("reset ran although the queue was full")
+			return nil
+		},
+	}
+	full := &jobsMock{submit: func(job func()) error {
+		return queue.ErrFull
+	}}
+	handler := middleware.SetContextRequestID(password.NewForgotPasswordHandler(zap.NewNop().Sugar(), registry, full))
+
+	body := strings.NewReader(`{"email": "known@test.com"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/password/forgot", body)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if http.StatusServiceUnavailable != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusServiceUnavailable, response.Code)
+	}
+}
+
+func Test_ResetPassword_InvalidToken(t *testing.T) {
+	registry := &registryMock{
+		resetPassword: func(dto model.ResetPasswordDTO) error {
+			return core.ErrInvalidResetToken
+		},
+	}
+	handler := middleware.SetContextRequestID(password.NewResetPasswordHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"token": "used", "password": "new_password"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/password/reset", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+	var got model.ResponseMessage
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if got.Message != "invalid or expired reset token" {
+		t.Fatalf("unexpected message: %s", got.Message)
+	}
+}
+
+func Test_ResetPassword_WeakPassword(t *testing.T) {
+	registry := &registryMock{
+		resetPassword: func(dto model.ResetPasswordDTO) error {
+			return fmt.Errorf("reset password: %w", &core.PolicyError{Violations: []string{"must be at least 8 characters long"}})
+		},
+	}
+	handler := middleware.SetContextRequestID(password.NewResetPasswordHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"token": "valid", "password": "short"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/password/reset", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+	var got model.ResponseMessage
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if !strings.Contains(got.Message, "at least 8 characters") {
+		t.Fatalf("unexpected message: %s", got.Message)
+	}
+}
+
+func Test_ResetPassword_Success(t *testing.T) {
+	registry := &registryMock{
+		resetPassword: func(dto model.ResetPasswordDTO) error {
+			if dto.Token != "reset_token" || dto.Password != "new_password" {
+				return fmt.Errorf("unexpected dto %+v", dto)
+			}
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(password.NewResetPasswordHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"token": "reset_token", "password": "new_password"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/password/reset", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
@@ -0,0 +1,14 @@
+package password
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	ForgotPassword(model.ForgotPasswordDTO) error
+	ResetPassword(model.ResetPasswordDTO) error
+}
+
+// Jobs runs work after the reply has been sent. Submit fails when the work
+// cannot be taken on.
+type Jobs interface {
+	Submit(job func()) error
+}
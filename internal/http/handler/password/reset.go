@@ -0,0 +1,106 @@
+package password
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type resetPasswordHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewResetPasswordHandler(logger *zap.SugaredLogger, reg Registry) *resetPasswordHandler {
+	return &resetPasswordHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *resetPasswordHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.ResetPasswordDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.ResetPassword(dto); err != nil {
+		m.logs.Warnw(
+			"reset password failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		var validationErr validator.ValidationErrors
+		var policyErr *core.PolicyError
+		switch {
+		case errors.Is(err, core.ErrInvalidResetToken):
+			msg = "invalid or expired reset token"
+			status = http.StatusBadRequest
+		case errors.As(err, &policyErr):
+			msg = policyErr.Error()
+			status = http.StatusBadRequest
+		case errors.As(err, &validationErr):
+			msg = "invalid request body"
+			status = http.StatusBadRequest
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (reset password)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteResponse(w, "password reset successfully", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (reset password success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"password reset",
+		"request_id", requestID,
+	)
+}
@@ -0,0 +1,7 @@
+package refresh
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	RefreshTokens(refreshToken string) (model.AuthTokens, error)
+}
@@ -0,0 +1,125 @@
+package refresh
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type refreshHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewRefreshHandler(logger *zap.SugaredLogger, reg Registry) *refreshHandler {
+	return &refreshHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *refreshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	refreshToken, err := readRefreshToken(r)
+	if err != nil {
+		m.logs.Warnw(
+			"failed to read refresh token",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "missing refresh token", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (read refresh token)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	tokens, err := m.registry.RefreshTokens(refreshToken)
+	if err != nil {
+		m.logs.Errorw(
+			"refresh tokens failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "internal server error"
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrInvalidRefreshToken) || errors.Is(err, core.ErrRefreshTokenReused) {
+			msg = "invalid refresh token"
+			status = http.StatusUnauthorized
+		}
+		if errors.Is(err, core.ErrNotMember) {
+			msg = "not a member of the organization"
+			status = http.StatusForbidden
+		}
+		if errors.Is(err, core.ErrAccountDisabled) {
+			msg = "account disabled"
+			status = http.StatusForbidden
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (refresh)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	common.SetAuthCookies(w, tokens)
+
+	if err := common.WriteResponse(w, "token refreshed", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (refresh success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"successfully refreshed token",
+		"request_id", requestID,
+	)
+}
+
+// readRefreshToken takes the refresh token from the "Refresh" cookie
+// and falls back to the JSON request body for non-browser clients.
+func readRefreshToken(r *http.Request) (string, error) {
+	cookie, err := r.Cookie("Refresh")
+	if err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+
+	var dto model.RefreshDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return "", fmt.Errorf("json decode: %w", err)
+	}
+	if dto.RefreshToken == "" {
+		return "", errors.New("empty refresh token")
+	}
+	return dto.RefreshToken, nil
+}
@@ -0,0 +1,128 @@
+package refresh_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/refresh"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type refreshMock struct {
+	refreshTokens func(refreshToken string) (model.AuthTokens, error)
+}
+
+func (r *refreshMock) RefreshTokens(refreshToken string) (model.AuthTokens, error) {
+	return r.refreshTokens(refreshToken)
+}
+
+func Test_ServeHTTP_Cookie_Success(t *testing.T) {
+	oldRefresh := "old_refresh_token"
+	expectedToken := "new_jwt_token"
+	expectedRefresh := "new_refresh_token"
+	registry := &refreshMock{
+		refreshTokens: func(refreshToken string) (model.AuthTokens, error) {
+			if refreshToken != oldRefresh {
+				return model.AuthTokens{}, fmt.Errorf("unexpected refresh token %s", refreshToken)
+			}
+			return model.AuthTokens{
+				AccessToken:      expectedToken,
+				AccessExpiresAt:  time.Now().Add(time.Hour),
+				RefreshToken:     expectedRefresh,
+				RefreshExpiresAt: time.Now().Add(24 * time.Hour),
+			}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(refresh.NewRefreshHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/token/refresh", http.NoBody)
+	request.AddCookie(&http.Cookie{Name: "Refresh", Value: oldRefresh})
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var authCookie, refreshCookie string
+	for _, cookie := range response.Result().Cookies() {
+		switch cookie.Name {
+		case "Authentication":
+			authCookie = cookie.Value
+		case "Refresh":
+			refreshCookie = cookie.Value
+		}
+	}
+
+	expectedBody, err := json.Marshal(model.ResponseMessage{Message: "token refreshed"})
+	if err != nil {
+		t.Fatal("failed to marshal ResponseMessage")
+	}
+	got, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal("failed to read response body")
+	}
+
+	if authCookie != expectedToken {
+		t.Fatalf("cookie does not match, expected: %s, got: %s", expectedToken, authCookie)
+	}
+	if refreshCookie != expectedRefresh {
+		t.Fatalf("refresh cookie does not match, expected: %s, got: %s", expectedRefresh, refreshCookie)
+	}
+	if string(expectedBody) != string(got) {
+		t.Fatalf("response does not match, expected: %s, got: %s", expectedBody, got)
+	}
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_ServeHTTP_Body_Reused(t *testing.T) {
+	registry := &refreshMock{
+		refreshTokens: func(refreshToken string) (model.AuthTokens, error) {
+			return model.AuthTokens{}, fmt.Errorf("mock error: %w", core.ErrRefreshTokenReused)
+		},
+	}
+	handler := middleware.SetContextRequestID(refresh.NewRefreshHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"refresh_token": "used_refresh_token"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/token/refresh", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	expectedBody, err := json.Marshal(model.ResponseMessage{Message: "invalid refresh token"})
+	if err != nil {
+		t.Fatal("failed to marshal ResponseMessage")
+	}
+	got, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal("failed to read response body")
+	}
+
+	if string(expectedBody) != string(got) {
+		t.Fatalf("response does not match, expected: %s, got: %s", expectedBody, got)
+	}
+	if http.StatusUnauthorized != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusUnauthorized, response.Code)
+	}
+}
+
+func Test_ServeHTTP_MissingToken(t *testing.T) {
+	handler := middleware.SetContextRequestID(refresh.NewRefreshHandler(zap.NewNop().Sugar(), &refreshMock{}))
+
+	request, _ := http.NewRequest(http.MethodPost, "/api/token/refresh", strings.NewReader("{}"))
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
@@ -4,5 +4,4 @@ import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
 
 type Registry interface {
 	RegisterUser(model.RegisterDTO) error
-	UserExists(email string) (bool, error)
 }
@@ -2,9 +2,11 @@ package register
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
 	"go.uber.org/zap"
@@ -56,36 +58,68 @@ func (m *registerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userExists, err := m.registry.UserExists(dto.Email)
-	if err != nil {
+	dto.TenantID = common.CurrentTenant(r)
+	err := m.registry.RegisterUser(dto)
+	if errors.Is(err, core.ErrUserExists) {
+		msg := fmt.Sprintf("user with email %s already exists", dto.Email)
+		if err := common.WriteResponse(w, msg, http.StatusOK); err != nil {
+			m.logs.Errorw(
+				"write response (user exists)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if errors.Is(err, core.ErrEmailVerificationNotSent) {
 		m.logs.Errorw(
-			"failed getting user from db",
+			"send email verification failed",
 			"error", err,
 			"request_id", requestID,
+			"email", dto.Email,
 		)
-		if err := common.WriteResponse(w, "something went wrong on our end", http.StatusInternalServerError); err != nil {
+		msg := "user registered successfully, but the verification email could not be sent"
+		if err := common.WriteResponse(w, msg, http.StatusOK); err != nil {
 			m.logs.Errorw(
-				"write response (get user failed)",
+				"write response (verification not sent)",
 				"error", err,
 				"request_id", requestID,
 			)
 		}
 		return
 	}
-
-	if userExists {
-		msg := fmt.Sprintf("user with email %s already exists", dto.Email)
-		if err := common.WriteResponse(w, msg, http.StatusOK); err != nil {
+	if errors.Is(err, core.ErrSignupClosed) || errors.Is(err, core.ErrUnknownTenant) {
+		m.logs.Warnw(
+			"registration refused",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "registration is closed for this organization", http.StatusForbidden); err != nil {
 			m.logs.Errorw(
-				"write response (get user failed)",
+				"write response failed (signup closed)",
 				"error", err,
 				"request_id", requestID,
 			)
 		}
 		return
 	}
-
-	if err := m.registry.RegisterUser(dto); err != nil {
+	var policyErr *core.PolicyError
+	if errors.As(err, &policyErr) {
+		m.logs.Warnw(
+			"password rejected by policy",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, policyErr.Error(), http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (weak password)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err != nil {
 		m.logs.Errorw(
 			"register user",
 			"error", err,
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/register"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
@@ -153,12 +154,8 @@ func Test_ServeHTTP_GetMethod_InvalidMethod(t *testing.T) {
 
 func Test_ServeHTTP_Duplicate(t *testing.T) {
 	registry := &registryMock{
-		registerUser: func(model.RegisterDTO) error {
-			// simulate successful register
-			return nil
-		},
-		userExists: func(email string) (bool, error) {
-			return true, nil
+		registerUser: func(dto model.RegisterDTO) error {
+			return fmt.Errorf("create user: %w: %s", core.ErrUserExists, dto.Email)
 		},
 	}
 
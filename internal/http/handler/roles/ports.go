@@ -0,0 +1,10 @@
+package roles
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	ListRoles() ([]model.RoleDTO, error)
+	SaveRole(dto model.SaveRoleDTO) error
+	AssignRole(dto model.RoleAssignmentDTO) error
+	RemoveRole(dto model.RoleAssignmentDTO) error
+}
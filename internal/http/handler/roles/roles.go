@@ -0,0 +1,226 @@
+package roles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type rolesHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewRolesHandler lists the roles on GET and creates or updates a role
+// on POST
+func NewRolesHandler(logger *zap.SugaredLogger, reg Registry) *rolesHandler {
+	return &rolesHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *rolesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		m.list(w, requestID)
+		return
+	case http.MethodPost:
+	default:
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.SaveRoleDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.SaveRole(dto); err != nil {
+		m.logs.Errorw(
+			"save role failed",
+			"error", err,
+			"role", dto.Name,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (save role)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	m.logs.Infow(
+		"role saved",
+		"role", dto.Name,
+		"permissions", dto.Permissions,
+		"request_id", requestID,
+	)
+	m.list(w, requestID)
+}
+
+func (m *rolesHandler) list(w http.ResponseWriter, requestID string) {
+	roles, err := m.registry.ListRoles()
+	if err != nil {
+		m.logs.Errorw(
+			"list roles failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (list roles)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err := common.WriteJSON(w, roles, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (list roles success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+type userRolesHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewUserRolesHandler assigns a role to a user on POST and takes it away
+// on DELETE
+func NewUserRolesHandler(logger *zap.SugaredLogger, reg Registry) *userRolesHandler {
+	return &userRolesHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *userRolesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.RoleAssignmentDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	var err error
+	msg := "role assigned"
+	if r.Method == http.MethodPost {
+		err = m.registry.AssignRole(dto)
+	} else {
+		err = m.registry.RemoveRole(dto)
+		msg = "role removed"
+	}
+	if err != nil {
+		m.logs.Errorw(
+			"change user roles failed",
+			"error", err,
+			"user_id", dto.UserID,
+			"role", dto.Role,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (change user roles)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	m.logs.Infow(
+		msg,
+		"user_id", dto.UserID,
+		"role", dto.Role,
+		"request_id", requestID,
+	)
+	if err := common.WriteResponse(w, msg, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (change user roles success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+func errorResponse(err error) (string, int) {
+	var validationErr validator.ValidationErrors
+	switch {
+	case errors.Is(err, core.ErrRolesDisabled):
+		return "roles are not enabled", http.StatusNotFound
+	case errors.Is(err, core.ErrUnknownRole):
+		return "unknown role", http.StatusNotFound
+	case errors.Is(err, core.ErrUserNotFound):
+		return "user not found", http.StatusNotFound
+	case errors.Is(err, core.ErrInvalidPermission), errors.As(err, &validationErr):
+		return "invalid request body", http.StatusBadRequest
+	}
+	return "internal server error", http.StatusInternalServerError
+}
@@ -0,0 +1,99 @@
+package roles_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/roles"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type rolesMock struct {
+	list   func() ([]model.RoleDTO, error)
+	save   func(dto model.SaveRoleDTO) error
+	assign func(dto model.RoleAssignmentDTO) error
+	remove func(dto model.RoleAssignmentDTO) error
+}
+
+func (m *rolesMock) ListRoles() ([]model.RoleDTO, error) {
+	return m.list()
+}
+
+func (m *rolesMock) SaveRole(dto model.SaveRoleDTO) error {
+	return m.save(dto)
+}
+
+func (m *rolesMock) AssignRole(dto model.RoleAssignmentDTO) error {
+	return m.assign(dto)
+}
+
+func (m *rolesMock) RemoveRole(dto model.RoleAssignmentDTO) error {
+	return m.remove(dto)
+}
+
+func Test_Roles_Save(t *testing.T) {
+	var saved model.SaveRoleDTO
+	registry := &rolesMock{
+		save: func(dto model.SaveRoleDTO) error {
+			saved = dto
+			return nil
+		},
+		list: func() ([]model.RoleDTO, error) {
+			return []model.RoleDTO{{Name: saved.Name, Permissions: saved.Permissions}}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(roles.NewRolesHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"name": "support", "permissions": ["users:read"]}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/roles", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	var got []model.RoleDTO
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	if len(got) != 1 || got[0].Name != "support" || got[0].Permissions[0] != "users:read" {
+		t.Fatalf("unexpected roles: %+v", got)
+	}
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_UserRoles(t *testing.T) {
+	registry := &rolesMock{
+		assign: func(dto model.RoleAssignmentDTO) error {
+			return fmt.Errorf("assign role: %w: %s", core.ErrUnknownRole, dto.Role)
+		},
+		remove: func(dto model.RoleAssignmentDTO) error {
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(roles.NewUserRolesHandler(zap.NewNop().Sugar(), registry))
+
+	cases := map[string]int{
+		http.MethodPost:   http.StatusNotFound,
+		http.MethodDelete: http.StatusOK,
+		http.MethodGet:    http.StatusMethodNotAllowed,
+	}
+	for method, expected := range cases {
+		body := strings.NewReader(`{"user_id": 3, "role": "support"}`)
+		request, _ := http.NewRequest(method, "/api/admin/users/roles", body)
+		response := httptest.NewRecorder()
+
+		handler.ServeHTTP(response, request)
+
+		if expected != response.Code {
+			t.Errorf("%s: response code does not match, expected: %d, got: %d", method, expected, response.Code)
+		}
+	}
+}
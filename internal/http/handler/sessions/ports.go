@@ -0,0 +1,5 @@
+package sessions
+
+type Registry interface {
+	RevokeUserSessions(tenantID uint, email string) error
+}
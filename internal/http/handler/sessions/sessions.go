@@ -0,0 +1,97 @@
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type revokeSessionsHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+func NewRevokeSessionsHandler(logger *zap.SugaredLogger, reg Registry) *revokeSessionsHandler {
+	return &revokeSessionsHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *revokeSessionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	defer r.Body.Close()
+
+	var dto model.RevokeSessionsDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil || dto.Email == "" {
+		m.logs.Warnw(
+			"json decode failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (decode failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := m.registry.RevokeUserSessions(common.CurrentTenant(r), dto.Email); err != nil {
+		m.logs.Errorw(
+			"revoke user sessions failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg := "something went wrong on our end"
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrUserNotFound) {
+			msg = fmt.Sprintf("user with email %s does not exist", dto.Email)
+			status = http.StatusNotFound
+		}
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (revoke sessions)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if err := common.WriteResponse(w, "user sessions revoked", http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (revoke sessions success)",
+			"error", err,
+			"request_id", requestID,
+		)
+		return
+	}
+
+	m.logs.Infow(
+		"revoked user sessions",
+		"email", dto.Email,
+		"request_id", requestID,
+	)
+}
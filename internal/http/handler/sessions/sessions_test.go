@@ -0,0 +1,84 @@
+package sessions_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/sessions"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"go.uber.org/zap"
+)
+
+type sessionsMock struct {
+	revokeUserSessions func(email string) error
+}
+
+func (s *sessionsMock) RevokeUserSessions(tenantID uint, email string) error {
+	return s.revokeUserSessions(email)
+}
+
+func Test_ServeHTTP_Success(t *testing.T) {
+	var revoked string
+	registry := &sessionsMock{
+		revokeUserSessions: func(email string) error {
+			revoked = email
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(
+		middleware.RequireAdminKey("admin_key", sessions.NewRevokeSessionsHandler(zap.NewNop().Sugar(), registry)),
+	)
+
+	body := strings.NewReader(`{"email": "test@test.com"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/sessions/revoke", body)
+	request.Header.Set("X-Admin-Key", "admin_key")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if revoked != "test@test.com" {
+		t.Fatalf("unexpected user revoked, expected: %s, got: %s", "test@test.com", revoked)
+	}
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_ServeHTTP_UserNotFound(t *testing.T) {
+	registry := &sessionsMock{
+		revokeUserSessions: func(email string) error {
+			return core.ErrUserNotFound
+		},
+	}
+	handler := middleware.SetContextRequestID(sessions.NewRevokeSessionsHandler(zap.NewNop().Sugar(), registry))
+
+	body := strings.NewReader(`{"email": "missing@test.com"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/sessions/revoke", body)
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusNotFound != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusNotFound, response.Code)
+	}
+}
+
+func Test_ServeHTTP_WrongAdminKey(t *testing.T) {
+	handler := middleware.SetContextRequestID(
+		middleware.RequireAdminKey("admin_key", sessions.NewRevokeSessionsHandler(zap.NewNop().Sugar(), &sessionsMock{})),
+	)
+
+	body := strings.NewReader(`{"email": "test@test.com"}`)
+	request, _ := http.NewRequest(http.MethodPost, "/api/admin/sessions/revoke", body)
+	request.Header.Set("X-Admin-Key", "wrong_key")
+	response := httptest.NewRecorder()
+
+	handler.ServeHTTP(response, request)
+
+	if http.StatusForbidden != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusForbidden, response.Code)
+	}
+}
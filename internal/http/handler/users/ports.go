@@ -0,0 +1,13 @@
+package users
+
+import "github.com/dgdraganov/fuzzy-user-api/pkg/model"
+
+type Registry interface {
+	ListUsers(tenantID uint, query model.UserQuery) (model.UserPageDTO, error)
+	GetUserDetails(tenantID, userID uint) (model.AdminUserDTO, error)
+	UpdateUser(tenantID, userID uint, dto model.UpdateUserDTO) (model.AdminUserDTO, error)
+	DisableUser(tenantID, userID uint) error
+	EnableUser(tenantID, userID uint) error
+	DeleteUser(tenantID, userID uint, hard bool) error
+	SearchUsers(tenantID uint, dto model.UserSearchDTO) ([]model.UserMatchDTO, error)
+}
@@ -0,0 +1,98 @@
+package users
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type searchHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewSearchHandler finds the users of the tenant matching the "q"
+// parameter on GET, best matches first
+func NewSearchHandler(logger *zap.SugaredLogger, reg Registry) *searchHandler {
+	return &searchHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *searchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	dto, err := parseSearchQuery(r.URL.Query())
+	if err != nil {
+		m.logs.Warnw(
+			"parse search query failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid query parameters", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (parse query failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	matches, err := m.registry.SearchUsers(common.CurrentTenant(r), dto)
+	if err != nil {
+		m.logs.Errorw(
+			"search users failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (search users)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err := common.WriteJSON(w, matches, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (search users success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+// parseSearchQuery reads the "q" and "limit" parameters
+func parseSearchQuery(values url.Values) (model.UserSearchDTO, error) {
+	dto := model.UserSearchDTO{Query: values.Get("q")}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return model.UserSearchDTO{}, fmt.Errorf("parse limit: %w", err)
+		}
+		dto.Limit = n
+	}
+	return dto, nil
+}
@@ -0,0 +1,275 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+// Prefix is the path the user handler is mounted at, followed by the ID
+// of the user
+const Prefix = "/api/admin/users/"
+
+type usersHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewUsersHandler lists the users of the tenant on GET, a page at a time
+func NewUsersHandler(logger *zap.SugaredLogger, reg Registry) *usersHandler {
+	return &usersHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *usersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	query, err := parseUserQuery(r.URL.Query())
+	if err != nil {
+		m.logs.Warnw(
+			"parse user query failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		if err := common.WriteResponse(w, "invalid query parameters", http.StatusBadRequest); err != nil {
+			m.logs.Errorw(
+				"write response failed (parse query failed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	page, err := m.registry.ListUsers(common.CurrentTenant(r), query)
+	if err != nil {
+		m.logs.Errorw(
+			"list users failed",
+			"error", err,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (list users)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err := common.WriteJSON(w, page, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (list users success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+type userHandler struct {
+	logs     *zap.SugaredLogger
+	registry Registry
+}
+
+// NewUserHandler manages a single user of the tenant under Prefix. It
+// returns the user on GET, updates the fields posted on PATCH and deletes
+// the user on DELETE, for good with the "hard=true" parameter. The
+// "/disable" and "/enable" subpaths take POST.
+func NewUserHandler(logger *zap.SugaredLogger, reg Registry) *userHandler {
+	return &userHandler{
+		logs:     logger,
+		registry: reg,
+	}
+}
+
+func (m *userHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value(model.RequestID).(string)
+	w.Header().Set("Content-Type", "application/json")
+
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, Prefix), "/")
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || userID == 0 || (action != "" && action != "disable" && action != "enable") {
+		if err := common.WriteResponse(w, "not found", http.StatusNotFound); err != nil {
+			m.logs.Errorw(
+				"write response failed (unknown path)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	allowed := r.Method == http.MethodPost
+	if action == "" {
+		allowed = r.Method == http.MethodGet || r.Method == http.MethodPatch || r.Method == http.MethodDelete
+	}
+	if !allowed {
+		msg := fmt.Sprintf("invalid request method - %s", r.Method)
+		if err := common.WriteResponse(w, msg, http.StatusMethodNotAllowed); err != nil {
+			m.logs.Errorw(
+				"write response failed (wrong request method)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	tenantID := common.CurrentTenant(r)
+	var user model.AdminUserDTO
+	msg := ""
+	switch {
+	case action == "disable":
+		err = m.registry.DisableUser(tenantID, uint(userID))
+		msg = "user disabled"
+	case action == "enable":
+		err = m.registry.EnableUser(tenantID, uint(userID))
+		msg = "user enabled"
+	case r.Method == http.MethodDelete:
+		err = m.registry.DeleteUser(tenantID, uint(userID), r.URL.Query().Get("hard") == "true")
+		msg = "user deleted"
+	case r.Method == http.MethodGet:
+		user, err = m.registry.GetUserDetails(tenantID, uint(userID))
+	case r.Method == http.MethodPatch:
+		defer r.Body.Close()
+		var dto model.UpdateUserDTO
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			m.logs.Warnw(
+				"json decode failed",
+				"error", err,
+				"request_id", requestID,
+			)
+			if err := common.WriteResponse(w, "invalid request body", http.StatusBadRequest); err != nil {
+				m.logs.Errorw(
+					"write response failed (decode failed)",
+					"error", err,
+					"request_id", requestID,
+				)
+			}
+			return
+		}
+		user, err = m.registry.UpdateUser(tenantID, uint(userID), dto)
+		msg = "user updated"
+	}
+	if err != nil {
+		m.logs.Errorw(
+			"manage user failed",
+			"error", err,
+			"user_id", userID,
+			"method", r.Method,
+			"action", action,
+			"request_id", requestID,
+		)
+		msg, status := errorResponse(err)
+		if err := common.WriteResponse(w, msg, status); err != nil {
+			m.logs.Errorw(
+				"write response failed (manage user)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+
+	if msg != "" {
+		m.logs.Infow(
+			msg,
+			"user_id", userID,
+			"tenant", tenantID,
+			"request_id", requestID,
+		)
+	}
+	if user.ID == 0 {
+		if err := common.WriteResponse(w, msg, http.StatusOK); err != nil {
+			m.logs.Errorw(
+				"write response failed (manage user success)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+		return
+	}
+	if err := common.WriteJSON(w, user, http.StatusOK); err != nil {
+		m.logs.Errorw(
+			"write response failed (manage user success)",
+			"error", err,
+			"request_id", requestID,
+		)
+	}
+}
+
+// parseUserQuery reads the "limit", "cursor", "created_after",
+// "created_before", "status" and "domain" parameters. Dates are RFC 3339
+// timestamps or plain days.
+func parseUserQuery(values url.Values) (model.UserQuery, error) {
+	query := model.UserQuery{
+		Cursor: values.Get("cursor"),
+		Status: values.Get("status"),
+		Domain: values.Get("domain"),
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return model.UserQuery{}, fmt.Errorf("parse limit: %w", err)
+		}
+		query.Limit = n
+	}
+	var err error
+	if query.CreatedAfter, err = parseDate(values.Get("created_after")); err != nil {
+		return model.UserQuery{}, fmt.Errorf("parse created_after: %w", err)
+	}
+	if query.CreatedBefore, err = parseDate(values.Get("created_before")); err != nil {
+		return model.UserQuery{}, fmt.Errorf("parse created_before: %w", err)
+	}
+	return query, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func errorResponse(err error) (string, int) {
+	var validationErr validator.ValidationErrors
+	switch {
+	case errors.Is(err, core.ErrUserNotFound):
+		return "user not found", http.StatusNotFound
+	case errors.Is(err, core.ErrEmailTaken):
+		return "email already in use", http.StatusConflict
+	case errors.Is(err, core.ErrInvalidCursor), errors.As(err, &validationErr):
+		return "invalid request", http.StatusBadRequest
+	}
+	return "internal server error", http.StatusInternalServerError
+}
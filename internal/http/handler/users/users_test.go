@@ -0,0 +1,179 @@
+package users_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/users"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/go-playground/validator"
+	"go.uber.org/zap"
+)
+
+type usersMock struct {
+	list    func(tenantID uint, query model.UserQuery) (model.UserPageDTO, error)
+	get     func(tenantID, userID uint) (model.AdminUserDTO, error)
+	update  func(tenantID, userID uint, dto model.UpdateUserDTO) (model.AdminUserDTO, error)
+	disable func(tenantID, userID uint) error
+	enable  func(tenantID, userID uint) error
+	delete  func(tenantID, userID uint, hard bool) error
+	search  func(tenantID uint, dto model.UserSearchDTO) ([]model.UserMatchDTO, error)
+}
+
+func (m *usersMock) ListUsers(tenantID uint, query model.UserQuery) (model.UserPageDTO, error) {
+	return m.list(tenantID, query)
+}
+
+func (m *usersMock) GetUserDetails(tenantID, userID uint) (model.AdminUserDTO, error) {
+	return m.get(tenantID, userID)
+}
+
+func (m *usersMock) UpdateUser(tenantID, userID uint, dto model.UpdateUserDTO) (model.AdminUserDTO, error) {
+	return m.update(tenantID, userID, dto)
+}
+
+func (m *usersMock) DisableUser(tenantID, userID uint) error {
+	return m.disable(tenantID, userID)
+}
+
+func (m *usersMock) EnableUser(tenantID, userID uint) error {
+	return m.enable(tenantID, userID)
+}
+
+func (m *usersMock) DeleteUser(tenantID, userID uint, hard bool) error {
+	return m.delete(tenantID, userID, hard)
+}
+
+func (m *usersMock) SearchUsers(tenantID uint, dto model.UserSearchDTO) ([]model.UserMatchDTO, error) {
+	return m.search(tenantID, dto)
+}
+
+func Test_Users_ListQuery(t *testing.T) {
+	var got model.UserQuery
+	registry := &usersMock{
+		list: func(tenantID uint, query model.UserQuery) (model.UserPageDTO, error) {
+			got = query
+			return model.UserPageDTO{}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(users.NewUsersHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/admin/users?limit=10&status=disabled&domain=test.com&created_after=2024-01-02", nil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	expected := model.UserQuery{Limit: 10, Status: "disabled", Domain: "test.com", CreatedAfter: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	if got != expected {
+		t.Fatalf("query does not match, expected: %+v, got: %+v", expected, got)
+	}
+
+	request, _ = http.NewRequest(http.MethodGet, "/api/admin/users?created_before=yesterday", nil)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if http.StatusBadRequest != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusBadRequest, response.Code)
+	}
+}
+
+func Test_User_Paths(t *testing.T) {
+	var hardDelete bool
+	registry := &usersMock{
+		get: func(tenantID, userID uint) (model.AdminUserDTO, error) {
+			if userID != 5 {
+				return model.AdminUserDTO{}, core.ErrUserNotFound
+			}
+			return model.AdminUserDTO{ID: 5}, nil
+		},
+		disable: func(tenantID, userID uint) error {
+			return nil
+		},
+		delete: func(tenantID, userID uint, hard bool) error {
+			hardDelete = hard
+			return nil
+		},
+	}
+	handler := middleware.SetContextRequestID(users.NewUserHandler(zap.NewNop().Sugar(), registry))
+
+	cases := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{http.MethodGet, "/api/admin/users/5", http.StatusOK},
+		{http.MethodGet, "/api/admin/users/6", http.StatusNotFound},
+		{http.MethodGet, "/api/admin/users/abc", http.StatusNotFound},
+		{http.MethodPost, "/api/admin/users/5/disable", http.StatusOK},
+		{http.MethodGet, "/api/admin/users/5/disable", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/admin/users/5/archive", http.StatusNotFound},
+		{http.MethodPost, "/api/admin/users/5", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/api/admin/users/5?hard=true", http.StatusOK},
+	}
+	for _, c := range cases {
+		request, _ := http.NewRequest(c.method, c.path, strings.NewReader(""))
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if c.expected != response.Code {
+			t.Errorf("%s %s: response code does not match, expected: %d, got: %d", c.method, c.path, c.expected, response.Code)
+		}
+	}
+	if !hardDelete {
+		t.Fatal("expected a hard delete")
+	}
+}
+
+func Test_Users_Search(t *testing.T) {
+	var got model.UserSearchDTO
+	registry := &usersMock{
+		search: func(tenantID uint, dto model.UserSearchDTO) ([]model.UserMatchDTO, error) {
+			got = dto
+			if len(dto.Query) < 2 {
+				return nil, fmt.Errorf("validate struct: %w", validator.ValidationErrors{})
+			}
+			return []model.UserMatchDTO{{AdminUserDTO: model.AdminUserDTO{ID: 2}, Score: 0.5}}, nil
+		},
+	}
+	handler := middleware.SetContextRequestID(users.NewSearchHandler(zap.NewNop().Sugar(), registry))
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/admin/users/search?q=jon+smiht&limit=5", nil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+	if expected := (model.UserSearchDTO{Query: "jon smiht", Limit: 5}); got != expected {
+		t.Fatalf("search does not match, expected: %+v, got: %+v", expected, got)
+	}
+	if !strings.Contains(response.Body.String(), `"score":0.5`) {
+		t.Fatalf("expected the score in the response, got: %s", response.Body.String())
+	}
+
+	cases := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{http.MethodGet, "/api/admin/users/search?q=j", http.StatusBadRequest},
+		{http.MethodGet, "/api/admin/users/search?q=john&limit=many", http.StatusBadRequest},
+		{http.MethodPost, "/api/admin/users/search?q=john", http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		request, _ := http.NewRequest(c.method, c.path, nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		if c.expected != response.Code {
+			t.Errorf("%s %s: response code does not match, expected: %d, got: %d", c.method, c.path, c.expected, response.Code)
+		}
+	}
+}
@@ -1,5 +0,0 @@
-package verify
-
-type Registry interface {
-	VerifyUser(jwtToken string) (map[string]any, error)
-}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/verify"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
 	"go.uber.org/zap"
 )
@@ -24,7 +25,7 @@ func (v *verifyMock) VerifyUser(jwtToken string) (map[string]any, error) {
 }
 
 func Test_ServeHTTP_PostMethod_InvalidMethod(t *testing.T) {
-	reg := verify.NewVerifyHandler(zap.NewNop().Sugar(), &verifyMock{})
+	reg := verify.NewVerifyHandler(zap.NewNop().Sugar())
 	loginHandler := middleware.SetContextRequestID(reg)
 
 	testMethod := http.MethodPost
@@ -63,8 +64,8 @@ func Test_ServeHTTP_Success(t *testing.T) {
 			}, nil
 		},
 	}
-	reg := verify.NewVerifyHandler(zap.NewNop().Sugar(), registry)
-	verifyHandler := middleware.SetContextRequestID(reg)
+	reg := verify.NewVerifyHandler(zap.NewNop().Sugar())
+	verifyHandler := middleware.SetContextRequestID(middleware.Authenticate(zap.NewNop().Sugar(), registry, reg))
 
 	request, _ := http.NewRequest(http.MethodGet, "/api/register", nil)
 	response := httptest.NewRecorder()
@@ -101,3 +102,63 @@ func Test_ServeHTTP_Success(t *testing.T) {
 		t.Fatalf("response code does not match, expected: %d, got: %d", expectedCode, response.Code)
 	}
 }
+
+func Test_ServeHTTP_BearerToken(t *testing.T) {
+	registry := &verifyMock{
+		verifyUser: func(jwtToken string) (map[string]any, error) {
+			if jwtToken != "fake_jwt_token" {
+				return nil, fmt.Errorf("%w: unexpected token", jwt.ErrTokenNotValid)
+			}
+			return map[string]any{"email": "test@test.com"}, nil
+		},
+	}
+	reg := verify.NewVerifyHandler(zap.NewNop().Sugar())
+	verifyHandler := middleware.SetContextRequestID(middleware.Authenticate(zap.NewNop().Sugar(), registry, reg))
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/verify", nil)
+	request.Header.Set("Authorization", "Bearer fake_jwt_token")
+	request.AddCookie(&http.Cookie{Name: "Authentication", Value: "stale_cookie_token"})
+	response := httptest.NewRecorder()
+
+	verifyHandler.ServeHTTP(response, request)
+
+	if http.StatusOK != response.Code {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusOK, response.Code)
+	}
+}
+
+func Test_ServeHTTP_Unauthenticated(t *testing.T) {
+	registry := &verifyMock{
+		verifyUser: func(jwtToken string) (map[string]any, error) {
+			return nil, fmt.Errorf("%w: expired", jwt.ErrTokenNotValid)
+		},
+	}
+	reg := verify.NewVerifyHandler(zap.NewNop().Sugar())
+	verifyHandler := middleware.SetContextRequestID(middleware.Authenticate(zap.NewNop().Sugar(), registry, reg))
+
+	cases := []struct {
+		name   string
+		header string
+	}{
+		{"missing token", ""},
+		{"invalid token", "Bearer expired_jwt_token"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, "/api/verify", nil)
+			if c.header != "" {
+				request.Header.Set("Authorization", c.header)
+			}
+			response := httptest.NewRecorder()
+
+			verifyHandler.ServeHTTP(response, request)
+
+			if http.StatusUnauthorized != response.Code {
+				t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusUnauthorized, response.Code)
+			}
+			if response.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("expected WWW-Authenticate header")
+			}
+		})
+	}
+}
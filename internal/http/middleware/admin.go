@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+)
+
+// RequireAdminKey only lets requests through which carry the given key in
+// the "X-Admin-Key" header. An empty key disables the wrapped handler.
+func RequireAdminKey(key string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get("X-Admin-Key")
+		if key == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+			w.Header().Set("Content-Type", "application/json")
+			_ = common.WriteResponse(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdminKeyOr lets requests carrying the admin key in the "X-Admin-Key"
+// header through to handler and passes all others to fallback, usually the
+// handler wrapped with Authenticate and RequirePermission
+func RequireAdminKeyOr(key string, handler, fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get("X-Admin-Key")
+		if key != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(key)) == 1 {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		fallback.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+// TokenVerifier validates access tokens and returns their claims
+type TokenVerifier interface {
+	VerifyUser(jwtToken string) (map[string]any, error)
+}
+
+// Authenticate only lets requests through which carry a valid access token,
+// either as a bearer token or in the "Authentication" cookie. The token claims
+// are stored in the request context under model.CurrentUser. Tokens are only
+// accepted for the tenant they were issued for, which is stored under
+// model.Tenant.
+func Authenticate(logger *zap.SugaredLogger, verifier TokenVerifier, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID, _ := r.Context().Value(model.RequestID).(string)
+
+		token, ok := common.AuthToken(r)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			if err := common.WriteResponse(w, "missing authentication token", http.StatusUnauthorized); err != nil {
+				logger.Errorw(
+					"write response failed (missing token)",
+					"error", err,
+					"request_id", requestID,
+				)
+			}
+			return
+		}
+
+		claims, err := verifier.VerifyUser(token)
+		if err != nil {
+			logger.Warnw(
+				"failed authenticating request",
+				"error", err,
+				"request_id", requestID,
+			)
+			msg := "something went wrong on our end"
+			status := http.StatusInternalServerError
+			if errors.Is(err, jwt.ErrTokenNotValid) || errors.Is(err, core.ErrTokenRevoked) || errors.Is(err, core.ErrTokenUse) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				msg = "invalid authentication token"
+				status = http.StatusUnauthorized
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := common.WriteResponse(w, msg, status); err != nil {
+				logger.Errorw(
+					"write response failed (invalid token)",
+					"error", err,
+					"request_id", requestID,
+				)
+			}
+			return
+		}
+
+		tenantID := common.TokenTenant(claims)
+		if requested, ok := r.Context().Value(model.Tenant).(uint); ok && requested != tenantID {
+			logger.Warnw(
+				"token used for another tenant",
+				"tenant", requested,
+				"request_id", requestID,
+			)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			if err := common.WriteResponse(w, "invalid authentication token", http.StatusUnauthorized); err != nil {
+				logger.Errorw(
+					"write response failed (wrong tenant)",
+					"error", err,
+					"request_id", requestID,
+				)
+			}
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), model.CurrentUser, claims)
+		ctx = context.WithValue(ctx, model.Tenant, tenantID)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+// RequirePermission only lets requests through whose access token belongs
+// to a user and grants the permission in its "permissions" claim. The
+// "scope" claim is not consulted, client credentials tokens carry it too.
+// It has to wrap handlers inside Authenticate, which stores the claims.
+func RequirePermission(logger *zap.SugaredLogger, permission string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := r.Context().Value(model.CurrentUser).(map[string]any)
+		_, isUser := claims["uid"]
+		perms, _ := claims["permissions"].(string)
+		for _, granted := range strings.Fields(perms) {
+			if isUser && granted == permission {
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		requestID, _ := r.Context().Value(model.RequestID).(string)
+		logger.Warnw(
+			"permission denied",
+			"permission", permission,
+			"request_id", requestID,
+		)
+		w.Header().Set("Content-Type", "application/json")
+		if err := common.WriteResponse(w, "forbidden", http.StatusForbidden); err != nil {
+			logger.Errorw(
+				"write response failed (permission denied)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+	})
+}
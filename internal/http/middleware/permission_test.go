@@ -0,0 +1,45 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+func Test_RequirePermission(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := middleware.SetContextRequestID(
+		middleware.RequirePermission(zap.NewNop().Sugar(), "users:read", ok),
+	)
+
+	cases := map[string]struct {
+		claims   map[string]any
+		expected int
+	}{
+		"granted":            {map[string]any{"uid": float64(1), "permissions": "roles:manage users:read"}, http.StatusNoContent},
+		"other permission":   {map[string]any{"uid": float64(1), "permissions": "users:readonly"}, http.StatusForbidden},
+		"no permissions":     {map[string]any{"uid": float64(1)}, http.StatusForbidden},
+		"scope only":         {map[string]any{"uid": float64(1), "scope": "users:read"}, http.StatusForbidden},
+		"client token":       {map[string]any{"client_id": "job", "scope": "users:read"}, http.StatusForbidden},
+		"permissions no uid": {map[string]any{"client_id": "job", "permissions": "users:read"}, http.StatusForbidden},
+		"no claims set":      {nil, http.StatusForbidden},
+	}
+	for name, c := range cases {
+		request, _ := http.NewRequest(http.MethodGet, "/api/admin/users", nil)
+		if c.claims != nil {
+			request = request.WithContext(context.WithValue(request.Context(), model.CurrentUser, c.claims))
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if response.Code != c.expected {
+			t.Errorf("%s: response code does not match, expected: %d, got: %d", name, c.expected, response.Code)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ForwardedFor replaces the remote address of the request with the client
+// address the proxy in front of the service appended to "X-Forwarded-For".
+// Only use it when every request passes through such a proxy, otherwise
+// clients can pick their own address.
+func ForwardedFor(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if ip := net.ParseIP(strings.TrimSpace(hops[len(hops)-1])); ip != nil {
+			r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/throttle"
+	"go.uber.org/zap"
+)
+
+// RateStore keeps the token buckets of rate limited clients
+type RateStore interface {
+	TakeRateToken(key string, limit throttle.Limit, now time.Time) (throttle.Result, error)
+}
+
+// RateKey names the client a request is counted against
+type RateKey func(r *http.Request) string
+
+// ByIP counts requests per client address
+func ByIP(r *http.Request) string {
+	return "ip:" + common.ClientIP(r)
+}
+
+// ByUser counts requests per authenticated user and has to wrap handlers
+// inside Authenticate, see AuthRateLimit. Requests without a user are counted
+// per address.
+func ByUser(r *http.Request) string {
+	if userID, ok := common.CurrentUserID(r); ok {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return ByIP(r)
+}
+
+// ByAPIKey counts requests per key sent in the given header. Only a hash of
+// the key is stored. Requests without a key are counted per address.
+func ByAPIKey(header string) RateKey {
+	return func(r *http.Request) string {
+		key := r.Header.Get(header)
+		if key == "" {
+			return ByIP(r)
+		}
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:])
+	}
+}
+
+// RateRule limits the requests to a route
+type RateRule struct {
+	// Name separates the buckets of different routes
+	Name  string
+	Limit throttle.Limit
+	Key   RateKey
+	// PerUser is set when Key is ByUser
+	PerUser bool
+}
+
+// RateLimit takes a token from the client's bucket for every request and
+// refuses requests with "429 Too Many Requests" once the bucket is empty.
+// Responses carry the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers. Requests are let through when the store fails.
+func RateLimit(logger *zap.SugaredLogger, store RateStore, rule RateRule, handler http.Handler) http.Handler {
+	if !rule.Limit.Enabled() {
+		return handler
+	}
+	policy := fmt.Sprintf("%d;w=%d", rule.Limit.Burst, int(float64(rule.Limit.Burst)/rule.Limit.Rate))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID, _ := r.Context().Value(model.RequestID).(string)
+
+		res, err := store.TakeRateToken(rule.Name+":"+rule.Key(r), rule.Limit, time.Now())
+		if err != nil {
+			logger.Errorw(
+				"rate limit check failed",
+				"error", err,
+				"rule", rule.Name,
+				"request_id", requestID,
+			)
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", policy)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(int((res.Reset+time.Second-1)/time.Second)))
+		if !res.Allowed {
+			common.SetRetryAfter(w, res.RetryAfter)
+			w.Header().Set("Content-Type", "application/json")
+			if err := common.WriteResponse(w, "too many requests, try again later", http.StatusTooManyRequests); err != nil {
+				logger.Errorw(
+					"write response failed (rate limited)",
+					"error", err,
+					"request_id", requestID,
+				)
+			}
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// AuthRateLimit lets requests to a route behind Authenticate through. The
+// rule counts every request per address before the token is checked, so
+// floods without a valid token never reach the token validation. Rules per
+// user count the request once more per user after it.
+func AuthRateLimit(logger *zap.SugaredLogger, store RateStore, rule RateRule, verifier TokenVerifier, handler http.Handler) http.Handler {
+	if rule.PerUser {
+		handler = RateLimit(logger, store, rule, handler)
+		rule.Key = ByIP
+	}
+	return RateLimit(logger, store, rule, Authenticate(logger, verifier, handler))
+}
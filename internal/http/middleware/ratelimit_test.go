@@ -0,0 +1,135 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/throttle"
+	"go.uber.org/zap"
+)
+
+func Test_RateLimit(t *testing.T) {
+	rule := middleware.RateRule{
+		Name:  "/api/register",
+		Limit: throttle.PerMinute(1, 2),
+		Key:   middleware.ByIP,
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := middleware.SetContextRequestID(
+		middleware.RateLimit(zap.NewNop().Sugar(), throttle.NewMemoryStore(), rule, ok),
+	)
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		request, _ := http.NewRequest(http.MethodPost, "/api/register", nil)
+		request.RemoteAddr = remoteAddr
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		response := send("192.0.2.1:1000")
+		if response.Code != http.StatusCreated {
+			t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusCreated, response.Code)
+		}
+		if got := response.Header().Get("RateLimit-Remaining"); got != remaining {
+			t.Fatalf("remaining does not match, expected: %s, got: %s", remaining, got)
+		}
+	}
+
+	response := send("192.0.2.1:2000")
+	if response.Code != http.StatusTooManyRequests {
+		t.Fatalf("response code does not match, expected: %d, got: %d", http.StatusTooManyRequests, response.Code)
+	}
+	headers := map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "120",
+		"RateLimit-Policy":    "2;w=120",
+		"Retry-After":         "60",
+	}
+	for name, expected := range headers {
+		if got := response.Header().Get(name); got != expected {
+			t.Errorf("%s does not match, expected: %s, got: %s", name, expected, got)
+		}
+	}
+
+	if response := send("192.0.2.2:1000"); response.Code != http.StatusCreated {
+		t.Fatalf("other client was limited: %d", response.Code)
+	}
+}
+
+func Test_ByUser(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "/api/me", nil)
+	request.RemoteAddr = "192.0.2.1:1000"
+	if got := middleware.ByUser(request); got != "ip:192.0.2.1" {
+		t.Fatalf("expected anonymous request to be keyed on ip, got: %s", got)
+	}
+
+	claims := map[string]any{"uid": float64(7)}
+	request = request.WithContext(context.WithValue(request.Context(), model.CurrentUser, claims))
+	if got := middleware.ByUser(request); got != "user:7" {
+		t.Fatalf("expected request to be keyed on user, got: %s", got)
+	}
+}
+
+type verifierFunc func(jwtToken string) (map[string]any, error)
+
+func (f verifierFunc) VerifyUser(jwtToken string) (map[string]any, error) {
+	return f(jwtToken)
+}
+
+func Test_AuthRateLimit(t *testing.T) {
+	rule := middleware.RateRule{
+		Name:    "/api/me/password",
+		Limit:   throttle.PerMinute(1, 2),
+		Key:     middleware.ByUser,
+		PerUser: true,
+	}
+	verified := 0
+	verifier := verifierFunc(func(jwtToken string) (map[string]any, error) {
+		verified++
+		if jwtToken != "valid" {
+			return nil, jwt.ErrTokenNotValid
+		}
+		return map[string]any{"uid": float64(7)}, nil
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := middleware.SetContextRequestID(
+		middleware.AuthRateLimit(zap.NewNop().Sugar(), throttle.NewMemoryStore(), rule, verifier, ok),
+	)
+	send := func(remoteAddr, token string) int {
+		request, _ := http.NewRequest(http.MethodPost, "/api/me/password", nil)
+		request.RemoteAddr = remoteAddr
+		request.Header.Set("Authorization", "Bearer "+token)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response.Code
+	}
+
+	for _, expected := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if got := send("192.0.2.1:1000", "forged"); got != expected {
+			t.Fatalf("response code does not match, expected: %d, got: %d", expected, got)
+		}
+	}
+	if verified != 2 {
+		t.Fatalf("expected limited requests to skip the token check, verified %d tokens", verified)
+	}
+
+	for _, expected := range []int{http.StatusNoContent, http.StatusNoContent} {
+		if got := send("192.0.2.2:1000", "valid"); got != expected {
+			t.Fatalf("response code does not match, expected: %d, got: %d", expected, got)
+		}
+	}
+	if got := send("192.0.2.3:1000", "valid"); got != http.StatusTooManyRequests {
+		t.Fatalf("expected the user to be limited from another address, got: %d", got)
+	}
+}
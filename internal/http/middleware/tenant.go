@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/common"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+// TenantResolver maps organization slugs to tenant IDs
+type TenantResolver interface {
+	ResolveTenant(slug string) (uint, error)
+}
+
+// ResolveTenant stores the tenant named by the "X-Tenant" header in the
+// request context under model.Tenant. Requests without the header are
+// served for the default tenant, or the tenant of their access token.
+func ResolveTenant(logger *zap.SugaredLogger, resolver TenantResolver, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slug := r.Header.Get("X-Tenant")
+		if slug == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		tenantID, err := resolver.ResolveTenant(slug)
+		if err != nil {
+			requestID, _ := r.Context().Value(model.RequestID).(string)
+			msg := "something went wrong on our end"
+			status := http.StatusInternalServerError
+			if errors.Is(err, core.ErrUnknownTenant) {
+				msg = "unknown tenant"
+				status = http.StatusNotFound
+			} else {
+				logger.Errorw(
+					"resolve tenant failed",
+					"error", err,
+					"request_id", requestID,
+				)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := common.WriteResponse(w, msg, status); err != nil {
+				logger.Errorw(
+					"write response failed (resolve tenant)",
+					"error", err,
+					"request_id", requestID,
+				)
+			}
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), model.Tenant, tenantID)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireDefaultTenant only lets requests through whose access token was
+// issued in the default tenant. It has to wrap handlers inside Authenticate.
+func RequireDefaultTenant(logger *zap.SugaredLogger, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if common.CurrentTenant(r) == 0 {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		requestID, _ := r.Context().Value(model.RequestID).(string)
+		w.Header().Set("Content-Type", "application/json")
+		if err := common.WriteResponse(w, "forbidden", http.StatusForbidden); err != nil {
+			logger.Errorw(
+				"write response failed (tenant not allowed)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+	})
+}
+
+// RequireOrgRole only lets requests through whose access token holds one of
+// the roles in its "org_role" claim. It has to wrap handlers inside
+// Authenticate.
+func RequireOrgRole(logger *zap.SugaredLogger, roles []string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := r.Context().Value(model.CurrentUser).(map[string]any)
+		role, _ := claims["org_role"].(string)
+		for _, allowed := range roles {
+			if role != "" && role == allowed {
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		requestID, _ := r.Context().Value(model.RequestID).(string)
+		logger.Warnw(
+			"organization role denied",
+			"org_role", role,
+			"request_id", requestID,
+		)
+		w.Header().Set("Content-Type", "application/json")
+		if err := common.WriteResponse(w, "forbidden", http.StatusForbidden); err != nil {
+			logger.Errorw(
+				"write response failed (organization role denied)",
+				"error", err,
+				"request_id", requestID,
+			)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"go.uber.org/zap"
+)
+
+type tenantsMock map[string]uint
+
+func (m tenantsMock) ResolveTenant(slug string) (uint, error) {
+	id, ok := m[slug]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", core.ErrUnknownTenant, slug)
+	}
+	return id, nil
+}
+
+type verifierMock map[string]any
+
+func (m verifierMock) VerifyUser(jwtToken string) (map[string]any, error) {
+	return m, nil
+}
+
+func Test_ResolveTenant(t *testing.T) {
+	var served uint
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served, _ = r.Context().Value(model.Tenant).(uint)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	logger := zap.NewNop().Sugar()
+	claims := verifierMock{"uid": float64(1), "tenant": float64(3)}
+	handler := middleware.ResolveTenant(logger, tenantsMock{"acme": 3, "other": 4}, middleware.Authenticate(logger, claims, ok))
+
+	cases := map[string]struct {
+		tenant   string
+		expected int
+	}{
+		"token tenant":   {"", http.StatusNoContent},
+		"same tenant":    {"acme", http.StatusNoContent},
+		"other tenant":   {"other", http.StatusUnauthorized},
+		"unknown tenant": {"missing", http.StatusNotFound},
+	}
+	for name, c := range cases {
+		served = 0
+		request, _ := http.NewRequest(http.MethodGet, "/api/me", nil)
+		request.Header.Set("Authorization", "Bearer token")
+		request.Header.Set("X-Tenant", c.tenant)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if response.Code != c.expected {
+			t.Errorf("%s: response code does not match, expected: %d, got: %d", name, c.expected, response.Code)
+		}
+		if c.expected == http.StatusNoContent && served != 3 {
+			t.Errorf("%s: expected tenant 3, got %d", name, served)
+		}
+	}
+}
+
+func Test_RequireDefaultTenant(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := middleware.RequireDefaultTenant(zap.NewNop().Sugar(), ok)
+
+	for tenantID, expected := range map[uint]int{0: http.StatusNoContent, 3: http.StatusForbidden} {
+		request, _ := http.NewRequest(http.MethodGet, "/api/admin/roles", nil)
+		request = request.WithContext(context.WithValue(request.Context(), model.Tenant, tenantID))
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if response.Code != expected {
+			t.Errorf("tenant %d: response code does not match, expected: %d, got: %d", tenantID, expected, response.Code)
+		}
+	}
+}
+
+func Test_RequireOrgRole(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := middleware.RequireOrgRole(zap.NewNop().Sugar(), []string{"owner", "admin"}, ok)
+
+	cases := map[string]struct {
+		claims   map[string]any
+		expected int
+	}{
+		"owner":   {map[string]any{"org_role": "owner"}, http.StatusNoContent},
+		"member":  {map[string]any{"org_role": "member"}, http.StatusForbidden},
+		"no role": {map[string]any{"uid": float64(1)}, http.StatusForbidden},
+	}
+	for name, c := range cases {
+		request, _ := http.NewRequest(http.MethodGet, "/api/org/members", nil)
+		request = request.WithContext(context.WithValue(request.Context(), model.CurrentUser, c.claims))
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if response.Code != c.expected {
+			t.Errorf("%s: response code does not match, expected: %d, got: %d", name, c.expected, response.Code)
+		}
+	}
+}
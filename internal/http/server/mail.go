@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/mail"
+)
+
+// newMailer builds the mailer selected by MAIL_BACKEND: "smtp", "file"
+// (an outbox directory of .eml files) or "stdout", the default.
+func newMailer() (core.Mailer, error) {
+	var templates fs.FS = mail.DefaultTemplates()
+	if dir := os.Getenv("MAIL_TEMPLATES_DIR"); dir != "" {
+		templates = os.DirFS(dir)
+	}
+	locale := os.Getenv("MAIL_DEFAULT_LOCALE")
+	if locale == "" {
+		locale = "en"
+	}
+	tmpl, err := mail.NewTemplates(templates, locale)
+	if err != nil {
+		return nil, fmt.Errorf("load mail templates: %w", err)
+	}
+
+	from := os.Getenv("MAIL_FROM")
+	switch backend := os.Getenv("MAIL_BACKEND"); backend {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, tmpl), nil
+	case "file":
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create outbox dir: %w", err)
+		}
+		return mail.NewFileMailer(dir, from, tmpl), nil
+	case "", "stdout":
+		return mail.NewWriterMailer(os.Stdout, from, tmpl), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", backend)
+	}
+}
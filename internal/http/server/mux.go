@@ -1,29 +1,118 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/dgdraganov/fuzzy-user-api/internal/core"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/clients"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/email"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/invitations"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/jwks"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/keys"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/login"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/logout"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/me"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/members"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/oidc"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/organizations"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/password"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/refresh"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/register"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/roles"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/sessions"
+	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/users"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/handler/verify"
 	"github.com/dgdraganov/fuzzy-user-api/internal/http/middleware"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/hibp"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/log"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/queue"
 	"github.com/dgdraganov/fuzzy-user-api/pkg/storage/pg"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/throttle"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 type httpServer struct {
-	mux      *http.ServeMux
-	register http.Handler
-	login    http.Handler
-	verify   http.Handler
-	logs     *zap.SugaredLogger
+	mux                *http.ServeMux
+	register           http.Handler
+	login              http.Handler
+	verify             http.Handler
+	refresh            http.Handler
+	logout             http.Handler
+	jwks               http.Handler
+	keys               http.Handler
+	discovery          http.Handler
+	authorize          http.Handler
+	token              http.Handler
+	userInfo           http.Handler
+	clients            http.Handler
+	introspect         http.Handler
+	revoke             http.Handler
+	verifyEmail        http.Handler
+	resendVerification http.Handler
+	forgotPassword     http.Handler
+	resetPassword      http.Handler
+	changePassword     http.Handler
+	changeEmail        http.Handler
+	confirmEmailChange http.Handler
+	mfaLogin           http.Handler
+	enrollTOTP         http.Handler
+	confirmTOTP        http.Handler
+	passkeyOptions     http.Handler
+	registerPasskey    http.Handler
+	passkeyLoginOpts   http.Handler
+	passkeyLogin       http.Handler
+	magicLink          http.Handler
+	magicLinkCallback  http.Handler
+	sessions           http.Handler
+	roles              http.Handler
+	userRoles          http.Handler
+	organizations      http.Handler
+	invitations        http.Handler
+	revokeInvitation   http.Handler
+	resendInvitation   http.Handler
+	acceptInvitation   http.Handler
+	members            http.Handler
+	users              http.Handler
+	user               http.Handler
+	searchUsers        http.Handler
+	verifier           middleware.TokenVerifier
+	tenants            middleware.TenantResolver
+	purger             TokenPurger
+	keyRing            *jwt.KeyRing
+	jobs               *queue.Queue
+	adminKey           string
+	trustProxy         bool
+	logs               *zap.SugaredLogger
+}
+
+const (
+	// backgroundWorkers and backgroundQueueSize bound the work done after
+	// replying, like sending password reset mails
+	backgroundWorkers   = 4
+	backgroundQueueSize = 256
+
+	// shutdownTimeout is how long in-flight requests and queued background
+	// work get to finish on shutdown
+	shutdownTimeout = 30 * time.Second
+)
+
+// TokenPurger removes expired token records from storage
+type TokenPurger interface {
+	PurgeExpiredTokens() error
 }
 
 func NewHTTPServer() *httpServer {
@@ -50,29 +139,524 @@ func NewHTTPServer() *httpServer {
 		"db_host", os.Getenv("DB_HOST"),
 	)
 
-	if err := db.Migrate(&model.User{}); err != nil {
+	if err := db.MigrateEmailVerification(); err != nil {
+		panic(fmt.Sprintf("email verification migration failed: %s", err))
+	}
+
+	if err := db.Migrate(
+		&model.User{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.AuthorizationCode{},
+		&model.OAuthClient{},
+		&model.PasswordResetToken{},
+		&model.PasswordHistory{},
+		&model.RecoveryCode{},
+		&model.WebAuthnCredential{},
+		&model.LoginFailure{},
+		&model.RateBucket{},
+		&model.Permission{},
+		&model.Role{},
+		&model.Organization{},
+		&model.Membership{},
+		&model.Invitation{},
+	); err != nil {
 		panic("database migration failed")
 	}
 
+	if err := db.MigrateTenants(); err != nil {
+		panic(fmt.Sprintf("tenant migration failed: %s", err))
+	}
+
+	// without pg_trgm users are searched in memory
+	var userSearch core.UserSearcher
+	if err := db.MigrateSearch(); err != nil {
+		logger.Warnw(
+			"user search indexes not created, searching in memory",
+			"error", err,
+		)
+	} else {
+		userSearch = db
+	}
+
 	logger.Infow(
 		"migrated db models",
 		"db_host", os.Getenv("DB_HOST"),
 	)
 
-	tokenGenerator := jwt.NewJwtGenerator([]byte(os.Getenv("JWT_SECRET")))
-	fuzz := core.NewFuzzy(db, tokenGenerator)
+	keyRing, err := newKeyRing()
+	if err != nil {
+		panic(fmt.Sprintf("loading jwt signing keys failed: %s", err))
+	}
+	tokenGenerator := jwt.NewJwtGeneratorWithKeyRing(keyRing)
+	issuer := strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/")
+	if issuer == "" {
+		issuer = fmt.Sprintf("http://localhost:%s", os.Getenv("APP_PORT"))
+	}
+
+	mailer, err := newMailer()
+	if err != nil {
+		panic(fmt.Sprintf("configuring mailer failed: %s", err))
+	}
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = issuer
+	}
+
+	relyingParty, err := relyingPartyFromEnv(publicURL)
+	if err != nil {
+		panic(fmt.Sprintf("configuring webauthn failed: %s", err))
+	}
+
+	var breached core.BreachedPasswords
+	if dir := os.Getenv("PASSWORD_BREACH_CORPUS_DIR"); dir != "" {
+		store, err := hibp.NewRangeStore(dir)
+		if err != nil {
+			panic(fmt.Sprintf("loading breached passwords corpus failed: %s", err))
+		}
+		breached = store
+	}
+
+	var throttleStore core.ThrottleStore = db
+	if os.Getenv("LOGIN_THROTTLE_STORE") == "memory" {
+		throttleStore = throttle.NewMemoryStore()
+	}
+
+	fuzz := core.NewFuzzy(
+		db,
+		tokenGenerator,
+		core.WithTokenTTL(
+			durationFromEnv("JWT_LOGIN_EXP", time.Minute),
+			durationFromEnv("JWT_REFRESH_EXP", time.Hour),
+		),
+		core.WithOIDC(issuer, oidcClientsFromEnv("OIDC_CLIENTS")),
+		core.WithClientScopes(strings.Fields(os.Getenv("OAUTH_CLIENT_SCOPES"))...),
+		core.WithPasswordHasher(core.NewArgon2Hasher(argon2ParamsFromEnv())),
+		core.WithMailer(mailer, publicURL),
+		core.WithEmailVerification(boolFromEnv("REQUIRE_EMAIL_VERIFICATION")),
+		core.WithPasswordReset(
+			durationFromEnv("PASSWORD_RESET_EXP", time.Minute),
+			os.Getenv("PASSWORD_RESET_URL"),
+		),
+		core.WithPasswordPolicy(passwordPolicyFromEnv(), breached),
+		core.WithTOTPIssuer(os.Getenv("TOTP_ISSUER")),
+		core.WithWebAuthn(relyingParty),
+		core.WithMagicLink(
+			durationFromEnv("MAGIC_LINK_EXP", time.Minute),
+			os.Getenv("MAGIC_LINK_URL"),
+		),
+		core.WithLoginThrottle(throttleStore, loginThrottleFromEnv()),
+		core.WithAccountHiding(boolFromEnv("HIDE_ACCOUNT_EXISTENCE")),
+		core.WithRoles(db),
+		core.WithInvitations(
+			durationFromEnv("INVITATION_EXP", time.Hour),
+			os.Getenv("INVITATION_URL"),
+		),
+		core.WithUserSearch(userSearch),
+		core.WithLogger(logger),
+	)
+
+	if err := fuzz.BootstrapRoles(os.Getenv("ADMIN_EMAIL")); err != nil {
+		panic(fmt.Sprintf("creating admin role failed: %s", err))
+	}
+
+	rules := rateRulesFromEnv()
+	limit := rateLimiter(logger, throttleStore, rules)
+	authLimit := authRateLimiter(logger, throttleStore, rules, fuzz)
 
-	regHandler := register.NewRegisterHandler(logger, fuzz)
-	loginHandler := login.NewLoginHandler(logger, fuzz)
-	verifyHandler := verify.NewVerifyHandler(logger, fuzz)
+	regHandler := limit("/api/register", register.NewRegisterHandler(logger, fuzz))
+	loginHandler := limit("/api/login", login.NewLoginHandler(logger, fuzz))
+	mfaLoginHandler := limit("/api/login/mfa", login.NewMFALoginHandler(logger, fuzz))
+	passkeyLoginOptsHandler := limit("/api/login/passkey/options", login.NewPasskeyOptionsHandler(logger, fuzz))
+	passkeyLoginHandler := limit("/api/login/passkey", login.NewPasskeyLoginHandler(logger, fuzz))
+	magicLinkHandler := limit("/api/login/magic", login.NewMagicLinkHandler(logger, fuzz))
+	magicLinkCallbackHandler := limit("/api/login/magic/callback", login.NewMagicLinkCallbackHandler(logger, fuzz))
+	verifyHandler := authLimit("/api/verify", verify.NewVerifyHandler(logger))
+	refreshHandler := limit("/api/token/refresh", refresh.NewRefreshHandler(logger, fuzz))
+	jwksHandler := limit("/.well-known/jwks.json", jwks.NewJwksHandler(logger, tokenGenerator))
+	keysHandler := limit("/api/admin/keys", keys.NewKeysHandler(logger, keyRing))
+	discoveryHandler := limit("/.well-known/openid-configuration", oidc.NewDiscoveryHandler(logger, issuer, keyRing))
+	authorizeHandler := limit("/authorize", oidc.NewAuthorizeHandler(logger, fuzz))
+	tokenHandler := limit("/token", oidc.NewTokenHandler(logger, fuzz))
+	userInfoHandler := limit("/userinfo", oidc.NewUserInfoHandler(logger, fuzz))
+	clientsHandler := limit("/api/admin/clients", clients.NewClientsHandler(logger, fuzz))
+	introspectHandler := limit("/oauth/introspect", oidc.NewIntrospectHandler(logger, fuzz))
+	revokeHandler := limit("/oauth/revoke", oidc.NewRevokeHandler(logger, fuzz))
+	verifyEmailHandler := limit("/api/verify-email", email.NewVerifyEmailHandler(logger, fuzz))
+	resendVerificationHandler := limit("/api/verify-email/resend", email.NewResendVerificationHandler(logger, fuzz))
+	jobs := queue.New(backgroundWorkers, backgroundQueueSize)
+	forgotPasswordHandler := limit("/api/password/forgot", password.NewForgotPasswordHandler(logger, fuzz, jobs))
+	resetPasswordHandler := limit("/api/password/reset", password.NewResetPasswordHandler(logger, fuzz))
+	changePasswordHandler := authLimit("/api/me/password", me.NewChangePasswordHandler(logger, fuzz))
+	changeEmailHandler := authLimit("/api/me/email", me.NewChangeEmailHandler(logger, fuzz))
+	confirmEmailChangeHandler := limit("/api/me/email/confirm", me.NewConfirmEmailChangeHandler(logger, fuzz))
+	enrollTOTPHandler := authLimit("/api/me/mfa/totp", me.NewEnrollTOTPHandler(logger, fuzz))
+	confirmTOTPHandler := authLimit("/api/me/mfa/totp/confirm", me.NewConfirmTOTPHandler(logger, fuzz))
+	passkeyOptionsHandler := authLimit("/api/me/passkeys/options", me.NewPasskeyOptionsHandler(logger, fuzz))
+	registerPasskeyHandler := authLimit("/api/me/passkeys", me.NewRegisterPasskeyHandler(logger, fuzz))
+	logoutHandler := limit("/api/logout", logout.NewLogoutHandler(logger, fuzz))
+	revokeSessionsHandler := limit("/api/admin/sessions/revoke", sessions.NewRevokeSessionsHandler(logger, fuzz))
+	rolesHandler := limit("/api/admin/roles", roles.NewRolesHandler(logger, fuzz))
+	userRolesHandler := limit("/api/admin/users/roles", roles.NewUserRolesHandler(logger, fuzz))
+	organizationsHandler := limit("/api/admin/organizations", organizations.NewOrganizationsHandler(logger, fuzz))
+	invitationsHandler := limit("/api/org/invitations", invitations.NewInvitationsHandler(logger, fuzz))
+	revokeInvitationHandler := limit("/api/org/invitations/revoke", invitations.NewRevokeInvitationHandler(logger, fuzz))
+	resendInvitationHandler := limit("/api/org/invitations/resend", invitations.NewResendInvitationHandler(logger, fuzz))
+	acceptInvitationHandler := limit("/api/invitations/accept", invitations.NewAcceptInvitationHandler(logger, fuzz))
+	membersHandler := limit("/api/org/members", members.NewMembersHandler(logger, fuzz))
+	usersHandler := limit("/api/admin/users", users.NewUsersHandler(logger, fuzz))
+	userHandler := limit(users.Prefix, users.NewUserHandler(logger, fuzz))
+	searchUsersHandler := limit("/api/admin/users/search", users.NewSearchHandler(logger, fuzz))
 
 	return &httpServer{
-		mux:      http.NewServeMux(),
-		register: regHandler,
-		login:    loginHandler,
-		verify:   verifyHandler,
-		logs:     logger,
+		mux:                http.NewServeMux(),
+		register:           regHandler,
+		login:              loginHandler,
+		verify:             verifyHandler,
+		refresh:            refreshHandler,
+		logout:             logoutHandler,
+		jwks:               jwksHandler,
+		keys:               keysHandler,
+		discovery:          discoveryHandler,
+		authorize:          authorizeHandler,
+		token:              tokenHandler,
+		userInfo:           userInfoHandler,
+		clients:            clientsHandler,
+		introspect:         introspectHandler,
+		revoke:             revokeHandler,
+		verifyEmail:        verifyEmailHandler,
+		resendVerification: resendVerificationHandler,
+		forgotPassword:     forgotPasswordHandler,
+		resetPassword:      resetPasswordHandler,
+		changePassword:     changePasswordHandler,
+		changeEmail:        changeEmailHandler,
+		confirmEmailChange: confirmEmailChangeHandler,
+		mfaLogin:           mfaLoginHandler,
+		enrollTOTP:         enrollTOTPHandler,
+		confirmTOTP:        confirmTOTPHandler,
+		passkeyOptions:     passkeyOptionsHandler,
+		registerPasskey:    registerPasskeyHandler,
+		passkeyLoginOpts:   passkeyLoginOptsHandler,
+		passkeyLogin:       passkeyLoginHandler,
+		magicLink:          magicLinkHandler,
+		magicLinkCallback:  magicLinkCallbackHandler,
+		sessions:           revokeSessionsHandler,
+		roles:              rolesHandler,
+		userRoles:          userRolesHandler,
+		organizations:      organizationsHandler,
+		invitations:        invitationsHandler,
+		revokeInvitation:   revokeInvitationHandler,
+		resendInvitation:   resendInvitationHandler,
+		acceptInvitation:   acceptInvitationHandler,
+		members:            membersHandler,
+		users:              usersHandler,
+		user:               userHandler,
+		searchUsers:        searchUsersHandler,
+		verifier:           fuzz,
+		tenants:            fuzz,
+		purger:             fuzz,
+		keyRing:            keyRing,
+		jobs:               jobs,
+		adminKey:           os.Getenv("ADMIN_API_KEY"),
+		trustProxy:         boolFromEnv("TRUST_PROXY_HEADERS"),
+		logs:               logger,
+	}
+}
+
+// newKeyRing signs tokens with the PEM key from JWT_SIGNING_KEY_FILE when one
+// is configured and with the JWT_SECRET shared secret otherwise. Keys found in
+// JWT_KEYS_DIR verify tokens and can be promoted at runtime. Promotions and
+// retirements are saved in JWT_KEYS_DIR and take precedence over the
+// configured signing key.
+func newKeyRing() (*jwt.KeyRing, error) {
+	retireAfter := durationFromEnv("JWT_KEY_RETIRE_AFTER", time.Hour)
+	if retireAfter == 0 {
+		retireAfter = 24 * time.Hour
+	}
+	secret := os.Getenv("JWT_SECRET")
+
+	var ring *jwt.KeyRing
+	keyFile := os.Getenv("JWT_SIGNING_KEY_FILE")
+	if keyFile == "" {
+		ring = jwt.NewKeyRing(jwt.NewHMACKey("", []byte(secret)), retireAfter)
+	} else {
+		pemData, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read key file: %w", err)
+		}
+		key, err := jwt.ParseSigningKey(os.Getenv("JWT_SIGNING_KEY_ID"), pemData)
+		if err != nil {
+			return nil, fmt.Errorf("parse signing key: %w", err)
+		}
+		ring = jwt.NewKeyRing(key, retireAfter)
+
+		// keep accepting tokens signed with the shared secret for a while
+		if secret != "" {
+			ring.Add(jwt.NewHMACKey("", []byte(secret)))
+		}
+	}
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		keys, err := jwt.LoadDirectory(dir)
+		if err != nil {
+			return nil, fmt.Errorf("load keys dir: %w", err)
+		}
+		active := ring.Keys()
+		for _, key := range keys {
+			if !isActive(active, key.ID) {
+				ring.Add(key)
+			}
+		}
+		ring.SetLoader(jwt.DirectoryLoader(dir))
+		if err := ring.SetStore(jwt.DirectoryStore(dir)); err != nil {
+			return nil, fmt.Errorf("load key ring state: %w", err)
+		}
+	}
+
+	// the shared secret is only retired once, a saved retirement is kept
+	for _, key := range ring.Keys() {
+		if key.ID == "" && !key.Active && key.RetireAt == nil {
+			if err := ring.Retire("", time.Now().Add(retireAfter)); err != nil {
+				return nil, fmt.Errorf("retire shared secret: %w", err)
+			}
+		}
+	}
+	return ring, nil
+}
+
+func isActive(keys []jwt.KeyStatus, kid string) bool {
+	for _, key := range keys {
+		if key.ID == kid {
+			return key.Active
+		}
+	}
+	return false
+}
+
+// oidcClientsFromEnv parses relying parties in the form
+// "client_id=redirect_uri[,redirect_uri...][;client_id=...]"
+func oidcClientsFromEnv(key string) []model.OIDCClient {
+	var res []model.OIDCClient
+	for _, entry := range strings.Split(os.Getenv(key), ";") {
+		id, uris, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || id == "" {
+			continue
+		}
+		client := model.OIDCClient{ID: id}
+		for _, uri := range strings.Split(uris, ",") {
+			if uri = strings.TrimSpace(uri); uri != "" {
+				client.RedirectURIs = append(client.RedirectURIs, uri)
+			}
+		}
+		res = append(res, client)
+	}
+	return res
+}
+
+// durationFromEnv reads a whole number of units from the given environment
+// variable. It returns zero when the variable is missing or malformed.
+func durationFromEnv(key string, unit time.Duration) time.Duration {
+	value, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil || value <= 0 {
+		return 0
+	}
+	return time.Duration(value) * unit
+}
+
+// argon2ParamsFromEnv overrides the default argon2id cost with ARGON2_MEMORY
+// (KiB), ARGON2_ITERATIONS and ARGON2_PARALLELISM. Stored hashes are
+// upgraded to new parameters as users log in.
+func argon2ParamsFromEnv() core.Argon2Params {
+	params := core.DefaultArgon2Params
+	if v, err := strconv.ParseUint(os.Getenv("ARGON2_MEMORY"), 10, 32); err == nil && v > 0 {
+		params.Memory = uint32(v)
+	}
+	if v, err := strconv.ParseUint(os.Getenv("ARGON2_ITERATIONS"), 10, 32); err == nil && v > 0 {
+		params.Iterations = uint32(v)
+	}
+	if v, err := strconv.ParseUint(os.Getenv("ARGON2_PARALLELISM"), 10, 8); err == nil && v > 0 {
+		params.Parallelism = uint8(v)
+	}
+	return params
+}
+
+// passwordPolicyFromEnv overrides the default password policy with
+// PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH, PASSWORD_MIN_CHAR_CLASSES,
+// PASSWORD_HISTORY and PASSWORD_ALLOW_PERSONAL_INFO
+func passwordPolicyFromEnv() core.PasswordPolicy {
+	policy := core.DefaultPasswordPolicy
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && v > 0 {
+		policy.MinLength = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_MAX_LENGTH")); err == nil && v > 0 {
+		policy.MaxLength = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_CHAR_CLASSES")); err == nil && v > 0 {
+		policy.MinCharClasses = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_HISTORY")); err == nil && v >= 0 {
+		policy.HistorySize = v
+	}
+	if boolFromEnv("PASSWORD_ALLOW_PERSONAL_INFO") {
+		policy.DisallowPersonalInfo = false
+	}
+	return policy
+}
+
+// loginThrottleFromEnv overrides the default login limits with
+// LOGIN_FREE_ATTEMPTS, LOGIN_LOCKOUT_MAX (minutes), LOGIN_IP_RATE (attempts
+// per minute) and LOGIN_IP_BURST
+func loginThrottleFromEnv() core.LoginThrottle {
+	limits := core.DefaultLoginThrottle
+	if v, err := strconv.Atoi(os.Getenv("LOGIN_FREE_ATTEMPTS")); err == nil && v >= 0 {
+		limits.FreeAttempts = v
+	}
+	if v := durationFromEnv("LOGIN_LOCKOUT_MAX", time.Minute); v > 0 {
+		limits.MaxDelay = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("LOGIN_IP_RATE")); err == nil && v > 0 {
+		limits.PerIP = throttle.PerMinute(v, limits.PerIP.Burst)
+	}
+	if v, err := strconv.Atoi(os.Getenv("LOGIN_IP_BURST")); err == nil && v > 0 {
+		limits.PerIP.Burst = v
+	}
+	return limits
+}
+
+// defaultRateRules slow down the endpoints which create accounts or send
+// mail
+var defaultRateRules = map[string]string{
+	"/api/register":            "5:10:ip",
+	"/api/login/magic":         "5:10:ip",
+	"/api/password/forgot":     "5:10:ip",
+	"/api/verify-email/resend": "5:10:ip",
+}
+
+// rateRulesFromEnv reads the per route limits from RATE_LIMITS, a semicolon
+// separated list of "route=requests_per_minute:burst:key" entries, where key
+// is "ip", "user" or "apikey". They are added to defaultRateRules, a rate of
+// 0 turns a default off. RATE_LIMIT_DEFAULT applies to all other routes.
+func rateRulesFromEnv() map[string]middleware.RateRule {
+	specs := map[string]string{}
+	for route, spec := range defaultRateRules {
+		specs[route] = spec
+	}
+	if spec := strings.TrimSpace(os.Getenv("RATE_LIMIT_DEFAULT")); spec != "" {
+		specs[""] = spec
+	}
+	for _, entry := range strings.Split(os.Getenv("RATE_LIMITS"), ";") {
+		route, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if ok {
+			specs[strings.TrimSpace(route)] = strings.TrimSpace(spec)
+		}
+	}
+
+	rules := map[string]middleware.RateRule{}
+	for route, spec := range specs {
+		rule, err := parseRateRule(route, spec)
+		if err != nil {
+			panic(fmt.Sprintf("invalid rate limit for %q: %s", route, err))
+		}
+		rules[route] = rule
+	}
+	return rules
+}
+
+func parseRateRule(route, spec string) (middleware.RateRule, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 {
+		return middleware.RateRule{}, fmt.Errorf("expected requests_per_minute:burst:key, got %q", spec)
+	}
+	rate, err := strconv.Atoi(parts[0])
+	if err != nil || rate < 0 {
+		return middleware.RateRule{}, fmt.Errorf("invalid rate %q", parts[0])
+	}
+	burst, err := strconv.Atoi(parts[1])
+	if err != nil || burst < 0 {
+		return middleware.RateRule{}, fmt.Errorf("invalid burst %q", parts[1])
+	}
+	rule := middleware.RateRule{Name: route, Limit: throttle.PerMinute(rate, burst)}
+	switch parts[2] {
+	case "ip":
+		rule.Key = middleware.ByIP
+	case "user":
+		rule.Key = middleware.ByUser
+		rule.PerUser = true
+	case "apikey":
+		rule.Key = middleware.ByAPIKey("X-Admin-Key")
+	default:
+		return middleware.RateRule{}, fmt.Errorf("unknown key %q", parts[2])
+	}
+	return rule, nil
+}
+
+// rateLimiter wraps the handler of a route with its rule from rules, or the
+// default rule stored under the empty route
+func rateLimiter(logger *zap.SugaredLogger, store middleware.RateStore, rules map[string]middleware.RateRule) func(string, http.Handler) http.Handler {
+	return func(route string, handler http.Handler) http.Handler {
+		rule, ok := rateRule(rules, route)
+		if !ok {
+			return handler
+		}
+		return middleware.RateLimit(logger, store, rule, handler)
+	}
+}
+
+// authRateLimiter is rateLimiter for routes which require an access token.
+// The limit is checked before the token.
+func authRateLimiter(logger *zap.SugaredLogger, store middleware.RateStore, rules map[string]middleware.RateRule, verifier middleware.TokenVerifier) func(string, http.Handler) http.Handler {
+	return func(route string, handler http.Handler) http.Handler {
+		rule, ok := rateRule(rules, route)
+		if !ok {
+			return middleware.Authenticate(logger, verifier, handler)
+		}
+		return middleware.AuthRateLimit(logger, store, rule, verifier, handler)
+	}
+}
+
+// rateRule returns the rule of the route or the default rule
+func rateRule(rules map[string]middleware.RateRule, route string) (middleware.RateRule, bool) {
+	rule, ok := rules[route]
+	if !ok {
+		rule, ok = rules[""]
+		rule.Name = route
+	}
+	return rule, ok
+}
+
+// relyingPartyFromEnv configures passkeys with WEBAUTHN_RP_ID, WEBAUTHN_RP_NAME
+// and the comma separated WEBAUTHN_ORIGINS. The host and the origin of the
+// public url are used when they are not set.
+func relyingPartyFromEnv(publicURL string) (core.RelyingParty, error) {
+	public, err := url.Parse(publicURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse public url: %w", err)
+	}
+	id := os.Getenv("WEBAUTHN_RP_ID")
+	if id == "" {
+		id = public.Hostname()
 	}
+	name := os.Getenv("WEBAUTHN_RP_NAME")
+	if name == "" {
+		name = "Fuzzy User API"
+	}
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{public.Scheme + "://" + public.Host}
+	}
+	return webauthn.NewRelyingParty(id, name, origins), nil
+}
+
+// boolFromEnv reports whether the given environment variable is set to a
+// true value such as "true" or "1"
+func boolFromEnv(key string) bool {
+	value, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	return err == nil && value
 }
 
 func (s *httpServer) RegisterHandlers() {
@@ -82,21 +666,256 @@ func (s *httpServer) RegisterHandlers() {
 	// [POST]
 	s.mux.Handle("/api/login", middleware.SetContextRequestID(s.login))
 
+	// [POST]
+	s.mux.Handle("/api/login/mfa", middleware.SetContextRequestID(s.mfaLogin))
+
+	// [POST]
+	s.mux.Handle("/api/login/passkey/options", middleware.SetContextRequestID(s.passkeyLoginOpts))
+
+	// [POST]
+	s.mux.Handle("/api/login/passkey", middleware.SetContextRequestID(s.passkeyLogin))
+
+	// [POST]
+	s.mux.Handle("/api/login/magic", middleware.SetContextRequestID(s.magicLink))
+
+	// [GET, POST]
+	s.mux.Handle("/api/login/magic/callback", middleware.SetContextRequestID(s.magicLinkCallback))
+
 	// [GET]
 	s.mux.Handle("/api/verify", middleware.SetContextRequestID(s.verify))
+
+	// [GET, POST]
+	s.mux.Handle("/api/verify-email", middleware.SetContextRequestID(s.verifyEmail))
+
+	// [POST]
+	s.mux.Handle("/api/verify-email/resend", middleware.SetContextRequestID(s.resendVerification))
+
+	// [POST]
+	s.mux.Handle("/api/password/forgot", middleware.SetContextRequestID(s.forgotPassword))
+
+	// [POST]
+	s.mux.Handle("/api/password/reset", middleware.SetContextRequestID(s.resetPassword))
+
+	// [POST]
+	s.mux.Handle("/api/me/password", middleware.SetContextRequestID(s.changePassword))
+
+	// [POST]
+	s.mux.Handle("/api/me/email", middleware.SetContextRequestID(s.changeEmail))
+
+	// [GET, POST]
+	s.mux.Handle("/api/me/email/confirm", middleware.SetContextRequestID(s.confirmEmailChange))
+
+	// [POST]
+	s.mux.Handle("/api/me/mfa/totp", middleware.SetContextRequestID(s.enrollTOTP))
+
+	// [POST]
+	s.mux.Handle("/api/me/mfa/totp/confirm", middleware.SetContextRequestID(s.confirmTOTP))
+
+	// [POST]
+	s.mux.Handle("/api/me/passkeys/options", middleware.SetContextRequestID(s.passkeyOptions))
+
+	// [POST]
+	s.mux.Handle("/api/me/passkeys", middleware.SetContextRequestID(s.registerPasskey))
+
+	// [POST]
+	s.mux.Handle("/api/token/refresh", middleware.SetContextRequestID(s.refresh))
+
+	// [POST]
+	s.mux.Handle("/api/logout", middleware.SetContextRequestID(s.logout))
+
+	// [GET]
+	s.mux.Handle("/.well-known/jwks.json", middleware.SetContextRequestID(s.jwks))
+
+	// [GET]
+	s.mux.Handle("/.well-known/openid-configuration", middleware.SetContextRequestID(s.discovery))
+
+	// [GET]
+	s.mux.Handle("/authorize", middleware.SetContextRequestID(s.authorize))
+
+	// [POST]
+	s.mux.Handle("/token", middleware.SetContextRequestID(s.token))
+	s.mux.Handle("/oauth/token", middleware.SetContextRequestID(s.token))
+
+	// [GET, POST]
+	s.mux.Handle("/userinfo", middleware.SetContextRequestID(s.userInfo))
+
+	// [POST]
+	s.mux.Handle("/oauth/introspect", middleware.SetContextRequestID(s.introspect))
+
+	// [POST]
+	s.mux.Handle("/oauth/revoke", middleware.SetContextRequestID(s.revoke))
+
+	// [POST]
+	s.mux.Handle("/api/admin/sessions/revoke", middleware.SetContextRequestID(
+		s.admin(core.PermissionSessionsRevoke, s.sessions),
+	))
+
+	// [POST]
+	s.mux.Handle("/api/admin/clients", middleware.SetContextRequestID(
+		s.admin(core.PermissionClientsManage, s.clients),
+	))
+
+	// [GET, POST]
+	s.mux.Handle("/api/admin/keys", middleware.SetContextRequestID(
+		s.admin(core.PermissionKeysManage, s.keys),
+	))
+
+	// [GET, POST]
+	s.mux.Handle("/api/admin/roles", middleware.SetContextRequestID(
+		s.admin(core.PermissionRolesManage, s.roles),
+	))
+
+	// [GET]
+	s.mux.Handle("/api/admin/users", middleware.SetContextRequestID(
+		s.admin(core.PermissionUsersRead, s.users),
+	))
+
+	// [GET, PATCH, DELETE] /api/admin/users/{id}
+	// [POST] /api/admin/users/{id}/disable, /api/admin/users/{id}/enable
+	s.mux.Handle(users.Prefix, middleware.SetContextRequestID(
+		s.adminByMethod(core.PermissionUsersRead, core.PermissionUsersWrite, s.user),
+	))
+
+	// [GET] /api/admin/users/search?q=
+	s.mux.Handle("/api/admin/users/search", middleware.SetContextRequestID(
+		s.admin(core.PermissionUsersRead, s.searchUsers),
+	))
+
+	// [POST, DELETE]
+	s.mux.Handle("/api/admin/users/roles", middleware.SetContextRequestID(
+		s.admin(core.PermissionRolesManage, s.userRoles),
+	))
+
+	// [GET, POST]
+	s.mux.Handle("/api/admin/organizations", middleware.SetContextRequestID(
+		s.admin(core.PermissionOrganizationsManage, s.organizations),
+	))
+
+	// [GET, POST]
+	s.mux.Handle("/api/org/invitations", middleware.SetContextRequestID(s.orgAdmin(s.invitations)))
+
+	// [POST]
+	s.mux.Handle("/api/org/invitations/revoke", middleware.SetContextRequestID(s.orgAdmin(s.revokeInvitation)))
+
+	// [POST]
+	s.mux.Handle("/api/org/invitations/resend", middleware.SetContextRequestID(s.orgAdmin(s.resendInvitation)))
+
+	// [GET, PATCH, DELETE]
+	s.mux.Handle("/api/org/members", middleware.SetContextRequestID(s.orgAdmin(s.members)))
+
+	// [POST]
+	s.mux.Handle("/api/invitations/accept", middleware.SetContextRequestID(s.acceptInvitation))
+}
+
+// admin lets requests through to the handler which carry the admin key or an
+// access token of the default tenant granting the permission
+func (s *httpServer) admin(permission string, handler http.Handler) http.Handler {
+	return middleware.RequireAdminKeyOr(s.adminKey, handler, middleware.Authenticate(
+		s.logs, s.verifier, middleware.RequireDefaultTenant(
+			s.logs, middleware.RequirePermission(s.logs, permission, handler),
+		),
+	))
+}
+
+// adminByMethod requires the read permission for GET requests and the write
+// permission for all others
+func (s *httpServer) adminByMethod(read, write string, handler http.Handler) http.Handler {
+	reader := s.admin(read, handler)
+	writer := s.admin(write, handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			reader.ServeHTTP(w, r)
+			return
+		}
+		writer.ServeHTTP(w, r)
+	})
+}
+
+// orgAdmin lets requests through to the handler which carry the admin key
+// or an access token of an owner or admin of the requested organization
+func (s *httpServer) orgAdmin(handler http.Handler) http.Handler {
+	return middleware.RequireAdminKeyOr(s.adminKey, handler, middleware.Authenticate(
+		s.logs, s.verifier, middleware.RequireOrgRole(
+			s.logs, []string{model.MemberRoleOwner, model.MemberRoleAdmin}, handler,
+		),
+	))
 }
 
 func (s *httpServer) StartServer() {
 
 	s.RegisterHandlers()
 
+	go s.purgeExpiredTokens(time.Hour)
+	go s.syncKeyRing(time.Minute)
+
 	s.logs.Infow(
 		"server starting...",
 		"app_port", os.Getenv("APP_PORT"),
 	)
 
-	port := fmt.Sprintf(":%s", os.Getenv("APP_PORT"))
-	if err := http.ListenAndServe(port, s.mux); err != nil {
-		s.logs.Fatalln("server stopped unexpectedly")
+	var handler http.Handler = middleware.ResolveTenant(s.logs, s.tenants, s.mux)
+	if s.trustProxy {
+		handler = middleware.ForwardedFor(handler)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%s", os.Getenv("APP_PORT")),
+		Handler: handler,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			s.logs.Fatalln("server stopped unexpectedly")
+		}
+	}()
+
+	<-ctx.Done()
+	s.logs.Infow("server shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		s.logs.Errorw(
+			"server shutdown failed",
+			"error", err,
+		)
+	}
+	if err := s.jobs.Close(shutdownCtx); err != nil {
+		s.logs.Errorw(
+			"background jobs not finished",
+			"error", err,
+		)
+	}
+}
+
+// syncKeyRing periodically picks up keys promoted or retired by other
+// replicas sharing JWT_KEYS_DIR
+func (s *httpServer) syncKeyRing(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.keyRing.Sync(); err != nil {
+			s.logs.Errorw(
+				"sync key ring failed",
+				"error", err,
+			)
+		}
+	}
+}
+
+// purgeExpiredTokens periodically cleans up expired revocation entries and refresh tokens
+func (s *httpServer) purgeExpiredTokens(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.purger.PurgeExpiredTokens(); err != nil {
+			s.logs.Errorw(
+				"purge expired tokens failed",
+				"error", err,
+			)
+		}
 	}
 }
@@ -0,0 +1,73 @@
+package hibp
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const prefixLength = 5
+
+type rangeStore struct {
+	dir string
+}
+
+// NewRangeStore is a constructor function for a local copy of the Pwned
+// Passwords corpus, so no password or hash prefix ever leaves the host.
+// The directory holds k-anonymity range files as written by the Pwned
+// Passwords downloader. Each file is named after a 5 character SHA-1 prefix,
+// optionally with a .txt extension, and holds "SUFFIX:COUNT" lines as
+// returned by the range API.
+func NewRangeStore(dir string) (*rangeStore, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("stat corpus dir: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("corpus %s is not a directory", dir)
+	}
+	return &rangeStore{dir: dir}, nil
+}
+
+// IsBreached reports whether the password appears in the corpus
+func (s *rangeStore) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	f, err := s.open(prefix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("open range %s: %w", prefix, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		candidate, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(candidate, suffix) {
+			// padded responses list made up suffixes with a zero count
+			return count != "0", nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("read range %s: %w", prefix, err)
+	}
+	return false, nil
+}
+
+func (s *rangeStore) open(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(s.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.Open(filepath.Join(s.dir, prefix))
+	}
+	return f, err
+}
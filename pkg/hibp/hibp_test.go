@@ -0,0 +1,61 @@
+package hibp_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/hibp"
+)
+
+func writeRange(t *testing.T, dir, name string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\r\n")), 0o600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func Test_RangeStore_IsBreached(t *testing.T) {
+	dir := t.TempDir()
+	breached := sha1Hex("password")
+	padded := sha1Hex("padding")
+	writeRange(t, dir, breached[:5]+".txt", "0000000000000000000000000000000000A:2", breached[5:]+":9545824")
+	writeRange(t, dir, padded[:5], strings.ToLower(padded[5:])+":0")
+
+	store, err := hibp.NewRangeStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		password string
+		expected bool
+	}{
+		{"password", true},
+		{"padding", false},
+		{"correct horse battery staple", false},
+	}
+	for _, c := range cases {
+		got, err := store.IsBreached(c.password)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != c.expected {
+			t.Fatalf("breached does not match for %q, expected: %t, got: %t", c.password, c.expected, got)
+		}
+	}
+}
+
+func Test_NewRangeStore_MissingDir(t *testing.T) {
+	if _, err := hibp.NewRangeStore(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected error for a missing corpus")
+	}
+}
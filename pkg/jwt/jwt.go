@@ -7,40 +7,87 @@ import (
 
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
 var TimeNow = time.Now
+var NewTokenID = uuid.NewString
 var ErrTokenNotValid error = errors.New("token is not valid")
 
 type jwtGenerator struct {
-	secret []byte
+	ring *KeyRing
 }
 
+// NewJwtGenerator creates a generator signing tokens with HS512 and the given secret
 func NewJwtGenerator(jwtSecret []byte) *jwtGenerator {
+	return NewJwtGeneratorWithKey(NewHMACKey("", jwtSecret))
+}
+
+// NewJwtGeneratorWithKey creates a generator signing tokens with the given key
+func NewJwtGeneratorWithKey(key *SigningKey) *jwtGenerator {
+	return NewJwtGeneratorWithKeyRing(NewKeyRing(key, 0))
+}
+
+// NewJwtGeneratorWithKeyRing creates a generator signing tokens with the
+// active key of the ring and verifying them with any key of the ring
+func NewJwtGeneratorWithKeyRing(ring *KeyRing) *jwtGenerator {
 	return &jwtGenerator{
-		secret: jwtSecret,
+		ring: ring,
 	}
 }
 
 func (gen *jwtGenerator) Generate(data *model.TokenInfo) *jwt.Token {
-	token := jwt.New(jwt.SigningMethodHS512)
+	key := gen.ring.active()
+	token := jwt.New(key.Method)
 
 	token.Header["typ"] = "JWT"
-	token.Header["alg"] = jwt.SigningMethodHS512.Name
+	token.Header["alg"] = key.Method.Alg()
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
 
 	claims := token.Claims.(jwt.MapClaims)
+	claims["jti"] = NewTokenID()
 	claims["sub"] = data.Subject
 	claims["iat"] = TimeNow().Unix()
-	claims["exp"] = TimeNow().Unix() + int64(data.Expiration*3600)
-	claims["first_name"] = data.FirstName
-	claims["last_name"] = data.LastName
-	claims["email"] = data.Email
+	claims["exp"] = TimeNow().Add(data.Expiration).Unix()
+	if data.FirstName != "" {
+		claims["first_name"] = data.FirstName
+	}
+	if data.LastName != "" {
+		claims["last_name"] = data.LastName
+	}
+	if data.Email != "" {
+		claims["email"] = data.Email
+	}
+	if data.UserID != 0 {
+		claims["uid"] = data.UserID
+	}
+	if data.Issuer != "" {
+		claims["iss"] = data.Issuer
+	}
+	if data.Audience != "" {
+		claims["aud"] = data.Audience
+	}
+	if data.Scope != "" {
+		claims["scope"] = data.Scope
+	}
+	for k, v := range data.Claims {
+		if _, ok := claims[k]; !ok {
+			claims[k] = v
+		}
+	}
 
 	return token
 }
 
 func (gen *jwtGenerator) Sign(token *jwt.Token) (string, error) {
-	tokenStr, err := token.SignedString(gen.secret)
+	kid, _ := token.Header["kid"].(string)
+	key, ok := gen.ring.lookup(kid)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	tokenStr, err := token.SignedString(key.private)
 	if err != nil {
 		return "", fmt.Errorf("string signed: %w", err)
 	}
@@ -49,13 +96,20 @@ func (gen *jwtGenerator) Sign(token *jwt.Token) (string, error) {
 
 func (gen *jwtGenerator) Validate(token string) (jwt.MapClaims, error) {
 	jwtToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		// tokens without a kid were issued before key rotation
+		// and are verified with the key registered without an id
+		kid, _ := t.Header["kid"].(string)
+		key, ok := gen.ring.lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return gen.secret, nil
+		return key.public, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("jwt parse: %w", err)
+		return nil, fmt.Errorf("jwt parse: %w: %w", ErrTokenNotValid, err)
 	}
 
 	if !jwtToken.Valid {
@@ -68,3 +122,20 @@ func (gen *jwtGenerator) Validate(token string) (jwt.MapClaims, error) {
 	}
 	return claims, nil
 }
+
+// JWKS returns the public keys which verify the generated tokens.
+// Shared secrets are never published.
+func (gen *jwtGenerator) JWKS() (JSONWebKeySet, error) {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range gen.ring.verificationKeys() {
+		if key.IsSymmetric() {
+			continue
+		}
+		jwk, err := key.JWK()
+		if err != nil {
+			return JSONWebKeySet{}, fmt.Errorf("jwk: %w", err)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
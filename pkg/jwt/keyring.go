@@ -0,0 +1,382 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownKey error = errors.New("unknown signing key")
+	ErrActiveKey  error = errors.New("active signing key can not be retired")
+)
+
+// KeyLoader looks up a signing key which is not part of the ring yet
+type KeyLoader func(kid string) (*SigningKey, error)
+
+// KeyStatus describes a key of the ring
+type KeyStatus struct {
+	ID        string     `json:"kid"`
+	Algorithm string     `json:"alg"`
+	Active    bool       `json:"active"`
+	RetireAt  *time.Time `json:"retire_at,omitempty"`
+}
+
+// RingState is what a RingStore keeps of a ring: the key signing new tokens
+// and the retirement times of the others, including already dropped ones
+type RingState struct {
+	ActiveID string               `json:"active"`
+	RetireAt map[string]time.Time `json:"retire_at"`
+}
+
+// RingStore persists the state of a ring, so that promotions and retirements
+// survive restarts and reach the other replicas sharing the store.
+// LoadRingState reports false when nothing has been saved yet.
+type RingStore interface {
+	LoadRingState() (RingState, bool, error)
+	SaveRingState(state RingState) error
+}
+
+type ringKey struct {
+	key      *SigningKey
+	retireAt time.Time
+}
+
+// KeyRing holds the key signing new tokens together with older keys which
+// still verify tokens issued before a rotation. Keys are looked up by kid
+// and are dropped from the ring once their retirement time has passed.
+type KeyRing struct {
+	mu          sync.RWMutex
+	keys        map[string]*ringKey
+	retired     map[string]time.Time
+	activeID    string
+	retireAfter time.Duration
+	load        KeyLoader
+	store       RingStore
+}
+
+// NewKeyRing creates a ring signing with the given key. On promotion of
+// another key the previously active one keeps verifying for retireAfter.
+func NewKeyRing(active *SigningKey, retireAfter time.Duration) *KeyRing {
+	return &KeyRing{
+		keys:        map[string]*ringKey{active.ID: {key: active}},
+		retired:     map[string]time.Time{},
+		activeID:    active.ID,
+		retireAfter: retireAfter,
+	}
+}
+
+// SetLoader sets the source of keys promoted by id without being added first
+func (r *KeyRing) SetLoader(load KeyLoader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.load = load
+}
+
+// SetStore applies the state saved in store to the ring and saves every
+// later promotion and retirement to it. Keys have to be added and the
+// loader set before, so that the saved active key can be found.
+func (r *KeyRing) SetStore(store RingStore) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+	return r.syncLocked()
+}
+
+// Sync applies the state saved by other replicas sharing the store
+func (r *KeyRing) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.store == nil {
+		return nil
+	}
+	return r.syncLocked()
+}
+
+// Add puts a verification key into the ring. Adding a key with an id
+// already present replaces it and clears its retirement.
+func (r *KeyRing) Add(key *SigningKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key.ID] = &ringKey{key: key}
+	delete(r.retired, key.ID)
+}
+
+// Promote makes the key with the given id sign all new tokens
+func (r *KeyRing) Promote(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked()
+
+	if err := r.loadLocked(kid); err != nil {
+		return err
+	}
+	if kid == r.activeID {
+		return nil
+	}
+
+	previousID, promoted := r.activeID, r.keys[kid]
+	previous, promotedRetireAt := r.keys[previousID], promoted.retireAt
+	previousRetireAt := previous.retireAt
+	previous.retireAt = TimeNow().Add(r.retireAfter)
+	promoted.retireAt = time.Time{}
+	r.activeID = kid
+	if err := r.saveLocked(); err != nil {
+		previous.retireAt, promoted.retireAt = previousRetireAt, promotedRetireAt
+		r.activeID = previousID
+		return err
+	}
+	return nil
+}
+
+// Retire schedules the key with the given id to be dropped at the given time
+func (r *KeyRing) Retire(kid string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	if kid == r.activeID {
+		return ErrActiveKey
+	}
+	previousRetireAt := entry.retireAt
+	entry.retireAt = at
+	if err := r.saveLocked(); err != nil {
+		entry.retireAt = previousRetireAt
+		return err
+	}
+	r.pruneLocked()
+	return nil
+}
+
+// Keys lists the keys of the ring ordered by id
+func (r *KeyRing) Keys() []KeyStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked()
+
+	res := make([]KeyStatus, 0, len(r.keys))
+	for id, entry := range r.keys {
+		status := KeyStatus{
+			ID:        id,
+			Algorithm: entry.key.Method.Alg(),
+			Active:    id == r.activeID,
+		}
+		if !entry.retireAt.IsZero() {
+			retireAt := entry.retireAt
+			status.RetireAt = &retireAt
+		}
+		res = append(res, status)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+func (r *KeyRing) active() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys[r.activeID].key
+}
+
+func (r *KeyRing) lookup(kid string) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.keys[kid]
+	if !ok || r.retiredLocked(entry) {
+		return nil, false
+	}
+	return entry.key, true
+}
+
+func (r *KeyRing) verificationKeys() []*SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]*SigningKey, 0, len(r.keys))
+	for _, entry := range r.keys {
+		if !r.retiredLocked(entry) {
+			res = append(res, entry.key)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+func (r *KeyRing) retiredLocked(entry *ringKey) bool {
+	return !entry.retireAt.IsZero() && !TimeNow().Before(entry.retireAt)
+}
+
+func (r *KeyRing) pruneLocked() {
+	for id, entry := range r.keys {
+		if id != r.activeID && r.retiredLocked(entry) {
+			r.retired[id] = entry.retireAt
+			delete(r.keys, id)
+		}
+	}
+}
+
+// loadLocked makes sure the key with the given id is in the ring
+func (r *KeyRing) loadLocked(kid string) error {
+	if _, ok := r.keys[kid]; ok {
+		return nil
+	}
+	if r.load == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	key, err := r.load(kid)
+	if err != nil {
+		return fmt.Errorf("load key: %w", err)
+	}
+	r.keys[kid] = &ringKey{key: key}
+	delete(r.retired, kid)
+	return nil
+}
+
+// syncLocked replaces the active key and the retirement times of the ring
+// with the saved state. Keys without a saved retirement time do not retire.
+func (r *KeyRing) syncLocked() error {
+	state, ok, err := r.store.LoadRingState()
+	if err != nil {
+		return fmt.Errorf("load ring state: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	if err := r.loadLocked(state.ActiveID); err != nil {
+		return err
+	}
+
+	r.activeID = state.ActiveID
+	r.retired = map[string]time.Time{}
+	for id, at := range state.RetireAt {
+		if _, ok := r.keys[id]; !ok {
+			r.retired[id] = at
+		}
+	}
+	for id, entry := range r.keys {
+		entry.retireAt = time.Time{}
+		if id != r.activeID {
+			entry.retireAt = state.RetireAt[id]
+		}
+	}
+	r.pruneLocked()
+	return nil
+}
+
+func (r *KeyRing) saveLocked() error {
+	if r.store == nil {
+		return nil
+	}
+	state := RingState{ActiveID: r.activeID, RetireAt: map[string]time.Time{}}
+	for id, at := range r.retired {
+		state.RetireAt[id] = at
+	}
+	for id, entry := range r.keys {
+		if !entry.retireAt.IsZero() {
+			state.RetireAt[id] = entry.retireAt
+		}
+	}
+	if err := r.store.SaveRingState(state); err != nil {
+		return fmt.Errorf("save ring state: %w", err)
+	}
+	return nil
+}
+
+// DirectoryLoader loads the key with a given id from the "<kid>.pem" file in dir
+func DirectoryLoader(dir string) KeyLoader {
+	return func(kid string) (*SigningKey, error) {
+		if kid == "" || kid != filepath.Base(kid) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+		}
+		pemData, err := os.ReadFile(filepath.Join(dir, kid+".pem"))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read key file: %w", err)
+		}
+		return ParseSigningKey(kid, pemData)
+	}
+}
+
+// ringStateFile holds the ring state in the keys directory. It does not end
+// in .pem, so LoadDirectory skips it.
+const ringStateFile = "keyring.json"
+
+type directoryStore struct {
+	dir string
+}
+
+// DirectoryStore keeps the ring state in a file of dir. Replicas sharing
+// the directory share promotions and retirements.
+func DirectoryStore(dir string) RingStore {
+	return &directoryStore{dir: dir}
+}
+
+func (d *directoryStore) LoadRingState() (RingState, bool, error) {
+	data, err := os.ReadFile(filepath.Join(d.dir, ringStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return RingState{}, false, nil
+	}
+	if err != nil {
+		return RingState{}, false, fmt.Errorf("read state file: %w", err)
+	}
+	var state RingState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return RingState{}, false, fmt.Errorf("json unmarshal: %w", err)
+	}
+	return state, true, nil
+}
+
+// SaveRingState writes the state to a temporary file first and renames it,
+// so that replicas never read a half written state
+func (d *directoryStore) SaveRingState(state RingState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	file, err := os.CreateTemp(d.dir, ringStateFile+".*")
+	if err != nil {
+		return fmt.Errorf("create state file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("write state file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close state file: %w", err)
+	}
+	if err := os.Rename(file.Name(), filepath.Join(d.dir, ringStateFile)); err != nil {
+		return fmt.Errorf("rename state file: %w", err)
+	}
+	return nil
+}
+
+// LoadDirectory parses every "<kid>.pem" file in dir
+func LoadDirectory(dir string) ([]*SigningKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("glob key files: %w", err)
+	}
+
+	load := DirectoryLoader(dir)
+	res := make([]*SigningKey, 0, len(paths))
+	for _, path := range paths {
+		key, err := load(strings.TrimSuffix(filepath.Base(path), ".pem"))
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", path, err)
+		}
+		res = append(res, key)
+	}
+	return res, nil
+}
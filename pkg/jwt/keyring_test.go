@@ -0,0 +1,190 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+func newEdKey(t *testing.T, kid string) *jwt.SigningKey {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ed25519 key")
+	}
+	key, err := jwt.ParseSigningKey(kid, pemEncode(t, private))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return key
+}
+
+func Test_KeyRing_Rotation(t *testing.T) {
+	jwt.TimeNow = time.Now
+
+	ring := jwt.NewKeyRing(jwt.NewHMACKey("", []byte("test_secret")), time.Hour)
+	jwtGen := jwt.NewJwtGeneratorWithKeyRing(ring)
+
+	legacy, err := jwtGen.Sign(jwtGen.Generate(&model.TokenInfo{Expiration: time.Hour}))
+	if err != nil {
+		t.Fatalf("token signing failed: %s", err)
+	}
+
+	ring.Add(newEdKey(t, "key-1"))
+	if err := ring.Promote("key-1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	token := jwtGen.Generate(&model.TokenInfo{Expiration: time.Hour})
+	if token.Header["kid"] != "key-1" {
+		t.Fatalf("token not signed by the promoted key, got kid: %v", token.Header["kid"])
+	}
+	rotated, err := jwtGen.Sign(token)
+	if err != nil {
+		t.Fatalf("token signing failed: %s", err)
+	}
+
+	for _, tokenStr := range []string{legacy, rotated} {
+		if _, err := jwtGen.Validate(tokenStr); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if err := ring.Retire("", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := jwtGen.Validate(legacy); !errors.Is(err, jwt.ErrTokenNotValid) {
+		t.Fatalf("token of a retired key still valid, got: %v", err)
+	}
+	if _, err := jwtGen.Validate(rotated); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func Test_KeyRing_RetireActive(t *testing.T) {
+	ring := jwt.NewKeyRing(newEdKey(t, "key-1"), time.Hour)
+
+	err := ring.Retire("key-1", time.Now())
+	if !errors.Is(err, jwt.ErrActiveKey) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", jwt.ErrActiveKey, err)
+	}
+}
+
+func Test_KeyRing_PromoteFromDirectory(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ed25519 key")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "key-2.pem"), pemEncode(t, private), 0o600); err != nil {
+		t.Fatal("failed to write key file")
+	}
+
+	ring := jwt.NewKeyRing(newEdKey(t, "key-1"), time.Hour)
+	ring.SetLoader(jwt.DirectoryLoader(dir))
+
+	if err := ring.Promote("missing"); !errors.Is(err, jwt.ErrUnknownKey) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", jwt.ErrUnknownKey, err)
+	}
+	if err := ring.Promote("key-2"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	keys := ring.Keys()
+	if len(keys) != 2 || keys[0].ID != "key-1" || keys[0].Active || keys[0].RetireAt == nil || !keys[1].Active {
+		t.Fatalf("unexpected keys after promotion: %+v", keys)
+	}
+}
+
+func Test_KeyRing_StateSurvivesRestart(t *testing.T) {
+	jwt.TimeNow = time.Now
+	dir := t.TempDir()
+	configured := newEdKey(t, "key-1")
+	promoted := newEdKey(t, "key-2")
+
+	ring := jwt.NewKeyRing(configured, time.Hour)
+	ring.Add(promoted)
+	if err := ring.SetStore(jwt.DirectoryStore(dir)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := ring.Promote("key-2"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	retireAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	if err := ring.Retire("key-1", retireAt); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	restarted := jwt.NewKeyRing(configured, time.Hour)
+	restarted.Add(promoted)
+	if err := restarted.SetStore(jwt.DirectoryStore(dir)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	keys := restarted.Keys()
+	if len(keys) != 2 || keys[0].Active || keys[0].RetireAt == nil || !keys[0].RetireAt.Equal(retireAt) || !keys[1].Active {
+		t.Fatalf("state not restored: %+v", keys)
+	}
+}
+
+func Test_KeyRing_RetiredKeyStaysRetired(t *testing.T) {
+	jwt.TimeNow = time.Now
+	dir := t.TempDir()
+	secret := jwt.NewHMACKey("", []byte("test_secret"))
+
+	ring := jwt.NewKeyRing(newEdKey(t, "key-1"), time.Hour)
+	ring.Add(secret)
+	if err := ring.SetStore(jwt.DirectoryStore(dir)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := ring.Retire("", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	restarted := jwt.NewKeyRing(newEdKey(t, "key-1"), time.Hour)
+	restarted.Add(secret)
+	if err := restarted.SetStore(jwt.DirectoryStore(dir)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if keys := restarted.Keys(); len(keys) != 1 || keys[0].ID != "key-1" {
+		t.Fatalf("retired key back after restart: %+v", keys)
+	}
+}
+
+func Test_KeyRing_SyncReplicas(t *testing.T) {
+	jwt.TimeNow = time.Now
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ed25519 key")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "key-2.pem"), pemEncode(t, private), 0o600); err != nil {
+		t.Fatal("failed to write key file")
+	}
+	configured := newEdKey(t, "key-1")
+
+	replicas := make([]*jwt.KeyRing, 2)
+	for i := range replicas {
+		replicas[i] = jwt.NewKeyRing(configured, time.Hour)
+		replicas[i].SetLoader(jwt.DirectoryLoader(dir))
+		if err := replicas[i].SetStore(jwt.DirectoryStore(dir)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := replicas[0].Promote("key-2"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := replicas[1].Sync(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	token := jwt.NewJwtGeneratorWithKeyRing(replicas[1]).Generate(&model.TokenInfo{Expiration: time.Hour})
+	if token.Header["kid"] != "key-2" {
+		t.Fatalf("replica does not sign with the promoted key, got kid: %v", token.Header["kid"])
+	}
+}
@@ -0,0 +1,117 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrUnsupportedKey error = errors.New("unsupported signing key")
+
+// SigningKey is a key used to sign and verify tokens together with the
+// algorithm it is used with. For HMAC keys the private and public parts
+// are the same secret.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+// NewHMACKey creates an HS512 signing key from a shared secret
+func NewHMACKey(id string, secret []byte) *SigningKey {
+	return &SigningKey{
+		ID:      id,
+		Method:  jwt.SigningMethodHS512,
+		private: secret,
+		public:  secret,
+	}
+}
+
+// ParseSigningKey reads an RSA, ECDSA or Ed25519 private key from PEM data.
+// RSA keys sign with RS256, ECDSA keys with the ES algorithm matching their
+// curve and Ed25519 keys with EdDSA. When id is empty the RFC 7638
+// thumbprint of the public key is used as the key id.
+func ParseSigningKey(id string, pemData []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	private, err := parsePrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+
+	key := &SigningKey{ID: id, private: private}
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.public = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		method, err := ecdsaMethod(k.Curve)
+		if err != nil {
+			return nil, err
+		}
+		key.Method = method
+		key.public = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.public = k.Public()
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, private)
+	}
+
+	if key.ID == "" {
+		thumbprint, err := key.Thumbprint()
+		if err != nil {
+			return nil, fmt.Errorf("key thumbprint: %w", err)
+		}
+		key.ID = thumbprint
+	}
+	return key, nil
+}
+
+// IsSymmetric reports whether the key is a shared secret which must not be published
+func (k *SigningKey) IsSymmetric() bool {
+	_, ok := k.public.([]byte)
+	return ok
+}
+
+// PublicKey returns the part of the key used for verification
+func (k *SigningKey) PublicKey() crypto.PublicKey {
+	return k.public
+}
+
+func parsePrivateKey(der []byte) (any, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func ecdsaMethod(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256, nil
+	case elliptic.P384():
+		return jwt.SigningMethodES384, nil
+	case elliptic.P521():
+		return jwt.SigningMethodES512, nil
+	}
+	return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedKey, curve.Params().Name)
+}
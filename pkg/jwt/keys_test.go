@@ -0,0 +1,117 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/jwt"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+func pemEncode(t *testing.T, key any) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %s", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func Test_ParseSigningKey_SignAndValidate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate rsa key")
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ecdsa key")
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ed25519 key")
+	}
+
+	cases := []struct {
+		name string
+		key  any
+		alg  string
+		kty  string
+	}{
+		{"rsa", rsaKey, "RS256", "RSA"},
+		{"ecdsa", ecKey, "ES256", "EC"},
+		{"ed25519", edKey, "EdDSA", "OKP"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			jwt.TimeNow = time.Now
+
+			key, err := jwt.ParseSigningKey("", pemEncode(t, c.key))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if key.Method.Alg() != c.alg {
+				t.Fatalf("signing method does not match, expected: %s, got: %s", c.alg, key.Method.Alg())
+			}
+
+			jwtGen := jwt.NewJwtGeneratorWithKey(key)
+			token := jwtGen.Generate(&model.TokenInfo{Email: "test@gmail.com", Expiration: time.Hour})
+			if token.Header["kid"] != key.ID {
+				t.Fatalf("kid header does not match, expected: %s, got: %v", key.ID, token.Header["kid"])
+			}
+			tokenStr, err := jwtGen.Sign(token)
+			if err != nil {
+				t.Fatalf("token signing failed: %s", err)
+			}
+
+			claims, err := jwtGen.Validate(tokenStr)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if claims["email"] != "test@gmail.com" {
+				t.Fatalf("email claim does not match, got: %v", claims["email"])
+			}
+
+			set, err := jwtGen.JWKS()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(set.Keys) != 1 || set.Keys[0].Kid != key.ID || set.Keys[0].Kty != c.kty {
+				t.Fatalf("unexpected key set: %+v", set)
+			}
+		})
+	}
+}
+
+func Test_JWKS_HMAC_NotPublished(t *testing.T) {
+	jwtGen := jwt.NewJwtGenerator([]byte("test_secret"))
+	set, err := jwtGen.JWKS()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(set.Keys) != 0 {
+		t.Fatalf("shared secret published: %+v", set)
+	}
+}
+
+func Test_Thumbprint_RFC7638(t *testing.T) {
+	// example key from RFC 8037, appendix A.3
+	seed := []byte{
+		0x9d, 0x61, 0xb1, 0x9d, 0xef, 0xfd, 0x5a, 0x60, 0xba, 0x84, 0x4a, 0xf4, 0x92, 0xec, 0x2c, 0xc4,
+		0x44, 0x49, 0xc5, 0x69, 0x7b, 0x32, 0x69, 0x19, 0x70, 0x3b, 0xac, 0x03, 0x1c, 0xae, 0x7f, 0x60,
+	}
+	key, err := jwt.ParseSigningKey("", pemEncode(t, ed25519.NewKeyFromSeed(seed)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+	if key.ID != expected {
+		t.Fatalf("thumbprint does not match, expected: %s, got: %s", expected, key.ID)
+	}
+}
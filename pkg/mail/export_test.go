@@ -0,0 +1,8 @@
+package mail
+
+import "net/smtp"
+
+// SetSend replaces the function delivering the messages of the mailer
+func (m *smtpMailer) SetSend(send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error) {
+	m.send = send
+}
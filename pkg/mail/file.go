@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/google/uuid"
+)
+
+type fileMailer struct {
+	from      string
+	dir       string
+	out       io.Writer
+	templates *Templates
+	mu        sync.Mutex
+}
+
+// NewFileMailer is a constructor function for a mailer which stores every
+// message as an .eml file in the outbox directory
+func NewFileMailer(dir, from string, templates *Templates) *fileMailer {
+	return &fileMailer{
+		from:      from,
+		dir:       dir,
+		templates: templates,
+	}
+}
+
+// NewWriterMailer is a constructor function for a mailer which writes
+// every message to out, e.g. os.Stdout
+func NewWriterMailer(out io.Writer, from string, templates *Templates) *fileMailer {
+	return &fileMailer{
+		from:      from,
+		out:       out,
+		templates: templates,
+	}
+}
+
+func (m *fileMailer) Send(mail model.Mail) error {
+	msg, err := m.templates.Render(mail)
+	if err != nil {
+		return fmt.Errorf("render mail: %w", err)
+	}
+	msg.From = m.from
+
+	now := time.Now()
+	body, err := msg.Bytes(now)
+	if err != nil {
+		return fmt.Errorf("encode mail: %w", err)
+	}
+
+	if m.out != nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, err := m.out.Write(append(body, '\r', '\n')); err != nil {
+			return fmt.Errorf("write mail: %w", err)
+		}
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405Z"), uuid.NewString())
+	if err := os.WriteFile(filepath.Join(m.dir, name), body, 0o600); err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package mail_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	fuzzymail "github.com/dgdraganov/fuzzy-user-api/pkg/mail"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+func testTemplates(t *testing.T) *fuzzymail.Templates {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"en/welcome.subject.txt": {Data: []byte("Welcome {{.Name}}\n")},
+		"en/welcome.txt":         {Data: []byte("Hello {{.Name}}")},
+		"en/welcome.html":        {Data: []byte("<p>Hello {{.Name}}</p>")},
+		"bg/welcome.subject.txt": {Data: []byte("Добре дошли, {{.Name}}")},
+		"bg/welcome.txt":         {Data: []byte("Здравейте, {{.Name}}")},
+	}
+	templates, err := fuzzymail.NewTemplates(fsys, "en")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return templates
+}
+
+func Test_Render_LocaleFallback(t *testing.T) {
+	templates := testTemplates(t)
+
+	cases := []struct {
+		locale  string
+		subject string
+	}{
+		{"bg", "Добре дошли, <Ana>"},
+		{"bg-BG", "Добре дошли, <Ana>"},
+		{"de", "Welcome <Ana>"},
+		{"", "Welcome <Ana>"},
+	}
+	for _, c := range cases {
+		msg, err := templates.Render(model.Mail{Template: "welcome", Locale: c.locale, Data: map[string]any{"Name": "<Ana>"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if msg.Subject != c.subject {
+			t.Fatalf("subject does not match for locale %q, expected: %s, got: %s", c.locale, c.subject, msg.Subject)
+		}
+	}
+
+	msg, err := templates.Render(model.Mail{Template: "welcome", Data: map[string]any{"Name": "<Ana>"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if msg.HTML != "<p>Hello &lt;Ana&gt;</p>" {
+		t.Fatalf("html body not escaped: %s", msg.HTML)
+	}
+	if msg.Text != "Hello <Ana>" {
+		t.Fatalf("text body does not match: %s", msg.Text)
+	}
+
+	if _, err := templates.Render(model.Mail{Template: "missing"}); !errors.Is(err, fuzzymail.ErrUnknownTemplate) {
+		t.Fatalf("error does not match, expected: %s, got: %v", fuzzymail.ErrUnknownTemplate, err)
+	}
+}
+
+func Test_NewTemplates_MissingText(t *testing.T) {
+	fsys := fstest.MapFS{"en/broken.subject.txt": {Data: []byte("Subject")}}
+	if _, err := fuzzymail.NewTemplates(fsys, "en"); err == nil {
+		t.Fatal("expected error for a template without a text body")
+	}
+}
+
+func Test_DefaultTemplates(t *testing.T) {
+	templates, err := fuzzymail.NewTemplates(fuzzymail.DefaultTemplates(), "en")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	msg, err := templates.Render(model.Mail{Template: "email_verification", Data: map[string]any{"Link": "https://fuzzy.test/verify"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(msg.Text, "https://fuzzy.test/verify") {
+		t.Fatalf("link missing from body: %s", msg.Text)
+	}
+}
+
+func Test_FileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := fuzzymail.NewFileMailer(dir, "fuzzy@test.com", testTemplates(t))
+
+	err := mailer.Send(model.Mail{To: "ana@test.com", Template: "welcome", Data: map[string]any{"Name": "Ana"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one outbox file, got: %v (%v)", files, err)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("invalid message: %s", err)
+	}
+	if msg.Header.Get("To") != "ana@test.com" || msg.Header.Get("From") != "fuzzy@test.com" {
+		t.Fatalf("unexpected headers: %v", msg.Header)
+	}
+	if !strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/alternative") {
+		t.Fatalf("unexpected content type: %s", msg.Header.Get("Content-Type"))
+	}
+	body, _ := io.ReadAll(msg.Body)
+	if !strings.Contains(string(body), "<p>Hello Ana</p>") {
+		t.Fatalf("html part missing: %s", body)
+	}
+}
+
+func Test_SMTPMailer_EnvelopeSender(t *testing.T) {
+	config := fuzzymail.SMTPConfig{Host: "localhost", Port: "1025", From: "Fuzzy User API <no-reply@fuzzy.local>"}
+	mailer := fuzzymail.NewSMTPMailer(config, testTemplates(t))
+
+	var gotAddr, gotFrom string
+	var gotMsg []byte
+	mailer.SetSend(func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotFrom, gotMsg = addr, from, msg
+		return nil
+	})
+
+	err := mailer.Send(model.Mail{To: "ana@test.com", Template: "welcome", Data: map[string]any{"Name": "Ana"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if gotAddr != "localhost:1025" || gotFrom != "no-reply@fuzzy.local" {
+		t.Fatalf("unexpected envelope: %s from %q", gotAddr, gotFrom)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(gotMsg))
+	if err != nil {
+		t.Fatalf("invalid message: %s", err)
+	}
+	if msg.Header.Get("From") != config.From {
+		t.Fatalf("unexpected From header: %q", msg.Header.Get("From"))
+	}
+}
+
+func Test_WriterMailer_PlainText(t *testing.T) {
+	var out bytes.Buffer
+	mailer := fuzzymail.NewWriterMailer(&out, "fuzzy@test.com", testTemplates(t))
+
+	if err := mailer.Send(model.Mail{To: "ana@test.com", Locale: "bg", Template: "welcome", Data: map[string]any{"Name": "Ana"}}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	msg, err := mail.ReadMessage(&out)
+	if err != nil {
+		t.Fatalf("invalid message: %s", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Добре дошли, Ana" {
+		t.Fatalf("subject does not match: %s (%v)", subject, err)
+	}
+	if !strings.HasPrefix(msg.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected content type: %s", msg.Header.Get("Content-Type"))
+	}
+}
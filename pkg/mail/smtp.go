@@ -0,0 +1,65 @@
+package mail
+
+import (
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+// SMTPConfig is a config type for the SMTP mailer construction
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	config    SMTPConfig
+	templates *Templates
+	send      func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer is a constructor function for the smtpMailer type.
+// Credentials are optional, so a local MailHog-style server works
+// without any.
+func NewSMTPMailer(config SMTPConfig, templates *Templates) *smtpMailer {
+	return &smtpMailer{
+		config:    config,
+		templates: templates,
+		send:      smtp.SendMail,
+	}
+}
+
+func (m *smtpMailer) Send(mail model.Mail) error {
+	msg, err := m.templates.Render(mail)
+	if err != nil {
+		return fmt.Errorf("render mail: %w", err)
+	}
+	msg.From = m.config.From
+
+	body, err := msg.Bytes(time.Now())
+	if err != nil {
+		return fmt.Errorf("encode mail: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+	// the envelope takes the bare address of a "Name <address>" sender
+	from, err := netmail.ParseAddress(m.config.From)
+	if err != nil {
+		return fmt.Errorf("parse sender address: %w", err)
+	}
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	if err := m.send(addr, auth, from.Address, []string{mail.To}, body); err != nil {
+		return fmt.Errorf("smtp send mail: %w", err)
+	}
+	return nil
+}
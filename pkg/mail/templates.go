@@ -0,0 +1,159 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+var ErrUnknownTemplate = errors.New("unknown mail template")
+
+// DefaultTemplates returns the templates shipped with the service
+func DefaultTemplates() fs.FS {
+	sub, _ := fs.Sub(defaultTemplates, "templates")
+	return sub
+}
+
+type templateSet struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// Templates renders messages from files laid out as
+// <locale>/<name>.subject.txt, <locale>/<name>.txt and the optional
+// <locale>/<name>.html
+type Templates struct {
+	defaultLocale string
+	sets          map[string]*templateSet
+}
+
+// NewTemplates parses every template found in fsys. Messages in a locale
+// without a matching template fall back to its base language and then
+// to the default locale.
+func NewTemplates(fsys fs.FS, defaultLocale string) (*Templates, error) {
+	t := &Templates{
+		defaultLocale: defaultLocale,
+		sets:          map[string]*templateSet{},
+	}
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		locale, file := path.Split(p)
+		locale = strings.Trim(locale, "/")
+		if locale == "" || strings.Contains(locale, "/") {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", p, err)
+		}
+
+		var name string
+		var parse func(set *templateSet) error
+		switch {
+		case strings.HasSuffix(file, ".subject.txt"):
+			name = strings.TrimSuffix(file, ".subject.txt")
+			parse = func(set *templateSet) (err error) {
+				set.subject, err = texttemplate.New(p).Parse(string(content))
+				return err
+			}
+		case strings.HasSuffix(file, ".txt"):
+			name = strings.TrimSuffix(file, ".txt")
+			parse = func(set *templateSet) (err error) {
+				set.text, err = texttemplate.New(p).Parse(string(content))
+				return err
+			}
+		case strings.HasSuffix(file, ".html"):
+			name = strings.TrimSuffix(file, ".html")
+			parse = func(set *templateSet) (err error) {
+				set.html, err = htmltemplate.New(p).Parse(string(content))
+				return err
+			}
+		default:
+			return nil
+		}
+
+		key := setKey(locale, name)
+		set, ok := t.sets[key]
+		if !ok {
+			set = &templateSet{}
+			t.sets[key] = set
+		}
+		if err := parse(set); err != nil {
+			return fmt.Errorf("parse %s: %w", p, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk templates: %w", err)
+	}
+
+	for key, set := range t.sets {
+		if set.subject == nil || set.text == nil {
+			return nil, fmt.Errorf("template %s: subject and text parts are required", key)
+		}
+	}
+	return t, nil
+}
+
+// Render builds the subject, plain text and HTML bodies of the mail
+func (t *Templates) Render(mail model.Mail) (Message, error) {
+	set, ok := t.lookup(mail.Template, mail.Locale)
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, mail.Template)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := set.subject.Execute(&subject, mail.Data); err != nil {
+		return Message{}, fmt.Errorf("execute subject: %w", err)
+	}
+	if err := set.text.Execute(&text, mail.Data); err != nil {
+		return Message{}, fmt.Errorf("execute text: %w", err)
+	}
+	if set.html != nil {
+		if err := set.html.Execute(&html, mail.Data); err != nil {
+			return Message{}, fmt.Errorf("execute html: %w", err)
+		}
+	}
+
+	return Message{
+		To:      mail.To,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+func (t *Templates) lookup(name, locale string) (*templateSet, bool) {
+	candidates := []string{locale}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		candidates = append(candidates, base)
+	}
+	candidates = append(candidates, t.defaultLocale)
+
+	for _, l := range candidates {
+		if set, ok := t.sets[setKey(l, name)]; ok {
+			return set, true
+		}
+	}
+	return nil, false
+}
+
+func setKey(locale, name string) string {
+	return strings.ToLower(locale) + "/" + name
+}
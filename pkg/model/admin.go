@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+// Statuses of users in the admin API
+const (
+	UserStatusActive     = "active"
+	UserStatusUnverified = "unverified"
+	UserStatusDisabled   = "disabled"
+	UserStatusDeleted    = "deleted"
+)
+
+// UserFilter selects the users of a tenant listed in the admin API. Users
+// are ordered by ID and AfterID is the last ID of the previous page.
+type UserFilter struct {
+	TenantID      uint
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Status        string
+	Domain        string
+	AfterID       uint
+	Limit         int
+}
+
+// UserQuery holds the query parameters of the user listing
+type UserQuery struct {
+	Limit         int       `validate:"omitempty,min=1,max=200"`
+	Cursor        string    `validate:"omitempty,max=64"`
+	CreatedAfter  time.Time `validate:"-"`
+	CreatedBefore time.Time `validate:"-"`
+	Status        string    `validate:"omitempty,oneof=active unverified disabled deleted"`
+	Domain        string    `validate:"omitempty,max=253,hostname_rfc1123"`
+}
+
+type AdminUserDTO struct {
+	ID            uint       `json:"id"`
+	TenantID      uint       `json:"tenant_id"`
+	Email         string     `json:"email"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	Locale        string     `json:"locale,omitempty"`
+	Status        string     `json:"status"`
+	EmailVerified bool       `json:"email_verified"`
+	HasPassword   bool       `json:"has_password"`
+	MFAEnabled    bool       `json:"mfa_enabled"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DisabledAt    *time.Time `json:"disabled_at,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+type UserPageDTO struct {
+	Users []AdminUserDTO `json:"users"`
+	// NextCursor is passed as the "cursor" parameter to get the next page,
+	// it is empty on the last one
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// UpdateUserDTO changes the fields which are set and leaves the others
+type UpdateUserDTO struct {
+	FirstName     *string `json:"first_name" validate:"omitempty,max=25"`
+	LastName      *string `json:"last_name" validate:"omitempty,max=25"`
+	Email         *string `json:"email" validate:"omitempty,email"`
+	EmailVerified *bool   `json:"email_verified"`
+	Locale        *string `json:"locale" validate:"omitempty,max=16"`
+}
+
+// UserSearchDTO holds the query parameters of the user search
+type UserSearchDTO struct {
+	Query string `validate:"required,min=2,max=100"`
+	Limit int    `validate:"omitempty,min=1,max=100"`
+}
+
+// UserMatch is a user found by the search with how well it matched, from
+// 0 to 1
+type UserMatch struct {
+	User
+	Score float64
+}
+
+type UserMatchDTO struct {
+	AdminUserDTO
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "gorm.io/gorm"
+
+// OAuthClient is a registered machine client authenticating with a secret.
+// Only the bcrypt hash of the secret is stored.
+type OAuthClient struct {
+	gorm.Model
+	ClientID   string `gorm:"uniqueIndex;not null;type:text"`
+	Name       string `gorm:"not null;type:text"`
+	SecretHash string `gorm:"not null;type:text"`
+	// Scopes is the space separated list of scopes the client may request
+	Scopes string `gorm:"type:text"`
+}
+
+type RegisterClientDTO struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"dive,required"`
+}
+
+// ClientCredentials is returned once on client registration.
+// The secret can not be recovered afterwards.
+type ClientCredentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Name         string `json:"name"`
+	Scope        string `json:"scope"`
+}
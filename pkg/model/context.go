@@ -5,4 +5,6 @@ type contextKey int
 const (
 	RequestID contextKey = iota
 	CurrentUser
+	// Tenant holds the uint ID of the tenant a request is served for
+	Tenant
 )
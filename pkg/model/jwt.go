@@ -1,9 +1,32 @@
 package model
 
+import "time"
+
 type TokenInfo struct {
+	UserID     uint
 	Email      string
 	FirstName  string
 	LastName   string
 	Subject    string
-	Expiration int
+	Expiration time.Duration
+	Issuer     string
+	Audience   string
+	Scope      string
+	// Claims holds any additional claims of the token
+	Claims map[string]any
+}
+
+// AuthTokens holds the tokens issued to a user on login or refresh
+type AuthTokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// KeyRotationDTO is an admin request to promote or retire a signing key
+type KeyRotationDTO struct {
+	Action   string     `json:"action"`
+	KeyID    string     `json:"kid"`
+	RetireAt *time.Time `json:"retire_at"`
 }
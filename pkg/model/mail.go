@@ -0,0 +1,10 @@
+package model
+
+// Mail is a message rendered from the named template in the
+// recipient's locale
+type Mail struct {
+	To       string
+	Locale   string
+	Template string
+	Data     map[string]any
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a single-use code which replaces a TOTP code when the
+// user has lost the authenticator. Only the SHA-256 hash of the code is
+// stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint   `gorm:"index;not null"`
+	CodeHash string `gorm:"index;not null;type:text"`
+	UsedAt   *time.Time
+}
+
+// TOTPEnrollment is the secret of a pending TOTP enrollment together with
+// the otpauth URI authenticator apps read it from
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are returned once, when TOTP enrollment is confirmed
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge is returned by login instead of the session tokens when the
+// user has to provide a second factor
+type MFAChallenge struct {
+	Message  string `json:"message"`
+	MFAToken string `json:"mfa_token"`
+}
+
+type ConfirmTOTPDTO struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MFALoginDTO struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	// Code is a TOTP code or one of the recovery codes
+	Code string `json:"code" validate:"required"`
+	// ClientIP is set by the handler for throttling
+	ClientIP string `json:"-"`
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OIDCClient is a relying party allowed to use the authorization code flow
+type OIDCClient struct {
+	ID           string
+	RedirectURIs []string
+}
+
+// AuthorizationCode is the server-side record of an issued authorization code.
+// Only the SHA-256 hash of the code is stored.
+type AuthorizationCode struct {
+	gorm.Model
+	CodeHash      string    `gorm:"uniqueIndex;not null;type:text"`
+	ClientID      string    `gorm:"not null;type:text"`
+	UserID        uint      `gorm:"not null"`
+	RedirectURI   string    `gorm:"not null;type:text"`
+	Scope         string    `gorm:"type:text"`
+	Nonce         string    `gorm:"type:text"`
+	CodeChallenge string    `gorm:"not null;type:text"`
+	AccessTokenID string    `gorm:"type:text"`
+	ExpiresAt     time.Time `gorm:"index;not null"`
+	UsedAt        *time.Time
+}
+
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	ClientID     string
+	ClientSecret string
+	CodeVerifier string
+	Scope        string
+}
+
+// TokenActionRequest is a client request to introspect or revoke a token
+type TokenActionRequest struct {
+	ClientID      string
+	ClientSecret  string
+	Token         string
+	TokenTypeHint string
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// OAuthErrorResponse is the error body defined in RFC 6749, section 5.2
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
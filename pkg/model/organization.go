@@ -0,0 +1,140 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization is a tenant of the service. Users belong to one tenant and
+// their email addresses are unique within it. Users outside of any
+// organization belong to the default tenant with ID 0.
+type Organization struct {
+	gorm.Model
+	// Slug is sent in the "X-Tenant" header to pick the tenant
+	Slug string `gorm:"uniqueIndex;not null;type:text"`
+	Name string `gorm:"not null;type:text"`
+	// AllowSignup lets anyone register in the organization, otherwise
+	// users have to be invited
+	AllowSignup bool `gorm:"not null;default:false"`
+}
+
+// Roles of the members within their organization
+const (
+	MemberRoleOwner  = "owner"
+	MemberRoleAdmin  = "admin"
+	MemberRoleMember = "member"
+)
+
+// Membership lets a user of an organization's tenant log in and holds the
+// user's role within the organization
+type Membership struct {
+	ID             uint   `gorm:"primarykey"`
+	OrganizationID uint   `gorm:"uniqueIndex:idx_memberships_organization_user;not null"`
+	UserID         uint   `gorm:"uniqueIndex:idx_memberships_organization_user;index;not null"`
+	Role           string `gorm:"not null;default:member;type:text"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type OrganizationDTO struct {
+	ID          uint      `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	AllowSignup bool      `json:"allow_signup"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateOrganizationDTO struct {
+	Slug        string `json:"slug" validate:"required,max=63,hostname_rfc1123"`
+	Name        string `json:"name" validate:"required,max=100"`
+	AllowSignup bool   `json:"allow_signup"`
+}
+
+// Invitation lets the owner of the email address join an organization.
+// The emailed token carries the TokenID as its jti, so resending an
+// invitation invalidates the links sent before.
+type Invitation struct {
+	ID             uint   `gorm:"primarykey"`
+	OrganizationID uint   `gorm:"index;not null"`
+	Email          string `gorm:"not null;type:text"`
+	Role           string `gorm:"not null;default:member;type:text"`
+	InvitedBy      uint
+	TokenID        string `gorm:"uniqueIndex;not null;type:text"`
+	ExpiresAt      time.Time
+	AcceptedAt     *time.Time
+	RevokedAt      *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Statuses of invitations
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+type InvitationDTO struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	InvitedBy uint      `json:"invited_by,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateInvitationDTO struct {
+	Email string `json:"email" validate:"required,email"`
+	// Role defaults to member
+	Role string `json:"role" validate:"omitempty,oneof=owner admin member"`
+}
+
+type InvitationActionDTO struct {
+	ID uint `json:"id" validate:"required"`
+}
+
+// AcceptInvitationDTO takes the token of an emailed invitation. The names
+// and the password are only used when the invited email has no account in
+// the organization's tenant yet.
+type AcceptInvitationDTO struct {
+	Token     string `json:"token" validate:"required"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Password  string `json:"password"`
+	Locale    string `json:"locale"`
+}
+
+// Member is a membership joined with the user it belongs to
+type Member struct {
+	Membership
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+type MemberDTO struct {
+	UserID    uint      `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// UpdateMemberDTO changes the role of a member. Only UserID is needed to
+// remove one.
+type UpdateMemberDTO struct {
+	UserID uint   `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+// OrgActor is the member managing an organization. Requests made with the
+// admin key act as an owner without a user.
+type OrgActor struct {
+	TenantID uint
+	UserID   uint
+	Role     string
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCredential is a passkey a user registered as an alternative to
+// the password
+type WebAuthnCredential struct {
+	gorm.Model
+	UserID uint `gorm:"index;not null"`
+	// CredentialID is the base64url encoded credential ID
+	CredentialID string `gorm:"uniqueIndex;not null;type:text"`
+	// PublicKey is the COSE_Key of the credential
+	PublicKey  []byte `gorm:"not null"`
+	SignCount  uint32 `gorm:"not null;default:0"`
+	Transports string `gorm:"type:text"`
+	Name       string `gorm:"size:64;type:text"`
+	LastUsedAt *time.Time
+}
+
+// PasskeyCreationOptions starts a passkey registration. Session has to be
+// sent back together with the created credential.
+type PasskeyCreationOptions struct {
+	Session   string                   `json:"session"`
+	PublicKey webauthn.CreationOptions `json:"publicKey"`
+}
+
+// PasskeyRequestOptions starts a passkey login. Session has to be sent
+// back together with the assertion.
+type PasskeyRequestOptions struct {
+	Session   string                  `json:"session"`
+	PublicKey webauthn.RequestOptions `json:"publicKey"`
+}
+
+type PasskeyRegistrationDTO struct {
+	Session    string                         `json:"session" validate:"required"`
+	Name       string                         `json:"name" validate:"max=64"`
+	Credential webauthn.AttestationCredential `json:"credential"`
+}
+
+type PasskeyLoginDTO struct {
+	Session    string                       `json:"session" validate:"required"`
+	Credential webauthn.AssertionCredential `json:"credential"`
+}
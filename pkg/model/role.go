@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Permission is a single action a role allows, such as "users:read".
+// Permissions are written to the permissions claim of access tokens.
+type Permission struct {
+	ID        uint   `gorm:"primarykey"`
+	Name      string `gorm:"uniqueIndex;not null;type:text"`
+	CreatedAt time.Time
+}
+
+// Role groups permissions and is assigned to users
+type Role struct {
+	ID          uint         `gorm:"primarykey"`
+	Name        string       `gorm:"uniqueIndex;not null;type:text"`
+	Description string       `gorm:"type:text"`
+	Permissions []Permission `gorm:"many2many:role_permissions"`
+	Users       []User       `gorm:"many2many:user_roles"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type RoleDTO struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type SaveRoleDTO struct {
+	Name        string   `json:"name" validate:"required,max=64"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"dive,required,max=64"`
+}
+
+type RoleAssignmentDTO struct {
+	UserID uint   `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// LoginFailure counts the consecutive failed logins of an account
+type LoginFailure struct {
+	Key          string    `gorm:"primaryKey;type:text"`
+	Count        int       `gorm:"not null;default:0"`
+	LastFailedAt time.Time `gorm:"index;not null"`
+}
+
+// RateBucket is the token bucket limiting the requests of a single client
+type RateBucket struct {
+	Key       string    `gorm:"primaryKey;type:text"`
+	Tokens    float64   `gorm:"not null"`
+	CheckedAt time.Time `gorm:"index;not null"`
+}
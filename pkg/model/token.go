@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is the server-side record of an issued refresh token.
+// Only the SHA-256 hash of the token is stored. Tokens obtained from
+// one another through rotation share the same FamilyID.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint      `gorm:"index;not null"`
+	FamilyID  string    `gorm:"index;not null;type:text"`
+	TokenHash string    `gorm:"uniqueIndex;not null;type:text"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// RevokedToken marks a single access token, identified by its jti claim,
+// as no longer valid. The entry is kept until the token would expire anyway.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;type:text"`
+	UserID    uint      `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+// PasswordResetToken is a single-use token emailed to a user who forgot
+// the password. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	gorm.Model
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null;type:text"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	UsedAt    *time.Time
+}
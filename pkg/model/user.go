@@ -1,15 +1,46 @@
 package model
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type User struct {
 	gorm.Model
-	FirstName    string `gorm:"size:25;default:null;not null;type:text"`
-	LastName     string `gorm:"size:25;default:null;not null;type:text"`
-	Email        string `gorm:"unique;type:text"`
-	PasswordHash string `gorm:"default:null;not null;type:text"`
+	FirstName string `gorm:"size:25;default:null;not null;type:text"`
+	LastName  string `gorm:"size:25;default:null;not null;type:text"`
+	// TenantID is the organization the user belongs to, 0 for the default
+	// tenant. Emails are unique per tenant.
+	TenantID uint   `gorm:"uniqueIndex:idx_users_tenant_email;not null;default:0"`
+	Email    string `gorm:"uniqueIndex:idx_users_tenant_email;type:text"`
+	// PasswordHash is empty for passwordless accounts, which log in with
+	// emailed links or passkeys only
+	PasswordHash string `gorm:"default:null;type:text"`
+	// EmailVerified is set once the user proves ownership of Email
+	EmailVerified bool `gorm:"not null;default:false"`
+	// Locale selects the language of the messages sent to the user
+	Locale string `gorm:"size:16;type:text"`
+	// SessionsRevokedAt invalidates every token issued before it
+	SessionsRevokedAt *time.Time
+	// TOTPSecret is the base32 secret of the user's authenticator app. It is
+	// only required on login once TOTPEnabledAt is set.
+	TOTPSecret    string `gorm:"type:text"`
+	TOTPEnabledAt *time.Time
+	// TOTPLastStep is the time step of the last accepted code, so a code
+	// cannot be used twice
+	TOTPLastStep int64 `gorm:"not null;default:0"`
+	// DisabledAt is set while an admin keeps the user from logging in
+	DisabledAt *time.Time
+}
+
+// PasswordHistory keeps a previous password hash of a user, so the
+// password policy can refuse its reuse
+type PasswordHistory struct {
+	ID           uint   `gorm:"primarykey"`
+	UserID       uint   `gorm:"index;not null"`
+	PasswordHash string `gorm:"not null;type:text"`
+	CreatedAt    time.Time
 }
 
 type UserLoginModel struct {
@@ -22,10 +53,70 @@ type RegisterDTO struct {
 	FirstName string `json:"first_name" validate:"required,min=2,max=25"`
 	LastName  string `json:"last_name" validate:"required,min=2,max=25"`
 	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required"`
+	// Password can be left out to create a passwordless account
+	Password string `json:"password"`
+	Locale   string `json:"locale" validate:"omitempty,max=16"`
+	// TenantID is set by the handler from the "X-Tenant" header
+	TenantID uint `json:"-"`
 }
 
 type LoginDTO struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// ClientIP is set by the handler for throttling
+	ClientIP string `json:"-"`
+	// TenantID is set by the handler from the "X-Tenant" header
+	TenantID uint `json:"-"`
+}
+
+type MagicLinkDTO struct {
+	Email string `json:"email" validate:"required,email"`
+	// TenantID is set by the handler from the "X-Tenant" header
+	TenantID uint `json:"-"`
+}
+
+type MagicLinkLoginDTO struct {
+	Token string `json:"token"`
+}
+
+type RefreshDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutDTO struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type RevokeSessionsDTO struct {
+	Email string `json:"email"`
+}
+
+type VerifyEmailDTO struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationDTO struct {
+	Email string `json:"email"`
+}
+
+type ForgotPasswordDTO struct {
+	Email string `json:"email" validate:"required,email"`
+	// TenantID is set by the handler from the "X-Tenant" header
+	TenantID uint `json:"-"`
+}
+
+type ResetPasswordDTO struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type ChangeEmailDTO struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
 }
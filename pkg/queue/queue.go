@@ -0,0 +1,78 @@
+// Package queue runs jobs in the background on a fixed number of workers,
+// for work which should not hold up the reply to a request
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrFull   error = errors.New("queue is full")
+	ErrClosed error = errors.New("queue is closed")
+)
+
+// Queue holds up to size waiting jobs. Submitting to a full queue fails
+// instead of blocking, so a flood of requests cannot pile up work.
+type Queue struct {
+	mu      sync.RWMutex
+	jobs    chan func()
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// New starts the workers of a queue with room for size waiting jobs
+func New(workers, size int) *Queue {
+	q := &Queue{jobs: make(chan func(), size)}
+	q.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// Submit queues the job without waiting for it to run
+func (q *Queue) Submit(job func()) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrClosed
+	}
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrFull
+	}
+}
+
+// Close stops accepting jobs and waits until the queued ones have run or
+// ctx is done
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work() {
+	defer q.workers.Done()
+	for job := range q.jobs {
+		job()
+	}
+}
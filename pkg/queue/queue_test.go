@@ -0,0 +1,70 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/queue"
+)
+
+func Test_Queue_Full(t *testing.T) {
+	q := queue.New(1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	if err := q.Submit(func() { close(started); <-release }); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	<-started
+	if err := q.Submit(func() {}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := q.Submit(func() {}); !errors.Is(err, queue.ErrFull) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", queue.ErrFull, err)
+	}
+	close(release)
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func Test_Queue_CloseDrains(t *testing.T) {
+	q := queue.New(2, 10)
+	var ran atomic.Int32
+	for i := 0; i < 10; i++ {
+		if err := q.Submit(func() {
+			time.Sleep(time.Millisecond)
+			ran.Add(1)
+		}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ran.Load() != 10 {
+		t.Fatalf("expected 10 jobs to run before close returned, got %d", ran.Load())
+	}
+	if err := q.Submit(func() {}); !errors.Is(err, queue.ErrClosed) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", queue.ErrClosed, err)
+	}
+}
+
+func Test_Queue_CloseTimeout(t *testing.T) {
+	q := queue.New(1, 1)
+	release := make(chan struct{})
+	defer close(release)
+	if err := q.Submit(func() { <-release }); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error, expected: %s, got: %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package search scores how well a search query matches text, for
+// repositories without a database side fuzzy search. Trigrams are extracted
+// the way the Postgres pg_trgm extension does it, so scores are comparable
+// with its similarity functions.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Threshold is the lowest score counted as a match, both here and in the
+// Postgres search, which sets pg_trgm.word_similarity_threshold to it. It
+// is the default of pg_trgm's similarity threshold.
+const Threshold = 0.3
+
+// Trigrams returns the set of trigrams of s. Every word of letters and
+// digits is lower cased and padded with two spaces in front and one behind.
+func Trigrams(s string) map[string]struct{} {
+	res := map[string]struct{}{}
+	for _, word := range words(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			res[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return res
+}
+
+// Similarity returns the share of trigrams a and b have in common, from 0
+// for none to 1 for all
+func Similarity(a, b string) float64 {
+	return jaccard(Trigrams(a), Trigrams(b))
+}
+
+// WordSimilarity returns the best similarity between the query and the
+// whole text or any of its words, so short queries are not drowned out by
+// long text
+func WordSimilarity(query, text string) float64 {
+	trigrams := Trigrams(query)
+	best := jaccard(trigrams, Trigrams(text))
+	for _, word := range words(text) {
+		if s := jaccard(trigrams, Trigrams(word)); s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// Levenshtein returns the number of single character insertions, deletions
+// and substitutions turning a into b
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// Score rates how well the query matches the best of the fields, from 0 to
+// 1. It takes the higher of the trigram word similarity and the edit
+// distance similarity, which is kinder to typos in short words.
+func Score(query string, fields ...string) float64 {
+	query = strings.Join(words(query), " ")
+	if query == "" {
+		return 0
+	}
+	best := 0.0
+	for _, field := range fields {
+		if s := WordSimilarity(query, field); s > best {
+			best = s
+		}
+		candidates := append(words(field), strings.Join(words(field), " "))
+		for _, candidate := range candidates {
+			if s := editSimilarity(query, candidate); s > best {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+func editSimilarity(a, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(longest)
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for trigram := range a {
+		if _, ok := b[trigram]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// words splits s into lower cased runs of letters and digits
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
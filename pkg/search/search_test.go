@@ -0,0 +1,65 @@
+package search_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/search"
+)
+
+func Test_Trigrams(t *testing.T) {
+	got := search.Trigrams("Cat")
+	for _, trigram := range []string{"  c", " ca", "cat", "at "} {
+		if _, ok := got[trigram]; !ok {
+			t.Errorf("missing trigram %q", trigram)
+		}
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 trigrams, got %d: %v", len(got), got)
+	}
+}
+
+func Test_Similarity(t *testing.T) {
+	// pg_trgm: SELECT similarity('word', 'two words') = 0.36363637
+	if got := search.Similarity("word", "two words"); math.Abs(got-4.0/11) > 1e-6 {
+		t.Fatalf("expected 0.3636, got %f", got)
+	}
+	if got := search.Similarity("abc", "xyz"); got != 0 {
+		t.Fatalf("expected 0, got %f", got)
+	}
+}
+
+func Test_Levenshtein(t *testing.T) {
+	cases := map[[2]string]int{
+		{"kitten", "sitting"}: 3,
+		{"", "abc"}:           3,
+		{"same", "same"}:      0,
+		{"иван", "иванн"}:     1,
+	}
+	for c, expected := range cases {
+		if got := search.Levenshtein(c[0], c[1]); got != expected {
+			t.Errorf("%s -> %s: expected %d, got %d", c[0], c[1], expected, got)
+		}
+	}
+}
+
+func Test_Score(t *testing.T) {
+	cases := []struct {
+		query  string
+		fields []string
+		match  bool
+	}{
+		{"jon", []string{"John Smith", "john@test.com"}, true},
+		{"smiht", []string{"John Smith", "john@test.com"}, true},
+		{"john smith", []string{"John Smith", "john@test.com"}, true},
+		{"exmaple", []string{"Ana Petrova", "ana@example.com"}, true},
+		{"george", []string{"John Smith", "john@test.com"}, false},
+		{"", []string{"John Smith"}, false},
+	}
+	for _, c := range cases {
+		score := search.Score(c.query, c.fields...)
+		if (score >= search.Threshold) != c.match {
+			t.Errorf("%q in %v: unexpected score %f", c.query, c.fields, score)
+		}
+	}
+}
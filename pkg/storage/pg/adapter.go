@@ -2,6 +2,7 @@ package pg
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
 	"gorm.io/driver/postgres"
@@ -38,6 +39,26 @@ func (db *database) Migrate(dst ...interface{}) error {
 	return nil
 }
 
+// MigrateEmailVerification adds the email_verified column to a users table
+// created before email verification and marks the existing users verified,
+// so requiring verification does not lock them out. It runs before Migrate
+// and does nothing once the column exists.
+func (db *database) MigrateEmailVerification() error {
+	migrator := db.pg.Migrator()
+	if !migrator.HasTable(&model.User{}) || migrator.HasColumn(&model.User{}, "EmailVerified") {
+		return nil
+	}
+	return db.pg.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&model.User{}, "EmailVerified"); err != nil {
+			return fmt.Errorf("add column: %w", err)
+		}
+		if err := tx.Exec("UPDATE users SET email_verified = true").Error; err != nil {
+			return fmt.Errorf("db exec: %w", err)
+		}
+		return nil
+	})
+}
+
 // Connect initializes a gorm.DB object and connects to the postgres db
 func (db *database) Connect() error {
 	dsn := db.buildDSN()
@@ -56,15 +77,313 @@ func (db *database) Create(obj any) error {
 	return result.Error
 }
 
-func (db *database) GetUser(email string) (model.User, error) {
+func (db *database) GetUser(tenantID uint, email string) (model.User, error) {
+	var user model.User
+	res := db.pg.Where("tenant_id = ? AND email = ?", tenantID, email).First(&user)
+	if res.Error != nil {
+		return model.User{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return user, nil
+}
+
+func (db *database) GetUserByID(id uint) (model.User, error) {
 	var user model.User
-	res := db.pg.Where("email = ?", email).First(&user)
+	res := db.pg.First(&user, id)
 	if res.Error != nil {
 		return model.User{}, fmt.Errorf("db query: %w", res.Error)
 	}
 	return user, nil
 }
 
+func (db *database) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	var token model.RefreshToken
+	res := db.pg.Where("token_hash = ?", tokenHash).First(&token)
+	if res.Error != nil {
+		return model.RefreshToken{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return token, nil
+}
+
+// ConsumeRefreshToken marks the token as used. It reports false
+// if the token has already been used or revoked.
+func (db *database) ConsumeRefreshToken(id uint, usedAt time.Time) (bool, error) {
+	res := db.pg.Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", usedAt)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (db *database) RevokeRefreshTokenFamily(familyID string, revokedAt time.Time) error {
+	res := db.pg.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt)
+	if res.Error != nil {
+		return fmt.Errorf("db update: %w", res.Error)
+	}
+	return nil
+}
+
+func (db *database) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	res := db.pg.Model(&model.RevokedToken{}).Where("jti = ?", jti).Count(&count)
+	if res.Error != nil {
+		return false, fmt.Errorf("db query: %w", res.Error)
+	}
+	return count > 0, nil
+}
+
+// RevokeUserSessions stamps the user's sessions revocation time
+// and revokes all of the user's refresh tokens.
+func (db *database) RevokeUserSessions(userID uint, revokedAt time.Time) error {
+	return db.pg.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.User{}).
+			Where("id = ?", userID).
+			Update("sessions_revoked_at", revokedAt)
+		if res.Error != nil {
+			return fmt.Errorf("db update user: %w", res.Error)
+		}
+		res = tx.Model(&model.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", revokedAt)
+		if res.Error != nil {
+			return fmt.Errorf("db update refresh tokens: %w", res.Error)
+		}
+		return nil
+	})
+}
+
+func (db *database) PurgeExpiredTokens(now time.Time) error {
+	res := db.pg.Where("expires_at < ?", now).Delete(&model.RevokedToken{})
+	if res.Error != nil {
+		return fmt.Errorf("db delete revoked tokens: %w", res.Error)
+	}
+	res = db.pg.Unscoped().Where("expires_at < ?", now).Delete(&model.RefreshToken{})
+	if res.Error != nil {
+		return fmt.Errorf("db delete refresh tokens: %w", res.Error)
+	}
+	res = db.pg.Unscoped().Where("expires_at < ?", now).Delete(&model.AuthorizationCode{})
+	if res.Error != nil {
+		return fmt.Errorf("db delete authorization codes: %w", res.Error)
+	}
+	res = db.pg.Unscoped().Where("expires_at < ?", now).Delete(&model.PasswordResetToken{})
+	if res.Error != nil {
+		return fmt.Errorf("db delete password reset tokens: %w", res.Error)
+	}
+	return nil
+}
+
+func (db *database) GetAuthorizationCode(codeHash string) (model.AuthorizationCode, error) {
+	var code model.AuthorizationCode
+	res := db.pg.Where("code_hash = ?", codeHash).First(&code)
+	if res.Error != nil {
+		return model.AuthorizationCode{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return code, nil
+}
+
+// ConsumeAuthorizationCode marks the code as used by the access token with
+// the given id. It reports false if the code has already been used.
+func (db *database) ConsumeAuthorizationCode(id uint, usedAt time.Time, accessTokenID string) (bool, error) {
+	res := db.pg.Model(&model.AuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Updates(map[string]any{"used_at": usedAt, "access_token_id": accessTokenID})
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (db *database) GetOAuthClient(clientID string) (model.OAuthClient, error) {
+	var client model.OAuthClient
+	res := db.pg.Where("client_id = ?", clientID).First(&client)
+	if res.Error != nil {
+		return model.OAuthClient{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return client, nil
+}
+
+// MarkEmailVerified marks the user's email as verified. It reports false
+// if the user's email has changed or has already been verified.
+func (db *database) MarkEmailVerified(userID uint, email string) (bool, error) {
+	res := db.pg.Model(&model.User{}).
+		Where("id = ? AND email = ? AND email_verified = ?", userID, email, false).
+		Update("email_verified", true)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (db *database) GetPasswordResetToken(tokenHash string) (model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	res := db.pg.Where("token_hash = ?", tokenHash).First(&token)
+	if res.Error != nil {
+		return model.PasswordResetToken{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return token, nil
+}
+
+// ConsumePasswordResetToken marks the token as used together with every
+// other unused reset token of the same user, so no older link outlives the
+// reset. It reports false if the token has already been used.
+func (db *database) ConsumePasswordResetToken(id uint, usedAt time.Time) (bool, error) {
+	consumed := false
+	err := db.pg.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", id).
+			Update("used_at", usedAt)
+		if res.Error != nil {
+			return fmt.Errorf("db update: %w", res.Error)
+		}
+		if res.RowsAffected != 1 {
+			return nil
+		}
+		consumed = true
+
+		owner := tx.Model(&model.PasswordResetToken{}).Select("user_id").Where("id = ?", id)
+		res = tx.Model(&model.PasswordResetToken{}).
+			Where("user_id = (?) AND used_at IS NULL", owner).
+			Update("used_at", usedAt)
+		if res.Error != nil {
+			return fmt.Errorf("db update: %w", res.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return consumed, nil
+}
+
+func (db *database) UpdatePasswordHash(userID uint, passwordHash string) error {
+	res := db.pg.Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("password_hash", passwordHash)
+	if res.Error != nil {
+		return fmt.Errorf("db update: %w", res.Error)
+	}
+	return nil
+}
+
+// UpdateEmail replaces the user's email with an already verified one. It
+// reports false if the user's email is no longer oldEmail.
+func (db *database) UpdateEmail(userID uint, oldEmail, newEmail string) (bool, error) {
+	res := db.pg.Model(&model.User{}).
+		Where("id = ? AND email = ?", userID, oldEmail).
+		Updates(map[string]any{"email": newEmail, "email_verified": true})
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// GetPasswordHistory returns the user's most recent previous password hashes
+func (db *database) GetPasswordHistory(userID uint, limit int) ([]model.PasswordHistory, error) {
+	var history []model.PasswordHistory
+	res := db.pg.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&history)
+	if res.Error != nil {
+		return nil, fmt.Errorf("db query: %w", res.Error)
+	}
+	return history, nil
+}
+
+// SetTOTPSecret stores the secret of a pending TOTP enrollment. It reports
+// false if the user has already enabled TOTP.
+func (db *database) SetTOTPSecret(userID uint, secret string) (bool, error) {
+	res := db.pg.Model(&model.User{}).
+		Where("id = ? AND totp_enabled_at IS NULL", userID).
+		Update("totp_secret", secret)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// EnableTOTP enables the pending TOTP enrollment and replaces the user's
+// recovery codes. It reports false if TOTP has already been enabled.
+func (db *database) EnableTOTP(userID uint, enabledAt time.Time, step int64, codes []model.RecoveryCode) (bool, error) {
+	enabled := false
+	err := db.pg.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.User{}).
+			Where("id = ? AND totp_enabled_at IS NULL", userID).
+			Updates(map[string]any{"totp_enabled_at": enabledAt, "totp_last_step": step})
+		if res.Error != nil {
+			return fmt.Errorf("db update: %w", res.Error)
+		}
+		if res.RowsAffected != 1 {
+			return nil
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("db delete recovery codes: %w", err)
+		}
+		if err := tx.Create(&codes).Error; err != nil {
+			return fmt.Errorf("db create recovery codes: %w", err)
+		}
+		enabled = true
+		return nil
+	})
+	return enabled, err
+}
+
+// UpdateTOTPStep records the time step of an accepted TOTP code. It reports
+// false if the same or a later step has already been used.
+func (db *database) UpdateTOTPStep(userID uint, step int64) (bool, error) {
+	res := db.pg.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ConsumeRecoveryCode marks the user's recovery code as used. It reports
+// false if there is no such unused code.
+func (db *database) ConsumeRecoveryCode(userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	res := db.pg.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// GetWebAuthnCredentials returns the passkeys registered by the user
+func (db *database) GetWebAuthnCredentials(userID uint) ([]model.WebAuthnCredential, error) {
+	var creds []model.WebAuthnCredential
+	res := db.pg.Where("user_id = ?", userID).Find(&creds)
+	if res.Error != nil {
+		return nil, fmt.Errorf("db query: %w", res.Error)
+	}
+	return creds, nil
+}
+
+func (db *database) GetWebAuthnCredential(credentialID string) (model.WebAuthnCredential, error) {
+	var cred model.WebAuthnCredential
+	res := db.pg.Where("credential_id = ?", credentialID).First(&cred)
+	if res.Error != nil {
+		return model.WebAuthnCredential{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return cred, nil
+}
+
+// UpdateWebAuthnSignCount records a login with the passkey. It reports false
+// if the signature counter did not increase, authenticators which keep no
+// counter always report zero.
+func (db *database) UpdateWebAuthnSignCount(id uint, signCount uint32, usedAt time.Time) (bool, error) {
+	res := db.pg.Model(&model.WebAuthnCredential{}).
+		Where("id = ? AND (sign_count < ? OR (sign_count = 0 AND ? = 0))", id, signCount, signCount).
+		Updates(map[string]any{"sign_count": signCount, "last_used_at": usedAt})
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
 func (db *database) buildDSN() string {
 	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
@@ -0,0 +1,201 @@
+package pg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MigrateTenants drops the global unique constraint on user emails left by
+// versions before organizations. Emails are unique per tenant instead.
+func (db *database) MigrateTenants() error {
+	migrator := db.pg.Migrator()
+	for _, name := range []string{"users_email_key", "uni_users_email"} {
+		if !migrator.HasConstraint(&model.User{}, name) {
+			continue
+		}
+		if err := migrator.DropConstraint(&model.User{}, name); err != nil {
+			return fmt.Errorf("drop constraint %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (db *database) GetOrganization(slug string) (model.Organization, error) {
+	var org model.Organization
+	res := db.pg.Where("slug = ?", slug).First(&org)
+	if res.Error != nil {
+		return model.Organization{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return org, nil
+}
+
+func (db *database) GetOrganizationByID(id uint) (model.Organization, error) {
+	var org model.Organization
+	res := db.pg.First(&org, id)
+	if res.Error != nil {
+		return model.Organization{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return org, nil
+}
+
+func (db *database) GetOrganizations() ([]model.Organization, error) {
+	var orgs []model.Organization
+	res := db.pg.Order("slug").Find(&orgs)
+	if res.Error != nil {
+		return nil, fmt.Errorf("db query: %w", res.Error)
+	}
+	return orgs, nil
+}
+
+func (db *database) GetMembership(organizationID, userID uint) (model.Membership, error) {
+	var membership model.Membership
+	res := db.pg.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&membership)
+	if res.Error != nil {
+		return model.Membership{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return membership, nil
+}
+
+func (db *database) GetMembers(organizationID uint) ([]model.Member, error) {
+	var members []model.Member
+	res := db.pg.Table("memberships").
+		Select("memberships.*, users.email, users.first_name, users.last_name").
+		Joins("JOIN users ON users.id = memberships.user_id AND users.deleted_at IS NULL").
+		Where("memberships.organization_id = ?", organizationID).
+		Order("memberships.created_at").
+		Scan(&members)
+	if res.Error != nil {
+		return nil, fmt.Errorf("db query: %w", res.Error)
+	}
+	return members, nil
+}
+
+func (db *database) UpdateMemberRole(organizationID, userID uint, role string) (bool, error) {
+	res := db.pg.Model(&model.Membership{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Update("role", role)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (db *database) DeleteMembership(organizationID, userID uint) (bool, error) {
+	res := db.pg.
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Delete(&model.Membership{})
+	if res.Error != nil {
+		return false, fmt.Errorf("db delete: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (db *database) GetInvitation(id uint) (model.Invitation, error) {
+	var invitation model.Invitation
+	res := db.pg.First(&invitation, id)
+	if res.Error != nil {
+		return model.Invitation{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return invitation, nil
+}
+
+func (db *database) GetInvitationByToken(tokenID string) (model.Invitation, error) {
+	var invitation model.Invitation
+	res := db.pg.Where("token_id = ?", tokenID).First(&invitation)
+	if res.Error != nil {
+		return model.Invitation{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return invitation, nil
+}
+
+func (db *database) GetInvitations(organizationID uint) ([]model.Invitation, error) {
+	var invitations []model.Invitation
+	res := db.pg.Where("organization_id = ?", organizationID).Order("created_at DESC").Find(&invitations)
+	if res.Error != nil {
+		return nil, fmt.Errorf("db query: %w", res.Error)
+	}
+	return invitations, nil
+}
+
+// RenewInvitation replaces the token of a pending invitation
+func (db *database) RenewInvitation(id uint, tokenID string, expiresAt time.Time) (bool, error) {
+	res := db.pg.Model(&model.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]any{"token_id": tokenID, "expires_at": expiresAt})
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (db *database) RevokeInvitation(id uint, revokedAt time.Time) (bool, error) {
+	res := db.pg.Model(&model.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// AcceptInvitation marks the invitation as accepted, as long as it is
+// pending and the token is its latest one, and makes the user a member of
+// the organization in the same transaction. A user without an ID is
+// registered first. Nothing is changed when any step fails.
+func (db *database) AcceptInvitation(id uint, tokenID string, acceptedAt time.Time, user *model.User, role string) (bool, error) {
+	accepted := false
+	err := db.pg.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Invitation{}).
+			Where("id = ? AND token_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id, tokenID).
+			Update("accepted_at", acceptedAt)
+		if res.Error != nil {
+			return fmt.Errorf("db update: %w", res.Error)
+		}
+		if res.RowsAffected != 1 {
+			return nil
+		}
+		if err := createMember(tx, user, role); err != nil {
+			return err
+		}
+		accepted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return accepted, nil
+}
+
+// CreateUser stores the user and, for users of an organization, their
+// membership with the role in one transaction
+func (db *database) CreateUser(user *model.User, role string) error {
+	return db.pg.Transaction(func(tx *gorm.DB) error {
+		return createMember(tx, user, role)
+	})
+}
+
+// createMember inserts the user unless it has an ID already and adds it to
+// the organization of its tenant. Existing memberships are kept.
+func createMember(tx *gorm.DB, user *model.User, role string) error {
+	if user.ID == 0 {
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("db create user: %w", err)
+		}
+	}
+	if user.TenantID == 0 {
+		return nil
+	}
+	membership := model.Membership{
+		OrganizationID: user.TenantID,
+		UserID:         user.ID,
+		Role:           role,
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&membership).Error; err != nil {
+		return fmt.Errorf("db create membership: %w", err)
+	}
+	return nil
+}
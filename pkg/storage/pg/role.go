@@ -0,0 +1,95 @@
+package pg
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+// GetUserRoles returns the roles assigned to the user together with their
+// permissions
+func (db *database) GetUserRoles(userID uint) ([]model.Role, error) {
+	var roles []model.Role
+	res := db.pg.Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles)
+	if res.Error != nil {
+		return nil, fmt.Errorf("db query: %w", res.Error)
+	}
+	return roles, nil
+}
+
+// GetRoles returns all roles together with their permissions
+func (db *database) GetRoles() ([]model.Role, error) {
+	var roles []model.Role
+	res := db.pg.Preload("Permissions").Order("name").Find(&roles)
+	if res.Error != nil {
+		return nil, fmt.Errorf("db query: %w", res.Error)
+	}
+	return roles, nil
+}
+
+// SaveRole creates the role or updates the one with the same name, and
+// replaces its permissions. Missing permissions are created.
+func (db *database) SaveRole(role model.Role, permissions []string) error {
+	return db.pg.Transaction(func(tx *gorm.DB) error {
+		perms := make([]model.Permission, 0, len(permissions))
+		for _, name := range permissions {
+			var perm model.Permission
+			if res := tx.Where(model.Permission{Name: name}).FirstOrCreate(&perm); res.Error != nil {
+				return fmt.Errorf("db save permission: %w", res.Error)
+			}
+			perms = append(perms, perm)
+		}
+
+		var stored model.Role
+		res := tx.Where(model.Role{Name: role.Name}).
+			Assign(model.Role{Description: role.Description}).
+			FirstOrCreate(&stored)
+		if res.Error != nil {
+			return fmt.Errorf("db save role: %w", res.Error)
+		}
+		if err := tx.Model(&stored).Association("Permissions").Replace(perms); err != nil {
+			return fmt.Errorf("db replace permissions: %w", err)
+		}
+		return nil
+	})
+}
+
+// AssignRole gives the user the named role. It reports false if there is no
+// such role.
+func (db *database) AssignRole(userID uint, roleName string) (bool, error) {
+	var role model.Role
+	res := db.pg.Where("name = ?", roleName).First(&role)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if res.Error != nil {
+		return false, fmt.Errorf("db query: %w", res.Error)
+	}
+	res = db.pg.Exec(
+		"INSERT INTO user_roles (user_id, role_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		userID, role.ID,
+	)
+	if res.Error != nil {
+		return false, fmt.Errorf("db insert: %w", res.Error)
+	}
+	return true, nil
+}
+
+// RemoveRole takes the named role away from the user. It reports false if
+// the user did not have it.
+func (db *database) RemoveRole(userID uint, roleName string) (bool, error) {
+	res := db.pg.Exec(
+		"DELETE FROM user_roles USING roles WHERE user_roles.role_id = roles.id AND user_roles.user_id = ? AND roles.name = ?",
+		userID, roleName,
+	)
+	if res.Error != nil {
+		return false, fmt.Errorf("db delete: %w", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
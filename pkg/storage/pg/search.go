@@ -0,0 +1,62 @@
+package pg
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/search"
+	"gorm.io/gorm"
+)
+
+// MigrateSearch enables the pg_trgm extension and indexes the names and
+// emails of users for similarity search. Creating the extension needs a
+// role allowed to do so.
+func (db *database) MigrateSearch() error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING gin ((first_name || ' ' || last_name) gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING gin (email gin_trgm_ops)",
+	}
+	for _, statement := range statements {
+		if err := db.pg.Exec(statement).Error; err != nil {
+			return fmt.Errorf("db exec: %w", err)
+		}
+	}
+	return nil
+}
+
+// SearchUsers ranks the users of the tenant by the word similarity of the
+// query to their full names and emails. Matches have to score at least
+// search.Threshold, like in the in-memory scorer. The <% operator, which
+// uses the trigram indexes, is held to the same threshold by setting
+// pg_trgm.word_similarity_threshold for the transaction; its default of 0.6
+// would drop most typos.
+func (db *database) SearchUsers(tenantID uint, query string, limit int) ([]model.UserMatch, error) {
+	var matches []model.UserMatch
+	err := db.pg.Transaction(func(tx *gorm.DB) error {
+		threshold := strconv.FormatFloat(search.Threshold, 'f', -1, 64)
+		if err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)", threshold).Error; err != nil {
+			return fmt.Errorf("set similarity threshold: %w", err)
+		}
+		return tx.Raw(`
+			SELECT * FROM (
+				SELECT *, GREATEST(
+					word_similarity(@query, first_name || ' ' || last_name),
+					word_similarity(@query, email)
+				) AS score
+				FROM users
+				WHERE tenant_id = @tenant AND deleted_at IS NULL
+					AND (@query <% (first_name || ' ' || last_name) OR @query <% email)
+			) AS matches
+			WHERE score >= @threshold
+			ORDER BY score DESC, id
+			LIMIT @limit`,
+			map[string]any{"query": query, "tenant": tenantID, "threshold": search.Threshold, "limit": limit},
+		).Scan(&matches).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db query: %w", err)
+	}
+	return matches, nil
+}
@@ -0,0 +1,95 @@
+package pg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/throttle"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReserveLoginAttempt counts an attempt for key as failed, unless the
+// failures counted so far leave it to wait. The row is locked, so parallel
+// attempts against the same key are counted one after the other.
+func (db *database) ReserveLoginAttempt(key string, now time.Time, window time.Duration, delay func(failures int) time.Duration) (time.Duration, error) {
+	var wait time.Duration
+	err := db.pg.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.LoginFailure{Key: key})
+		if res.Error != nil {
+			return fmt.Errorf("db create: %w", res.Error)
+		}
+		var failure model.LoginFailure
+		res = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&failure)
+		if res.Error != nil {
+			return fmt.Errorf("db query: %w", res.Error)
+		}
+		failure, wait = throttle.ReserveAttempt(failure, now, window, delay)
+		if wait > 0 {
+			return nil
+		}
+		res = tx.Model(&model.LoginFailure{}).Where("key = ?", key).
+			Updates(map[string]any{"count": failure.Count, "last_failed_at": now})
+		if res.Error != nil {
+			return fmt.Errorf("db update: %w", res.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("db transaction: %w", err)
+	}
+	return wait, nil
+}
+
+// ResetLoginFailures forgets the failed logins of key
+func (db *database) ResetLoginFailures(key string) error {
+	res := db.pg.Where("key = ?", key).Delete(&model.LoginFailure{})
+	if res.Error != nil {
+		return fmt.Errorf("db delete: %w", res.Error)
+	}
+	return nil
+}
+
+// TakeRateToken takes a token from the bucket of key
+func (db *database) TakeRateToken(key string, limit throttle.Limit, now time.Time) (throttle.Result, error) {
+	var result throttle.Result
+	err := db.pg.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.RateBucket{Key: key, Tokens: float64(limit.Burst), CheckedAt: now})
+		if res.Error != nil {
+			return fmt.Errorf("db create: %w", res.Error)
+		}
+		var bucket model.RateBucket
+		res = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&bucket)
+		if res.Error != nil {
+			return fmt.Errorf("db query: %w", res.Error)
+		}
+		var tokens float64
+		tokens, result = limit.Take(bucket.Tokens, bucket.CheckedAt, now)
+		res = tx.Model(&model.RateBucket{}).Where("key = ?", key).
+			Updates(map[string]any{"tokens": tokens, "checked_at": now})
+		if res.Error != nil {
+			return fmt.Errorf("db update: %w", res.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return throttle.Result{}, fmt.Errorf("db transaction: %w", err)
+	}
+	return result, nil
+}
+
+// PurgeThrottle removes failures and buckets not touched since before
+func (db *database) PurgeThrottle(before time.Time) error {
+	res := db.pg.Where("last_failed_at < ?", before).Delete(&model.LoginFailure{})
+	if res.Error != nil {
+		return fmt.Errorf("db delete login failures: %w", res.Error)
+	}
+	res = db.pg.Where("checked_at < ?", before).Delete(&model.RateBucket{})
+	if res.Error != nil {
+		return fmt.Errorf("db delete rate buckets: %w", res.Error)
+	}
+	return nil
+}
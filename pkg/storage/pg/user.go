@@ -0,0 +1,109 @@
+package pg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+	"gorm.io/gorm"
+)
+
+func (db *database) GetUsers(filter model.UserFilter) ([]model.User, error) {
+	query := db.pg.Where("tenant_id = ? AND id > ?", filter.TenantID, filter.AfterID)
+	switch filter.Status {
+	case model.UserStatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	case model.UserStatusActive:
+		query = query.Where("disabled_at IS NULL AND email_verified")
+	case model.UserStatusUnverified:
+		query = query.Where("disabled_at IS NULL AND NOT email_verified")
+	case model.UserStatusDisabled:
+		query = query.Where("disabled_at IS NOT NULL")
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if filter.Domain != "" {
+		query = query.Where("lower(split_part(email, '@', 2)) = lower(?)", filter.Domain)
+	}
+
+	var users []model.User
+	res := query.Order("id").Limit(filter.Limit).Find(&users)
+	if res.Error != nil {
+		return nil, fmt.Errorf("db query: %w", res.Error)
+	}
+	return users, nil
+}
+
+// GetUserUnscoped finds the user with the given ID, including soft deleted
+// ones
+func (db *database) GetUserUnscoped(id uint) (model.User, error) {
+	var user model.User
+	res := db.pg.Unscoped().First(&user, id)
+	if res.Error != nil {
+		return model.User{}, fmt.Errorf("db query: %w", res.Error)
+	}
+	return user, nil
+}
+
+func (db *database) UpdateUser(id uint, fields map[string]any) (bool, error) {
+	res := db.pg.Model(&model.User{}).Where("id = ?", id).Updates(fields)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (db *database) SetUserDisabled(id uint, disabledAt *time.Time) (bool, error) {
+	res := db.pg.Model(&model.User{}).Where("id = ?", id).Update("disabled_at", disabledAt)
+	if res.Error != nil {
+		return false, fmt.Errorf("db update: %w", res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// DeleteUser soft deletes the user, keeping the row and its email address
+// taken. A hard delete removes the user and everything stored for it.
+func (db *database) DeleteUser(id uint, hard bool) (bool, error) {
+	if !hard {
+		res := db.pg.Delete(&model.User{}, id)
+		if res.Error != nil {
+			return false, fmt.Errorf("db delete: %w", res.Error)
+		}
+		return res.RowsAffected == 1, nil
+	}
+
+	var deleted bool
+	err := db.pg.Transaction(func(tx *gorm.DB) error {
+		for _, related := range []any{
+			&model.RefreshToken{},
+			&model.RevokedToken{},
+			&model.PasswordResetToken{},
+			&model.PasswordHistory{},
+			&model.AuthorizationCode{},
+			&model.RecoveryCode{},
+			&model.WebAuthnCredential{},
+			&model.Membership{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", id).Delete(related).Error; err != nil {
+				return fmt.Errorf("delete %T: %w", related, err)
+			}
+		}
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", id).Error; err != nil {
+			return fmt.Errorf("delete user roles: %w", err)
+		}
+		res := tx.Unscoped().Delete(&model.User{}, id)
+		if res.Error != nil {
+			return fmt.Errorf("delete user: %w", res.Error)
+		}
+		deleted = res.RowsAffected == 1
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("db transaction: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,97 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+type memoryStore struct {
+	mu       sync.Mutex
+	failures map[string]model.LoginFailure
+	buckets  map[string]model.RateBucket
+}
+
+// NewMemoryStore is a constructor function for a throttling store kept in
+// process memory. Its counters are not shared between replicas, use the
+// Postgres store for that.
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		failures: map[string]model.LoginFailure{},
+		buckets:  map[string]model.RateBucket{},
+	}
+}
+
+// ReserveLoginAttempt counts an attempt for key as failed, unless the
+// failures counted so far leave it to wait. Then it returns the wait and
+// counts nothing. The count starts over when the previous failure is older
+// than window.
+func (s *memoryStore) ReserveLoginAttempt(key string, now time.Time, window time.Duration, delay func(failures int) time.Duration) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failure, wait := ReserveAttempt(s.failures[key], now, window, delay)
+	if wait > 0 {
+		return wait, nil
+	}
+	failure.Key = key
+	s.failures[key] = failure
+	return 0, nil
+}
+
+// GetLoginFailure returns the failed logins counted for key, a zero count
+// when there are none
+func (s *memoryStore) GetLoginFailure(key string) (model.LoginFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failure, ok := s.failures[key]
+	if !ok {
+		return model.LoginFailure{Key: key}, nil
+	}
+	return failure, nil
+}
+
+// ResetLoginFailures forgets the failed logins of key
+func (s *memoryStore) ResetLoginFailures(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+// TakeRateToken takes a token from the bucket of key
+func (s *memoryStore) TakeRateToken(key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = model.RateBucket{Key: key, Tokens: float64(limit.Burst), CheckedAt: now}
+	}
+	tokens, res := limit.Take(bucket.Tokens, bucket.CheckedAt, now)
+	bucket.Tokens = tokens
+	bucket.CheckedAt = now
+	s.buckets[key] = bucket
+	return res, nil
+}
+
+// PurgeThrottle removes failures and buckets not touched since before
+func (s *memoryStore) PurgeThrottle(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, failure := range s.failures {
+		if failure.LastFailedAt.Before(before) {
+			delete(s.failures, key)
+		}
+	}
+	for key, bucket := range s.buckets {
+		if bucket.CheckedAt.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package throttle
+
+import (
+	"math"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/model"
+)
+
+// Limit describes a token bucket holding up to Burst tokens, refilled with
+// Rate tokens per second. Each request takes one token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute allows n requests a minute with bursts of up to burst requests
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket
+	Limit int
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is the time until the next token when the request was
+	// refused
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+}
+
+// Take refills a bucket which held tokens when it was last checked and
+// removes a token from it. It returns the tokens left in the bucket. A new
+// bucket starts with Burst tokens.
+func (l Limit) Take(tokens float64, checkedAt, now time.Time) (float64, Result) {
+	burst := float64(l.Burst)
+	if elapsed := now.Sub(checkedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*l.Rate)
+	}
+
+	res := Result{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.refill(1 - tokens)
+	}
+	res.Remaining = int(tokens)
+	res.Reset = l.refill(burst - tokens)
+	return tokens, res
+}
+
+// refill returns the time it takes to add the given number of tokens
+func (l Limit) refill(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.Rate * float64(time.Second)))
+}
+
+// ReserveAttempt applies an attempt to the failures of an account. It
+// returns how long the account still has to wait, leaving failure as it
+// is, or failure with the attempt counted.
+func ReserveAttempt(failure model.LoginFailure, now time.Time, window time.Duration, delay func(failures int) time.Duration) (model.LoginFailure, time.Duration) {
+	if now.Sub(failure.LastFailedAt) > window {
+		failure.Count = 0
+	}
+	if failure.Count > 0 {
+		if wait := failure.LastFailedAt.Add(delay(failure.Count)).Sub(now); wait > 0 {
+			return failure, wait
+		}
+	}
+	failure.Count++
+	failure.LastFailedAt = now
+	return failure, 0
+}
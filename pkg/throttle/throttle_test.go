@@ -0,0 +1,112 @@
+package throttle_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/throttle"
+)
+
+func Test_MemoryStore_TakeRateToken(t *testing.T) {
+	store := throttle.NewMemoryStore()
+	limit := throttle.PerMinute(30, 3)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		res, err := store.TakeRateToken("client", limit, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !res.Allowed || res.Remaining != i {
+			t.Fatalf("expected allowed with %d remaining, got %+v", i, res)
+		}
+	}
+
+	res, _ := store.TakeRateToken("client", limit, now)
+	if res.Allowed {
+		t.Fatal("expected empty bucket to refuse")
+	}
+	if res.RetryAfter != 2*time.Second || res.Reset != 6*time.Second {
+		t.Errorf("unexpected wait: %+v", res)
+	}
+
+	if res, _ := store.TakeRateToken("other", limit, now); !res.Allowed {
+		t.Error("expected buckets to be separate per key")
+	}
+
+	res, _ = store.TakeRateToken("client", limit, now.Add(2*time.Second))
+	if !res.Allowed || res.Remaining != 0 {
+		t.Errorf("expected a refilled token, got %+v", res)
+	}
+}
+
+func Test_MemoryStore_LoginFailures(t *testing.T) {
+	store := throttle.NewMemoryStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	delay := func(failures int) time.Duration {
+		if failures < 2 {
+			return 0
+		}
+		return 10 * time.Minute
+	}
+
+	store.ReserveLoginAttempt("account", now, time.Hour, delay)
+	store.ReserveLoginAttempt("account", now.Add(time.Minute), time.Hour, delay)
+	failure, _ := store.GetLoginFailure("account")
+	if failure.Count != 2 {
+		t.Errorf("expected 2 failures, got %d", failure.Count)
+	}
+
+	wait, _ := store.ReserveLoginAttempt("account", now.Add(2*time.Minute), time.Hour, delay)
+	if wait != 9*time.Minute {
+		t.Errorf("expected to wait 9 minutes, got %s", wait)
+	}
+	if failure, _ = store.GetLoginFailure("account"); failure.Count != 2 {
+		t.Errorf("expected the refused attempt not to count, got %d", failure.Count)
+	}
+
+	wait, _ = store.ReserveLoginAttempt("account", now.Add(3*time.Hour), time.Hour, delay)
+	failure, _ = store.GetLoginFailure("account")
+	if wait != 0 || failure.Count != 1 {
+		t.Errorf("expected failures outside the window to be forgotten, got %d", failure.Count)
+	}
+
+	if err := store.PurgeThrottle(now.Add(4 * time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	failure, _ = store.GetLoginFailure("account")
+	if failure.Count != 0 {
+		t.Errorf("expected purged failures, got %d", failure.Count)
+	}
+}
+
+func Test_MemoryStore_ParallelAttempts(t *testing.T) {
+	store := throttle.NewMemoryStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	delay := func(failures int) time.Duration {
+		if failures < 3 {
+			return 0
+		}
+		return time.Minute
+	}
+
+	var wg sync.WaitGroup
+	var allowed atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if wait, _ := store.ReserveLoginAttempt("account", now, time.Hour, delay); wait == 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 3 {
+		t.Fatalf("expected 3 attempts let through, got %d", allowed.Load())
+	}
+}
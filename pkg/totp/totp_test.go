@@ -0,0 +1,68 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/totp"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 appendix B test vectors
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func Test_Code_RFC6238(t *testing.T) {
+	cases := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, c := range cases {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(c.unix, 0)))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if code != c.expected {
+			t.Fatalf("code does not match at %d, expected: %s, got: %s", c.unix, c.expected, code)
+		}
+	}
+}
+
+func Test_Validate_Skew(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	now := time.Unix(1700000000, 0)
+	previous, _ := totp.Code(secret, totp.Step(now)-1)
+	stale, _ := totp.Code(secret, totp.Step(now)-2)
+
+	step, ok := totp.Validate(secret, previous, now, 1)
+	if !ok || step != totp.Step(now)-1 {
+		t.Fatalf("code of the previous step rejected")
+	}
+	if _, ok := totp.Validate(secret, stale, now, 1); ok {
+		t.Fatalf("code outside the skew window accepted")
+	}
+	if _, ok := totp.Validate(secret, "12345", now, 1); ok {
+		t.Fatalf("short code accepted")
+	}
+}
+
+func Test_URI(t *testing.T) {
+	uri := totp.URI("Fuzzy", "penko@test.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/Fuzzy:penko@test.com?") {
+		t.Fatalf("unexpected uri: %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=Fuzzy", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Fatalf("uri %s misses %s", uri, part)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrVerification error = errors.New("webauthn verification failed")
+
+// clientData is the CollectedClientData the browser signs over
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// VerifyRegistration runs the registration ceremony checks of WebAuthn
+// Level 2, section 7.1. The attestation statement is not verified since
+// none is requested, the credential is trusted on first use.
+func (rp *relyingParty) VerifyRegistration(cred AttestationCredential, challenge []byte) (Credential, error) {
+	if cred.Type != "public-key" {
+		return Credential{}, fmt.Errorf("%w: credential type %q", ErrVerification, cred.Type)
+	}
+	if err := rp.verifyClientData(cred.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return Credential{}, err
+	}
+
+	item, rest, err := decodeCBOR(cred.Response.AttestationObject)
+	if err != nil || len(rest) != 0 {
+		return Credential{}, fmt.Errorf("%w: malformed attestation object", ErrVerification)
+	}
+	attestation, ok := item.(map[any]any)
+	if !ok {
+		return Credential{}, fmt.Errorf("%w: malformed attestation object", ErrVerification)
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return Credential{}, fmt.Errorf("%w: attestation object without authData", ErrVerification)
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return Credential{}, err
+	}
+	if authData.flags&flagAttestedCredData == 0 {
+		return Credential{}, fmt.Errorf("%w: no attested credential data", ErrVerification)
+	}
+	if len(cred.RawID) != 0 && !bytes.Equal(cred.RawID, authData.credentialID) {
+		return Credential{}, fmt.Errorf("%w: credential id mismatch", ErrVerification)
+	}
+	if _, err := parsePublicKey(authData.publicKey); err != nil {
+		return Credential{}, fmt.Errorf("%w: %w", ErrVerification, err)
+	}
+
+	return Credential{
+		ID:           authData.credentialID,
+		PublicKey:    authData.publicKey,
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&flagUserVerified != 0,
+		Transports:   cred.Response.Transports,
+	}, nil
+}
+
+// VerifyAssertion runs the authentication ceremony checks of WebAuthn
+// Level 2, section 7.2 against the stored COSE_Key of the credential.
+// Comparing the signature counter is left to the caller.
+func (rp *relyingParty) VerifyAssertion(cred AssertionCredential, challenge, storedKey []byte) (Assertion, error) {
+	if cred.Type != "public-key" {
+		return Assertion{}, fmt.Errorf("%w: credential type %q", ErrVerification, cred.Type)
+	}
+	if err := rp.verifyClientData(cred.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return Assertion{}, err
+	}
+	authData, err := rp.verifyAuthenticatorData(cred.Response.AuthenticatorData)
+	if err != nil {
+		return Assertion{}, err
+	}
+
+	key, err := parsePublicKey(storedKey)
+	if err != nil {
+		return Assertion{}, fmt.Errorf("%w: stored key: %w", ErrVerification, err)
+	}
+	clientDataHash := sha256.Sum256(cred.Response.ClientDataJSON)
+	signed := append(append([]byte{}, cred.Response.AuthenticatorData...), clientDataHash[:]...)
+	if !key.verify(signed, cred.Response.Signature) {
+		return Assertion{}, fmt.Errorf("%w: invalid signature", ErrVerification)
+	}
+
+	return Assertion{
+		CredentialID: cred.RawID,
+		UserHandle:   cred.Response.UserHandle,
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
+
+func (rp *relyingParty) verifyClientData(raw []byte, ceremony string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return fmt.Errorf("%w: malformed client data: %w", ErrVerification, err)
+	}
+	if cd.Type != ceremony {
+		return fmt.Errorf("%w: client data type %q", ErrVerification, cd.Type)
+	}
+	expected := base64.RawURLEncoding.EncodeToString(challenge)
+	if len(challenge) == 0 || subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(expected)) != 1 {
+		return fmt.Errorf("%w: challenge mismatch", ErrVerification)
+	}
+	if !rp.origins[cd.Origin] {
+		return fmt.Errorf("%w: origin %q not allowed", ErrVerification, cd.Origin)
+	}
+	if cd.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin ceremony", ErrVerification)
+	}
+	return nil
+}
+
+func (rp *relyingParty) verifyAuthenticatorData(raw []byte) (authenticatorData, error) {
+	authData, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return authenticatorData{}, err
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.id))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return authenticatorData{}, fmt.Errorf("%w: rp id hash mismatch", ErrVerification)
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return authenticatorData{}, fmt.Errorf("%w: user not present", ErrVerification)
+	}
+	return authData, nil
+}
@@ -0,0 +1,89 @@
+package webauthn_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn"
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn/webauthntest"
+)
+
+const (
+	rpID   = "fuzzy.local"
+	origin = "https://fuzzy.local"
+)
+
+var user = webauthn.UserEntity{ID: []byte{0, 0, 0, 0, 0, 0, 0, 7}, Name: "penko@test.com", DisplayName: "Penko Penkov"}
+
+func Test_Registration_And_Assertion(t *testing.T) {
+	rp := webauthn.NewRelyingParty(rpID, "Fuzzy", []string{origin})
+	authn := webauthntest.NewAuthenticator(rpID, origin)
+
+	challenge := []byte("registration-challenge-0123456789")
+	attestation, err := authn.Register(rp.CreationOptions(user, challenge, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// credentials travel to the server as JSON
+	raw, _ := json.Marshal(attestation)
+	var received webauthn.AttestationCredential
+	if err := json.Unmarshal(raw, &received); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cred, err := rp.VerifyRegistration(received, challenge)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(cred.ID) != string(attestation.RawID) || !cred.UserVerified {
+		t.Fatalf("unexpected credential: %+v", cred)
+	}
+
+	challenge = []byte("login-challenge-0123456789abcdef")
+	assertion, err := authn.Login(rp.RequestOptions(challenge, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	verified, err := rp.VerifyAssertion(assertion, challenge, cred.PublicKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if verified.SignCount != 1 || string(verified.UserHandle) != string(user.ID) {
+		t.Fatalf("unexpected assertion: %+v", verified)
+	}
+
+	tampered := assertion
+	tampered.Response.Signature = append([]byte{}, assertion.Response.Signature...)
+	tampered.Response.Signature[len(tampered.Response.Signature)-1] ^= 0xff
+	if _, err := rp.VerifyAssertion(tampered, challenge, cred.PublicKey); !errors.Is(err, webauthn.ErrVerification) {
+		t.Fatalf("tampered signature accepted: %v", err)
+	}
+}
+
+func Test_VerifyRegistration_Rejects(t *testing.T) {
+	rp := webauthn.NewRelyingParty(rpID, "Fuzzy", []string{origin})
+	challenge := []byte("registration-challenge-0123456789")
+
+	cases := []struct {
+		name      string
+		origin    string
+		rpID      string
+		challenge []byte
+	}{
+		{"wrong origin", "https://evil.example", rpID, challenge},
+		{"wrong rp id", origin, "evil.example", challenge},
+		{"wrong challenge", origin, rpID, []byte("another-challenge")},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			authn := webauthntest.NewAuthenticator(c.rpID, c.origin)
+			attestation, err := authn.Register(rp.CreationOptions(user, challenge, nil))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if _, err := rp.VerifyRegistration(attestation, c.challenge); !errors.Is(err, webauthn.ErrVerification) {
+				t.Fatalf("error does not match, expected: %s, got: %v", webauthn.ErrVerification, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package webauthntest provides a software authenticator which answers
+// WebAuthn ceremonies without any hardware, for use in tests
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dgdraganov/fuzzy-user-api/pkg/webauthn"
+)
+
+type authenticator struct {
+	// Origin and RPID are what the simulated browser reports, tests can
+	// change them to run a ceremony from the wrong site
+	Origin string
+	RPID   string
+	// UserVerified sets the UV flag in the authenticator data
+	UserVerified bool
+
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+// NewAuthenticator is a constructor function for a software authenticator
+// holding a single discoverable ES256 credential
+func NewAuthenticator(rpID, origin string) *authenticator {
+	return &authenticator{
+		Origin:       origin,
+		RPID:         rpID,
+		UserVerified: true,
+	}
+}
+
+// Register creates the credential and answers navigator.credentials.create()
+func (a *authenticator) Register(options webauthn.CreationOptions) (webauthn.AttestationCredential, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return webauthn.AttestationCredential{}, fmt.Errorf("generate key: %w", err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return webauthn.AttestationCredential{}, fmt.Errorf("read random: %w", err)
+	}
+	a.key = key
+	a.credentialID = id
+	a.userHandle = options.User.ID
+	a.signCount = 0
+
+	clientData, err := a.clientData("webauthn.create", options.Challenge)
+	if err != nil {
+		return webauthn.AttestationCredential{}, err
+	}
+
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	coseKey := encodeMap(
+		pair{int64(1), int64(2)},
+		pair{int64(3), int64(webauthn.AlgES256)},
+		pair{int64(-1), int64(1)},
+		pair{int64(-2), x},
+		pair{int64(-3), y},
+	)
+	attested := binary.BigEndian.AppendUint16(make([]byte, 16), uint16(len(id)))
+	attested = append(append(attested, id...), coseKey...)
+	authData := append(a.authData(0x40), attested...)
+
+	attestation := encodeMap(
+		pair{"fmt", "none"},
+		pair{"attStmt", encodedMap{}},
+		pair{"authData", authData},
+	)
+	return webauthn.AttestationCredential{
+		ID:    base64.RawURLEncoding.EncodeToString(id),
+		RawID: id,
+		Type:  "public-key",
+		Response: webauthn.AttestationResponse{
+			ClientDataJSON:    clientData,
+			AttestationObject: []byte(attestation),
+			Transports:        []string{"internal"},
+		},
+	}, nil
+}
+
+// Login signs the challenge and answers navigator.credentials.get()
+func (a *authenticator) Login(options webauthn.RequestOptions) (webauthn.AssertionCredential, error) {
+	if a.key == nil {
+		return webauthn.AssertionCredential{}, errors.New("no credential registered")
+	}
+	clientData, err := a.clientData("webauthn.get", options.Challenge)
+	if err != nil {
+		return webauthn.AssertionCredential{}, err
+	}
+	a.signCount++
+	authData := a.authData(0)
+
+	hash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), hash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return webauthn.AssertionCredential{}, fmt.Errorf("sign assertion: %w", err)
+	}
+	return webauthn.AssertionCredential{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: a.credentialID,
+		Type:  "public-key",
+		Response: webauthn.AssertionResponse{
+			ClientDataJSON:    clientData,
+			AuthenticatorData: authData,
+			Signature:         signature,
+			UserHandle:        a.userHandle,
+		},
+	}, nil
+}
+
+func (a *authenticator) clientData(ceremony string, challenge []byte) ([]byte, error) {
+	data, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.Origin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal client data: %w", err)
+	}
+	return data, nil
+}
+
+func (a *authenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	flags |= 0x01
+	if a.UserVerified {
+		flags |= 0x04
+	}
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
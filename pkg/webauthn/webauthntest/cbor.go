@@ -0,0 +1,58 @@
+package webauthntest
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// pair is a map entry, kept in a slice so the encoding is deterministic
+type pair struct {
+	key   any
+	value any
+}
+
+// encodedMap is an already encoded CBOR map
+type encodedMap []byte
+
+func encodeMap(pairs ...pair) encodedMap {
+	out := encodeHead(5, uint64(len(pairs)))
+	for _, p := range pairs {
+		out = append(out, encode(p.key)...)
+		out = append(out, encode(p.value)...)
+	}
+	return out
+}
+
+func encode(v any) []byte {
+	switch v := v.(type) {
+	case encodedMap:
+		if len(v) == 0 {
+			return encodeHead(5, 0)
+		}
+		return v
+	case int64:
+		if v < 0 {
+			return encodeHead(1, uint64(-1-v))
+		}
+		return encodeHead(0, uint64(v))
+	case []byte:
+		return append(encodeHead(2, uint64(len(v))), v...)
+	case string:
+		return append(encodeHead(3, uint64(len(v))), v...)
+	}
+	panic(fmt.Sprintf("webauthntest: cannot encode %T", v))
+}
+
+func encodeHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, arg)
+}